@@ -0,0 +1,239 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"CURATOR/database"
+	"CURATOR/internal/validator"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Agents directory (/agents). The info forms pick the agent
+// who reported the defect and the one it's assigned to.
+// An inactive agent isn't offered anymore
+
+type agentForm struct {
+	Name    string
+	Team    string
+	Contact string
+	Active  bool
+
+	validator.Validator
+}
+
+func newAgentForm(r *http.Request) agentForm {
+	return agentForm{
+		Name:    strings.TrimSpace(r.PostForm.Get("name")),
+		Team:    strings.TrimSpace(r.PostForm.Get("team")),
+		Contact: strings.TrimSpace(r.PostForm.Get("contact")),
+		Active:  r.PostForm.Get("active") == "true",
+	}
+}
+
+func (form *agentForm) check() {
+	form.CheckField(validator.NotBlank(form.Name), "name", "Cannot be empty")
+	form.CheckField(validator.MaxChars(form.Name, 50), "name", "50 characters max")
+	form.CheckField(validator.MaxChars(form.Team, 50), "team", "50 characters max")
+	form.CheckField(validator.MaxChars(form.Contact, 100), "contact", "100 characters max")
+}
+
+func (form *agentForm) agent(id int) *database.Agent {
+	return &database.Agent{
+		ID:      id,
+		Name:    form.Name,
+		Team:    form.Team,
+		Contact: form.Contact,
+		Active:  form.Active,
+	}
+}
+
+// Every agent with their open infos, and the form to add one
+func (app *application) agents(w http.ResponseWriter, r *http.Request) {
+	app.renderAgents(w, r, http.StatusOK, agentForm{Active: true})
+}
+
+func (app *application) renderAgents(w http.ResponseWriter, r *http.Request, status int, form agentForm) {
+	agents, err := app.store.Agents().AgentList(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Agents = agents
+	data.Form = form
+
+	app.render(w, r, status, "agents.tmpl.html", data)
+}
+
+func (app *application) agentCreatePost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	form := newAgentForm(r)
+	form.check()
+
+	if form.Valid() {
+		_, err = app.store.Agents().AgentInsert(r.Context(), form.agent(0))
+		if errors.Is(err, database.ErrDuplicateAgent) {
+			form.AddFieldError("name", "This agent already exists")
+		} else if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	if !form.Valid() {
+		app.renderAgents(w, r, http.StatusUnprocessableEntity, form)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Agent added")
+
+	http.Redirect(w, r, "/agents", http.StatusSeeOther)
+}
+
+// agentParam returns the agent of the URL, or writes
+// the error page and returns nil
+func (app *application) agentParam(w http.ResponseWriter, r *http.Request) *database.Agent {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return nil
+	}
+
+	agent, err := app.store.Agents().AgentGet(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return nil
+	}
+
+	return agent
+}
+
+// Open infos reported by or assigned to the agent
+func (app *application) agentView(w http.ResponseWriter, r *http.Request) {
+	agent := app.agentParam(w, r)
+	if agent == nil {
+		return
+	}
+
+	infos, err := app.store.Infos().InfoListAgent(r.Context(), agent.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Agent = agent
+	data.Infos = infos
+
+	app.render(w, r, http.StatusOK, "agentView.tmpl.html", data)
+}
+
+func (app *application) agentUpdate(w http.ResponseWriter, r *http.Request) {
+	agent := app.agentParam(w, r)
+	if agent == nil {
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Agent = agent
+	data.Form = agentForm{
+		Name:    agent.Name,
+		Team:    agent.Team,
+		Contact: agent.Contact,
+		Active:  agent.Active,
+	}
+
+	app.render(w, r, http.StatusOK, "agentUpdate.tmpl.html", data)
+}
+
+func (app *application) agentUpdatePost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	form := newAgentForm(r)
+	form.check()
+
+	if form.Valid() {
+		err = app.store.Agents().AgentUpdate(r.Context(), form.agent(id))
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+			return
+		} else if errors.Is(err, database.ErrDuplicateAgent) {
+			form.AddFieldError("name", "This agent already exists")
+		} else if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	if !form.Valid() {
+		data := app.newTemplateData(r)
+		data.Agent = form.agent(id)
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "agentUpdate.tmpl.html", data)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Agent updated")
+
+	http.Redirect(w, r, "/agents", http.StatusSeeOther)
+}
+
+// agentsField checks the reporter and the assignee of an info
+// form. They must be active agents, unless the info already had
+// them (current, nil for a new info). Every agent is returned
+// for the selects
+func (app *application) agentsField(r *http.Request, form *infoCreateForm, current *database.Info) ([]*database.Agent, *database.Agent, int, error) {
+	agents, err := app.store.Agents().AgentList(r.Context())
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	if current == nil {
+		current = &database.Info{}
+	}
+
+	var reporter *database.Agent
+	assignee := 0
+
+	for _, agent := range agents {
+		id := strconv.Itoa(agent.ID)
+
+		if id == form.Reporter && (agent.Active || agent.ID == current.ReporterID) {
+			reporter = agent
+		}
+		if id == form.Assignee && (agent.Active || agent.ID == current.AssigneeID) {
+			assignee = agent.ID
+		}
+	}
+
+	form.CheckField(form.Reporter == "" || reporter != nil,
+		"reporter", "Choose an agent")
+	form.CheckField(form.Assignee == "" || assignee != 0,
+		"assignee", "Choose an agent")
+
+	return agents, reporter, assignee, nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"CURATOR/database"
+)
+
+func TestAgents(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	ts := newTestServer(t, app.routes())
+
+	code, _, body := ts.get(t, "/agents")
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Dupont")
+
+	tests := []struct {
+		name string
+		form url.Values
+	}{
+		{"No name", url.Values{"name": {" "}}},
+		{"Same name", url.Values{"name": {"dupont"}}},
+		{"Long team", url.Values{"name": {"Martin"}, "team": {strings.Repeat("a", 51)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := ts.postForm(t, "/agents/create", tt.form)
+			assertStatus(t, code, http.StatusUnprocessableEntity)
+		})
+	}
+
+	code, _, _ = ts.postForm(t, "/agents/create", url.Values{
+		"name":    {"Martin"},
+		"team":    {"Maintenance Nord"},
+		"contact": {"06 12 34 56 78"},
+		"active":  {"true"},
+	})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	agents, _ := app.store.Agents().AgentList(ctx)
+	if len(agents) != 2 || agents[1].Name != "Martin" || !agents[1].Active {
+		t.Fatalf("got %+v", agents)
+	}
+	id := agents[1].ID
+	path := fmt.Sprintf("/agents/update/%d", id)
+
+	code, _, body = ts.get(t, path)
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Maintenance Nord")
+
+	code, _, _ = ts.get(t, "/agents/update/42")
+	assertStatus(t, code, http.StatusNotFound)
+
+	code, _, _ = ts.postForm(t, path, url.Values{"name": {"Dupont"}})
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+
+	// Unchecked: not active anymore
+	code, _, _ = ts.postForm(t, path, url.Values{"name": {"Martin"}, "team": {"Sud"}})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	agent, _ := app.store.Agents().AgentGet(ctx, id)
+	if agent.Active || agent.Team != "Sud" {
+		t.Errorf("got %+v", agent)
+	}
+}
+
+func TestInfoAgents(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	sID := addSource(t, app, "Billancourt")
+	martin := addAgent(t, app, "Martin")
+	ts := newTestServer(t, app.routes())
+	path := fmt.Sprintf("/source/%d/info/create", sID)
+
+	code, _, body := ts.get(t, path)
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, fmt.Sprintf(`<option value="%d">Martin</option>`, martin))
+
+	form := validInfoForm()
+	form.Set("assignee", fmt.Sprint(martin))
+	code, _, _ = ts.postForm(t, path, form)
+	assertStatus(t, code, http.StatusSeeOther)
+
+	infos, _ := app.store.Infos().InfoList(ctx, sID)
+	if len(infos) != 1 {
+		t.Fatalf("got %d infos; want 1", len(infos))
+	}
+	info, _ := app.store.Infos().InfoGet(ctx, infos[0].ID)
+	if info.ReporterID != 1 || info.Agent != "Dupont" ||
+		info.AssigneeID != martin || info.Assignee != "Martin" {
+		t.Fatalf("got %+v", info)
+	}
+
+	code, _, body = ts.get(t, fmt.Sprintf("/source/%d/info/view/%d", sID, info.ID))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, fmt.Sprintf(`<a href="/agents/view/%d">Martin</a>`, martin))
+
+	// Workload of both agents
+	code, _, body = ts.get(t, fmt.Sprintf("/agents/view/%d", martin))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Transfo 1")
+	assertContains(t, body, "Assigned to")
+
+	code, _, body = ts.get(t, "/agents/view/1")
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Reported by")
+
+	code, _, _ = ts.get(t, "/agents/view/42")
+	assertStatus(t, code, http.StatusNotFound)
+
+	// An inactive agent isn't offered to new infos...
+	app.store.Agents().AgentUpdate(ctx, &database.Agent{ID: martin, Name: "Martin"})
+
+	code, _, _ = ts.postForm(t, path, form)
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+
+	// ...but stays on the infos they had
+	update := fmt.Sprintf("/source/%d/info/update/%d", sID, info.ID)
+	code, _, body = ts.get(t, update)
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, fmt.Sprintf(`value="%d" selected>Martin`, martin))
+
+	form.Set("status", "affected")
+	code, _, _ = ts.postForm(t, update, form)
+	assertStatus(t, code, http.StatusSeeOther)
+
+	// Archived: not in the workload anymore
+	form.Set("status", "archived")
+	ts.postForm(t, update, form)
+
+	code, _, body = ts.get(t, fmt.Sprintf("/agents/view/%d", martin))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "No open info.")
+}
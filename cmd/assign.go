@@ -0,0 +1,210 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"CURATOR/database"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// An info is assigned to an agent and/or a team (the teams
+// of the directory). Every change of assignment is written
+// in the history of the info. "My infos" (/my) is the queue
+// of the agent whose name was chosen in the menu
+
+// dueLayout is the value of <input type="date">
+const dueLayout = "2006-01-02"
+
+// teamsOf returns the teams of the active agents by name, with
+// the one of the info (nil for a new one) even if it's gone
+func teamsOf(agents []*database.Agent, info *database.Info) []string {
+	seen := map[string]bool{}
+	teams := []string{}
+
+	for _, agent := range agents {
+		if agent.Active && agent.Team != "" && !seen[agent.Team] {
+			seen[agent.Team] = true
+			teams = append(teams, agent.Team)
+		}
+	}
+	if info != nil && info.Team != "" && !seen[info.Team] {
+		teams = append(teams, info.Team)
+	}
+
+	sort.Strings(teams)
+
+	return teams
+}
+
+// teamField checks the team of an info form: a team of the
+// directory, or the one the info already had
+func teamField(form *infoCreateForm, agents []*database.Agent, current *database.Info) {
+	if form.Team == "" {
+		return
+	}
+
+	for _, team := range teamsOf(agents, current) {
+		if team == form.Team {
+			return
+		}
+	}
+
+	form.AddFieldError("team", "Choose a team")
+}
+
+// dateField reads a date of an info form (due, planned),
+// "" is none
+func dateField(form *infoCreateForm, key, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+
+	t, err := time.Parse(dueLayout, value)
+	if err != nil {
+		form.AddFieldError(key, "Choose a date")
+	}
+
+	return t
+}
+
+// assignment is who has the info, as written in the history:
+// "Martin (Nord)", "Nord", or "-" for nobody
+func assignment(agent, team string) string {
+	switch {
+	case agent != "" && team != "":
+		return fmt.Sprintf("%s (%s)", agent, team)
+	case agent != "":
+		return agent
+	case team != "":
+		return team
+	}
+	return "-"
+}
+
+// auditAssign writes the change of assignment of the info,
+// nothing when it's the same
+func (app *application) auditAssign(r *http.Request, tx database.Store, info *database.Info, oldAgent, oldTeam string) error {
+	newAgent := ""
+	if info.AssigneeID != 0 {
+		agent, err := tx.Agents().AgentGet(r.Context(), info.AssigneeID)
+		if err != nil {
+			return err
+		}
+		newAgent = agent.Name
+	}
+
+	before, after := assignment(oldAgent, oldTeam), assignment(newAgent, info.Team)
+	if before == after {
+		return nil
+	}
+
+	return tx.Audit().AuditInsert(r.Context(), &database.AuditEntry{
+		Actor:    app.currentUser(r),
+		Action:   database.AuditInfoAssign,
+		SourceID: info.SourceID,
+		InfoID:   info.ID,
+		Detail:   before + " → " + after,
+	})
+}
+
+// Assign form of the info page. A waiting info becomes affected
+func (app *application) infoAssignPost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	info, err := app.store.Infos().InfoGet(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	form := infoCreateForm{
+		Assignee: r.PostForm.Get("assignee"),
+		Team:     r.PostForm.Get("team"),
+	}
+
+	agents, _, assigneeID, err := app.agentsField(r, &form, info)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	teamField(&form, agents, info)
+
+	if !form.Valid() {
+		app.clientError(w, r, http.StatusUnprocessableEntity)
+		return
+	}
+
+	oldAgent, oldTeam := info.Assignee, info.Team
+	info.AssigneeID = assigneeID
+	info.Team = form.Team
+
+	err = app.store.WithTx(r.Context(), func(tx database.Store) error {
+		err := tx.Infos().InfoAssign(r.Context(), id, assigneeID, form.Team)
+		if err != nil {
+			return err
+		}
+
+		return app.auditAssign(r, tx, info, oldAgent, oldTeam)
+	})
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Info assigned")
+
+	http.Redirect(w, r, fmt.Sprintf("/source/%d/info/view/%d",
+		info.SourceID, id), http.StatusSeeOther)
+}
+
+// My infos: what's assigned to the agent named in the menu, or
+// to their team. Without a name of the directory, the page asks
+// who the user is
+func (app *application) myInfos(w http.ResponseWriter, r *http.Request) {
+	agents, err := app.store.Agents().AgentList(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Agents = agents
+
+	for _, agent := range agents {
+		if strings.EqualFold(agent.Name, data.User) {
+			data.Agent = agent
+		}
+	}
+
+	if data.Agent != nil {
+		data.Infos, err = app.store.Infos().InfoListAssigned(r.Context(),
+			data.Agent.ID, data.Agent.Team)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	app.render(w, r, http.StatusOK, "myInfos.tmpl.html", data)
+}
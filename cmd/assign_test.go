@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"CURATOR/database"
+)
+
+func TestTeamsOf(t *testing.T) {
+	agents := []*database.Agent{
+		{Name: "Dupont", Team: "Sud", Active: true},
+		{Name: "Martin", Team: "Nord", Active: true},
+		{Name: "Petit", Team: "Nord", Active: true},
+		{Name: "Leroy", Team: "Ouest"},
+		{Name: "Moreau", Active: true},
+	}
+
+	got := strings.Join(teamsOf(agents, nil), ",")
+	if got != "Nord,Sud" {
+		t.Errorf("got %q; want Nord,Sud", got)
+	}
+
+	// The team of the info stays even without active agents
+	got = strings.Join(teamsOf(agents, &database.Info{Team: "Ouest"}), ",")
+	if got != "Nord,Ouest,Sud" {
+		t.Errorf("got %q; want Nord,Ouest,Sud", got)
+	}
+}
+
+func TestInfoAssign(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	sID := addSource(t, app, "Billancourt")
+	martin, err := app.store.Agents().AgentInsert(ctx,
+		&database.Agent{Name: "Martin", Team: "Nord", Active: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := newTestServer(t, app.routes())
+	path := fmt.Sprintf("/source/%d/info/create", sID)
+
+	tests := []struct {
+		name  string
+		field string
+		value string
+	}{
+		{"Unknown team", "team", "Est"},
+		{"Bad due date", "due", "31/12/2024"},
+		{"Bad planned date", "planned", "soon"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := validInfoForm()
+			form.Set(tt.field, tt.value)
+
+			code, _, _ := ts.postForm(t, path, form)
+			assertStatus(t, code, http.StatusUnprocessableEntity)
+		})
+	}
+
+	form := validInfoForm()
+	form.Set("assignee", fmt.Sprint(martin))
+	form.Set("team", "Nord")
+	form.Set("due", "2024-12-31")
+	code, _, _ := ts.postForm(t, path, form)
+	assertStatus(t, code, http.StatusSeeOther)
+
+	infos, _ := app.store.Infos().InfoList(ctx, sID)
+	id := infos[0].ID
+	info, _ := app.store.Infos().InfoGet(ctx, id)
+	if info.Team != "Nord" || info.Due.Format(dueLayout) != "2024-12-31" {
+		t.Fatalf("got %+v", info)
+	}
+
+	history, _ := app.store.Audit().AuditListInfo(ctx, id)
+	if len(history) != 1 || history[0].Detail != "- → Martin (Nord)" {
+		t.Fatalf("got %+v", history)
+	}
+
+	// Saved again without changes: nothing new in the history
+	view := fmt.Sprintf("/source/%d/info/view/%d", sID, id)
+	update := fmt.Sprintf("/source/%d/info/update/%d", sID, id)
+
+	code, _, body := ts.get(t, update)
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, `value="2024-12-31"`)
+
+	code, _, _ = ts.postForm(t, update, form)
+	assertStatus(t, code, http.StatusSeeOther)
+	if history, _ = app.store.Audit().AuditListInfo(ctx, id); len(history) != 1 {
+		t.Fatalf("got %d entries; want 1", len(history))
+	}
+
+	// Reassigned from the edit form...
+	form.Set("assignee", "1")
+	form.Set("team", "")
+	code, _, _ = ts.postForm(t, update, form)
+	assertStatus(t, code, http.StatusSeeOther)
+
+	history, _ = app.store.Audit().AuditListInfo(ctx, id)
+	if len(history) != 2 || history[0].Detail != "Martin (Nord) → Dupont" {
+		t.Fatalf("got %+v", history[0])
+	}
+
+	// ...or the info page, a waiting info becomes affected
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/source/%d/info/assign/%d", sID, id),
+		url.Values{"team": {"Nord"}})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	info, _ = app.store.Infos().InfoGet(ctx, id)
+	if info.AssigneeID != 0 || info.Team != "Nord" || info.Status != "affected" {
+		t.Errorf("got %+v", info)
+	}
+
+	code, _, body = ts.get(t, view)
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Dupont → Nord")
+
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/source/%d/info/assign/%d", sID, id),
+		url.Values{"assignee": {"42"}})
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/source/%d/info/assign/42", sID),
+		url.Values{"team": {"Nord"}})
+	assertStatus(t, code, http.StatusNotFound)
+}
+
+func TestMyInfos(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	sID := addSource(t, app, "Billancourt")
+	martin, _ := app.store.Agents().AgentInsert(ctx,
+		&database.Agent{Name: "Martin", Team: "Nord", Active: true})
+	ts := newTestServer(t, app.routes())
+
+	add := func(material string, priority int, assignee int, team, due string) {
+		t.Helper()
+
+		info := &database.Info{SourceID: sID, Agent: "Dupont", Material: material,
+			Detail: "Detail", Priority: priority, Status: "affected",
+			ReporterID: 1, AssigneeID: assignee, Team: team}
+		if due != "" {
+			info.Due, _ = time.Parse(dueLayout, due)
+		}
+		if _, err := app.store.Infos().InfoInsert(ctx, info); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	add("Later", 1, martin, "", "2024-12-31")
+	add("Sooner", 1, martin, "", "2024-06-01")
+	add("Whenever", 1, martin, "", "")
+	add("Urgent", 0, 0, "Nord", "")
+	add("Not mine", 0, 1, "Nord", "")
+	add("Other team", 0, 0, "Sud", "")
+
+	code, _, body := ts.get(t, "/my")
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Who are you?")
+
+	code, _, _ = ts.postForm(t, "/user", url.Values{"user": {"martin"}})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	code, _, body = ts.get(t, "/my")
+	assertStatus(t, code, http.StatusOK)
+
+	// Priority first, then the due date, none last
+	last := -1
+	for _, material := range []string{"Urgent", "Sooner", "Later", "Whenever"} {
+		i := strings.Index(body, material)
+		if i < 0 || i < last {
+			t.Errorf("%s is missing or not in order", material)
+		}
+		last = i
+	}
+	for _, material := range []string{"Not mine", "Other team"} {
+		if strings.Contains(body, material) {
+			t.Errorf("%s is shown", material)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"CURATOR/database"
+
+	"github.com/go-chi/chi/v5"
+)
+
+//
+// Board Handlers
+//
+
+// A column of the board, the infos of one status
+type boardColumn struct {
+	Status string
+	Infos  []*database.Info
+}
+
+// Filters of the board (/board?source=1&agent=2&archived=1),
+// 0 for every source or agent
+type boardFilter struct {
+	Source   int
+	Agent    int
+	Archived bool
+}
+
+// Infos by status for the morning meeting. Cards are dragged
+// from a column to another (ui/static/js/board.js)
+func (app *application) board(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := boardFilter{Archived: query.Get("archived") != ""}
+	filter.Source, _ = strconv.Atoi(query.Get("source"))
+	filter.Agent, _ = strconv.Atoi(query.Get("agent"))
+
+	infos, err := app.store.Infos().InfoBoard(r.Context(),
+		filter.Source, filter.Agent, filter.Archived)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	sources, err := app.store.Sources().MenuSource(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	agents, err := app.store.Agents().AgentList(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// Archived infos are hidden unless asked
+	columns := []*boardColumn{}
+	byStatus := map[string]*boardColumn{}
+
+	for _, status := range database.InfoStatuses {
+		if status == "archived" && !filter.Archived {
+			continue
+		}
+		column := &boardColumn{Status: status}
+		columns = append(columns, column)
+		byStatus[status] = column
+	}
+
+	for _, info := range infos {
+		if column, ok := byStatus[info.Status]; ok {
+			column.Infos = append(column.Infos, info)
+		}
+	}
+
+	data := app.newTemplateData(r)
+	data.Board = columns
+	data.Sources = sources
+	data.Agents = agents
+	data.Form = filter
+
+	app.render(w, r, http.StatusOK, "board.tmpl.html", data)
+}
+
+// infoValues is the edit form of info as the edit page
+// would send it, with the tags checked
+func infoValues(info *database.Info, tags []*database.Tag) url.Values {
+	values := url.Values{
+		"material": {info.Material},
+		"detail":   {info.Detail},
+		"priority": {strconv.Itoa(info.Priority)},
+		"estimate": {info.Estimate},
+		"status":   {info.Status},
+		"team":     {info.Team},
+	}
+
+	if info.ReporterID != 0 {
+		values.Set("reporter", strconv.Itoa(info.ReporterID))
+	}
+	if info.AssigneeID != 0 {
+		values.Set("assignee", strconv.Itoa(info.AssigneeID))
+	}
+	if !info.Due.IsZero() {
+		values.Set("due", info.Due.Format(dueLayout))
+	}
+	if !info.Planned.IsZero() {
+		values.Set("planned", info.Planned.Format(dueLayout))
+	}
+	if info.EquipmentID != 0 {
+		values.Set("equipment", strconv.Itoa(info.EquipmentID))
+	}
+	for _, tag := range tags {
+		values.Add("tags", strconv.Itoa(tag.ID))
+	}
+
+	return values
+}
+
+// A card dropped on another column: only the status changes,
+// checked and saved like the edit page (updateInfo)
+func (app *application) infoStatusPost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	sID, err := strconv.Atoi(chi.URLParam(r, "sid"))
+	if err != nil || sID < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	current, err := app.store.Infos().InfoGet(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if current.SourceID != sID {
+		app.notFound(w, r)
+		return
+	}
+
+	checked, err := app.store.Tags().TagsOfInfos(r.Context(), []int{id})
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	status := r.PostForm.Get("status")
+	r.PostForm = infoValues(current, checked[id])
+	r.PostForm.Set("status", status)
+
+	data, err := app.updateInfo(r, current)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// The info itself may be wrong (no reporter...), it
+	// has to be corrected on its edit page
+	if data != nil {
+		if wantsJSON(r) {
+			app.fieldErrors(w, r, data.Form.(infoCreateForm).FieldErrors)
+			return
+		}
+		app.clientError(w, r, http.StatusUnprocessableEntity)
+		return
+	}
+
+	if wantsJSON(r) {
+		app.writeJSON(w, r, http.StatusOK, map[string]any{
+			"info":   id,
+			"status": status,
+		})
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Info updated")
+
+	http.Redirect(w, r, fmt.Sprintf("/source/%d/info/view/%d",
+		current.SourceID, id), http.StatusSeeOther)
+}
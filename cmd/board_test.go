@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"CURATOR/database"
+)
+
+func TestBoard(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	billancourt := addSource(t, app, "Billancourt")
+	nanterre := addSource(t, app, "Nanterre")
+	martin := addAgent(t, app, "Martin")
+
+	addInfo(t, app, billancourt, "Transfo 1", "waiting")
+	addInfo(t, app, billancourt, "Old breaker", "archived")
+	id := addInfo(t, app, nanterre, "Bay 2", "done")
+
+	info, _ := app.store.Infos().InfoGet(ctx, id)
+	info.AssigneeID = martin
+	if err := app.store.Infos().InfoUpdate(ctx, info); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := newTestServer(t, app.routes())
+
+	tests := []struct {
+		name    string
+		urlPath string
+		shown   []string
+		hidden  []string
+	}{
+		{"Every info", "/board",
+			[]string{"Transfo 1", "Bay 2", `data-status="done"`},
+			[]string{"Old breaker", `data-status="archived"`}},
+		{"Archived", "/board?archived=1",
+			[]string{"Transfo 1", "Old breaker", `data-status="archived"`}, nil},
+		{"Source", fmt.Sprintf("/board?source=%d", nanterre),
+			[]string{"Bay 2"}, []string{"Transfo 1"}},
+		{"Agent", fmt.Sprintf("/board?agent=%d", martin),
+			[]string{"Bay 2"}, []string{"Transfo 1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+			assertStatus(t, code, http.StatusOK)
+
+			for _, s := range tt.shown {
+				assertContains(t, body, s)
+			}
+			for _, s := range tt.hidden {
+				if strings.Contains(body, s) {
+					t.Errorf("%q is shown", s)
+				}
+			}
+		})
+	}
+}
+
+func TestInfoStatus(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	sID := addSource(t, app, "Billancourt")
+	id := addInfo(t, app, sID, "Transfo 1", "waiting")
+	tagID := addTag(t, app, "Safety")
+
+	if err := app.store.Tags().TagsSet(ctx, id, []int{tagID}); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := newTestServer(t, app.routes())
+
+	// As board.js sends it
+	post := func(t *testing.T, sID, id int, status, token string) (int, string) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodPost,
+			ts.URL+fmt.Sprintf("/source/%d/info/status/%d", sID, id),
+			strings.NewReader(url.Values{"status": {status}}.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set(csrfHeader, token)
+
+		code, _, body := ts.do(t, req)
+		return code, body
+	}
+
+	code, body := post(t, sID, id, "done", ts.token(t))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, `"status":"done"`)
+
+	// Only the status changed
+	info, _ := app.store.Infos().InfoGet(ctx, id)
+	if info.Status != "done" || info.Material != "Transfo 1" || info.ReporterID != 1 {
+		t.Errorf("got %+v", info)
+	}
+	tags, _ := app.store.Tags().TagsOfInfos(ctx, []int{id})
+	if len(tags[id]) != 1 {
+		t.Errorf("got %d tags; want 1", len(tags[id]))
+	}
+
+	code, body = post(t, sID, id, "lost", ts.token(t))
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+	assertContains(t, body, `"status":"Choose a status"`)
+
+	// Checked like the edit page: an info without reporter
+	// must be corrected there first
+	orphan, _ := app.store.Infos().InfoInsert(ctx, &database.Info{SourceID: sID,
+		Material: "Bay 2", Detail: "Noise", Priority: 1, Status: "waiting"})
+	code, body = post(t, sID, orphan, "affected", ts.token(t))
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+	assertContains(t, body, `"reporter"`)
+
+	code, _ = post(t, sID, id, "affected", "wrong")
+	assertStatus(t, code, http.StatusForbidden)
+
+	code, _ = post(t, sID, 42, "affected", ts.token(t))
+	assertStatus(t, code, http.StatusNotFound)
+
+	// The info of another source
+	other := addSource(t, app, "Nanterre")
+	code, _ = post(t, other, id, "affected", ts.token(t))
+	assertStatus(t, code, http.StatusNotFound)
+
+	if info, _ = app.store.Infos().InfoGet(ctx, id); info.Status != "done" {
+		t.Errorf("got status %q; want done", info.Status)
+	}
+}
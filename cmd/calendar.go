@@ -0,0 +1,374 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"CURATOR/database"
+	"CURATOR/internal/i18n"
+	"CURATOR/internal/ical"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// The calendar (/calendar) shows the infos by due and planned
+// date, a month or a week at a time. An agent or a source has an
+// .ics feed behind a secret link (/calendar/{token}.ics) that
+// Thunderbird or Outlook can subscribe to
+
+// Days of the .ics feeds around today
+const (
+	feedPast   = 90
+	feedFuture = 365
+)
+
+// An info on a day of the calendar, Kind is "due" or "planned"
+type calendarEvent struct {
+	Info *database.Info
+	Kind string
+}
+
+type calendarDay struct {
+	Date   time.Time
+	Events []calendarEvent
+
+	// Other is a day of the month before or after
+	Other bool
+	Today bool
+}
+
+// calendarPage is the month or the week shown. Source and
+// Agent filter it when not 0, the URLs keep the filters
+type calendarPage struct {
+	View   string
+	Date   time.Time
+	Weeks  [][]*calendarDay
+	Source int
+	Agent  int
+
+	Prev, Next, Month, Week string
+
+	// Secret link of the feed of the agent (or of the source),
+	// "" when there's none yet
+	Feed string
+}
+
+// calendarDate is a day at midnight UTC, the way due and
+// planned dates are stored
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// monday returns the first day of the week of day
+func monday(day time.Time) time.Time {
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// absoluteURL is path on this server, for the links
+// that leave the browser (feeds, QR codes). The Host of the
+// request is only used without -base-url
+func (app *application) absoluteURL(r *http.Request, path string) string {
+	if app.baseURL != "" {
+		return app.baseURL + path
+	}
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + path
+}
+
+// url is the calendar page with its filters
+func (c *calendarPage) url(view string, date time.Time) string {
+	values := url.Values{}
+	values.Set("view", view)
+	values.Set("date", date.Format(dueLayout))
+	if c.Source != 0 {
+		values.Set("source", strconv.Itoa(c.Source))
+	}
+	if c.Agent != 0 {
+		values.Set("agent", strconv.Itoa(c.Agent))
+	}
+	return "/calendar?" + values.Encode()
+}
+
+// ?view=month|week&date=2024-06-01&source=1&agent=2, the month
+// of today by default
+func (app *application) calendar(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	// Today where the user is
+	now := time.Now()
+	if tz := i18n.FromContext(r.Context()).TZ; tz != nil {
+		now = now.In(tz)
+	}
+	today := calendarDate(now)
+
+	page := &calendarPage{View: query.Get("view"), Date: today}
+	if page.View == "" {
+		page.View = "month"
+	}
+	if page.View != "month" && page.View != "week" {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	if value := query.Get("date"); value != "" {
+		date, err := time.Parse(dueLayout, value)
+		if err != nil {
+			app.clientError(w, r, http.StatusBadRequest)
+			return
+		}
+		page.Date = date
+	}
+	page.Source, _ = strconv.Atoi(query.Get("source"))
+	page.Agent, _ = strconv.Atoi(query.Get("agent"))
+
+	// Whole weeks, from Monday to Sunday
+	var start, end time.Time
+	if page.View == "month" {
+		page.Date = page.Date.AddDate(0, 0, 1-page.Date.Day())
+		start = monday(page.Date)
+		end = monday(page.Date.AddDate(0, 1, 6))
+		page.Prev = page.url("month", page.Date.AddDate(0, -1, 0))
+		page.Next = page.url("month", page.Date.AddDate(0, 1, 0))
+	} else {
+		page.Date = monday(page.Date)
+		start = page.Date
+		end = start.AddDate(0, 0, 7)
+		page.Prev = page.url("week", start.AddDate(0, 0, -7))
+		page.Next = page.url("week", end)
+	}
+	page.Month = page.url("month", page.Date)
+	page.Week = page.url("week", page.Date)
+
+	infos, err := app.store.Infos().InfoCalendar(r.Context(), start, end,
+		page.Source, page.Agent)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	days := []*calendarDay{}
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, &calendarDay{
+			Date:  day,
+			Other: page.View == "month" && day.Month() != page.Date.Month(),
+			Today: day.Equal(today),
+		})
+	}
+
+	for _, info := range infos {
+		for _, event := range []calendarEvent{{info, "due"}, {info, "planned"}} {
+			date := info.Due
+			if event.Kind == "planned" {
+				date = info.Planned
+			}
+			if date.IsZero() || date.Before(start) || !date.Before(end) {
+				continue
+			}
+			day := days[int(date.Sub(start).Hours()/24)]
+			day.Events = append(day.Events, event)
+		}
+	}
+
+	for i := 0; i < len(days); i += 7 {
+		page.Weeks = append(page.Weeks, days[i:i+7])
+	}
+
+	// One feed at a time: the agent's, or the source's
+	if page.Agent != 0 || page.Source != 0 {
+		feed, err := app.feedOf(r, page.Agent, page.Source)
+		if err != nil && !errors.Is(err, database.ErrNoRecord) {
+			app.serverError(w, r, err)
+			return
+		}
+		if feed != nil {
+			page.Feed = app.absoluteURL(r, "/calendar/"+feed.Token+".ics")
+		}
+	}
+
+	sources, err := app.store.Sources().MenuSource(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	agents, err := app.store.Agents().AgentList(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Calendar = page
+	data.Sources = sources
+	data.Agents = agents
+
+	app.render(w, r, http.StatusOK, "calendar.tmpl.html", data)
+}
+
+// feedOf returns the feed of the agent, or of the source
+// when agentID is 0
+func (app *application) feedOf(r *http.Request, agentID, sourceID int) (*database.Feed, error) {
+	if agentID != 0 {
+		sourceID = 0
+	}
+	return app.store.Feeds().FeedFind(r.Context(), agentID, sourceID)
+}
+
+// newFeedToken returns a secret token for a feed link
+func newFeedToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Creates the feed link of the agent (or the source) of the
+// calendar. A new link replaces the old one, which stops working
+func (app *application) calendarFeedPost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	feed := &database.Feed{}
+	feed.AgentID, _ = strconv.Atoi(r.PostForm.Get("agent"))
+	if feed.AgentID == 0 {
+		feed.SourceID, _ = strconv.Atoi(r.PostForm.Get("source"))
+	}
+	if feed.AgentID < 1 && feed.SourceID < 1 {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	// The feed of a source in the trash would stay empty
+	if feed.SourceID != 0 {
+		_, err = app.store.Sources().SourceGet(r.Context(), feed.SourceID)
+		if err != nil {
+			if errors.Is(err, database.ErrNoRecord) {
+				app.notFound(w, r)
+			} else {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+	}
+
+	feed.Token, err = newFeedToken()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.store.Feeds().FeedRenew(r.Context(), feed)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "New calendar link")
+
+	http.Redirect(w, r, backURL(r), http.StatusSeeOther)
+}
+
+// The .ics feed of a secret link, in the language
+// asked by the client
+func (app *application) calendarFeed(w http.ResponseWriter, r *http.Request) {
+	feed, err := app.store.Feeds().FeedGet(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	cal := &ical.Calendar{Name: "CURATOR"}
+
+	if feed.AgentID != 0 {
+		agent, err := app.store.Agents().AgentGet(r.Context(), feed.AgentID)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+		cal.Name += " - " + agent.Name
+	} else {
+		source, err := app.store.Sources().SourceGet(r.Context(), feed.SourceID)
+		if err != nil {
+			if errors.Is(err, database.ErrNoRecord) {
+				app.notFound(w, r)
+			} else {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+		cal.Name += " - " + source.Name
+	}
+
+	today := calendarDate(time.Now())
+	infos, err := app.store.Infos().InfoCalendar(r.Context(),
+		today.AddDate(0, 0, -feedPast), today.AddDate(0, 0, feedFuture),
+		feed.SourceID, feed.AgentID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	locale := i18n.FromContext(r.Context())
+
+	for _, info := range infos {
+		stamp := info.Created
+		if !info.Updated.IsZero() {
+			stamp = info.Updated
+		}
+
+		what := fmt.Sprintf("%s - %s", info.SourceName, info.Material)
+		description := []string{info.Detail, locale.T(info.Status)}
+		if who := assignment(info.Assignee, info.Team); who != "-" {
+			description = append(description, who)
+		}
+
+		event := ical.Event{
+			Stamp:       stamp,
+			Description: strings.Join(description, "\n"),
+			URL: app.absoluteURL(r, fmt.Sprintf("/source/%d/info/view/%d",
+				info.SourceID, info.ID)),
+		}
+
+		if !info.Due.IsZero() {
+			event.UID = fmt.Sprintf("info-%d-due@curator", info.ID)
+			event.Day = info.Due
+			event.Summary = locale.T("Due: %s", what)
+			cal.Events = append(cal.Events, event)
+		}
+		if !info.Planned.IsZero() {
+			event.UID = fmt.Sprintf("info-%d-planned@curator", info.ID)
+			event.Day = info.Planned
+			event.Summary = locale.T("Planned: %s", what)
+			cal.Events = append(cal.Events, event)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	if err := cal.Write(w); err != nil {
+		app.logger.ErrorContext(r.Context(), "writing calendar",
+			"error", err)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"CURATOR/database"
+)
+
+func TestMonday(t *testing.T) {
+	// June 3, 2024 is a Monday, June 9 the Sunday after
+	for day, want := range map[int]string{
+		3:  "2024-06-03",
+		5:  "2024-06-03",
+		9:  "2024-06-03",
+		10: "2024-06-10",
+	} {
+		got := monday(time.Date(2024, 6, day, 0, 0, 0, 0, time.UTC))
+		if got.Format(dueLayout) != want {
+			t.Errorf("June %d: got %s; want %s", day, got.Format(dueLayout), want)
+		}
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	sID := addSource(t, app, "Billancourt")
+	martin := addAgent(t, app, "Martin")
+
+	add := func(material string, assignee int, due, planned string) {
+		t.Helper()
+
+		info := &database.Info{SourceID: sID, Agent: "Dupont", Material: material,
+			Detail: "Detail", Priority: 1, Status: "affected",
+			ReporterID: 1, AssigneeID: assignee}
+		info.Due, _ = time.Parse(dueLayout, due)
+		info.Planned, _ = time.Parse(dueLayout, planned)
+		if _, err := app.store.Infos().InfoInsert(ctx, info); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	add("Transfo 1", martin, "2024-06-28", "2024-06-10")
+	add("Bay 2", 0, "2024-07-15", "")
+	add("Breaker", 0, "", "")
+
+	ts := newTestServer(t, app.routes())
+
+	tests := []struct {
+		name    string
+		urlPath string
+		code    int
+		shown   []string
+		hidden  []string
+	}{
+		{"Month", "/calendar?date=2024-06-12", http.StatusOK,
+			[]string{"June 2024", "Transfo 1", "calendar-planned", "calendar-due"},
+			[]string{"Bay 2", "Breaker"}},
+		{"Week", "/calendar?view=week&date=2024-06-12", http.StatusOK,
+			[]string{"Week of Jun 10, 2024", "Transfo 1", "calendar-planned"},
+			[]string{"calendar-due"}},
+		{"Next month", "/calendar?date=2024-07-01", http.StatusOK,
+			[]string{"July 2024", "Bay 2"}, []string{"Transfo 1"}},
+		{"Agent", fmt.Sprintf("/calendar?date=2024-07-01&agent=%d", martin), http.StatusOK,
+			[]string{"Create a link"}, []string{"Bay 2"}},
+		{"Bad date", "/calendar?date=12/06/2024", http.StatusBadRequest, nil, nil},
+		{"Bad view", "/calendar?view=year", http.StatusBadRequest, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+			assertStatus(t, code, tt.code)
+
+			for _, s := range tt.shown {
+				assertContains(t, body, s)
+			}
+			for _, s := range tt.hidden {
+				if strings.Contains(body, s) {
+					t.Errorf("%q is shown", s)
+				}
+			}
+		})
+	}
+}
+
+var feedRX = regexp.MustCompile(`/calendar/([A-Za-z0-9_-]+)\.ics`)
+
+func TestCalendarFeed(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	sID := addSource(t, app, "Billancourt")
+	martin := addAgent(t, app, "Martin")
+
+	// The feeds cover the days around today
+	soon := time.Now().UTC().AddDate(0, 0, 7)
+	due := time.Date(soon.Year(), soon.Month(), soon.Day(), 0, 0, 0, 0, time.UTC)
+	_, err := app.store.Infos().InfoInsert(ctx, &database.Info{SourceID: sID,
+		Agent: "Dupont", Material: "Transfo 1", Detail: "Oil leak, again",
+		Priority: 1, Status: "affected", ReporterID: 1, AssigneeID: martin,
+		Due: due})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := newTestServer(t, app.routes())
+	page := fmt.Sprintf("/calendar?agent=%d", martin)
+
+	newLink := func() string {
+		t.Helper()
+
+		code, _, _ := ts.postForm(t, "/calendar/feed",
+			url.Values{"agent": {fmt.Sprint(martin)}})
+		assertStatus(t, code, http.StatusSeeOther)
+
+		_, _, body := ts.get(t, page)
+		m := feedRX.FindStringSubmatch(body)
+		if m == nil {
+			t.Fatal("no feed link in the page")
+		}
+		return m[0]
+	}
+
+	link := newLink()
+
+	code, header, body := ts.get(t, link)
+	assertStatus(t, code, http.StatusOK)
+	if ct := header.Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+		t.Errorf("got Content-Type %q", ct)
+	}
+	assertContains(t, body, "X-WR-CALNAME:CURATOR - Martin")
+	assertContains(t, body, "SUMMARY:Due: Billancourt - Transfo 1")
+	assertContains(t, body, "DTSTART;VALUE=DATE:"+due.Format("20060102"))
+	assertContains(t, body, `Oil leak\, again`)
+
+	// A new link stops the old one
+	renewed := newLink()
+	if renewed == link {
+		t.Fatal("the link didn't change")
+	}
+
+	code, _, _ = ts.get(t, link)
+	assertStatus(t, code, http.StatusNotFound)
+	code, _, _ = ts.get(t, renewed)
+	assertStatus(t, code, http.StatusOK)
+
+	// The feed of a source
+	code, _, _ = ts.postForm(t, "/calendar/feed", url.Values{"source": {fmt.Sprint(sID)}})
+	assertStatus(t, code, http.StatusSeeOther)
+	feed, err := app.store.Feeds().FeedFind(ctx, 0, sID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _, body = ts.get(t, "/calendar/"+feed.Token+".ics")
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "X-WR-CALNAME:CURATOR - Billancourt")
+
+	// A merge keeps the feed, unless the other source has its own
+	nanterre := addSource(t, app, "Nanterre")
+	if err = app.store.Sources().SourceMerge(ctx, sID, nanterre); err != nil {
+		t.Fatal(err)
+	}
+	code, _, body = ts.get(t, "/calendar/"+feed.Token+".ics")
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "X-WR-CALNAME:CURATOR - Nanterre")
+
+	puteaux := addSource(t, app, "Puteaux")
+	err = app.store.Feeds().FeedRenew(ctx, &database.Feed{Token: "puteaux", SourceID: puteaux})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = app.store.Sources().SourceMerge(ctx, nanterre, puteaux); err != nil {
+		t.Fatal(err)
+	}
+	code, _, _ = ts.get(t, "/calendar/"+feed.Token+".ics")
+	assertStatus(t, code, http.StatusNotFound)
+	code, _, body = ts.get(t, "/calendar/puteaux.ics")
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "X-WR-CALNAME:CURATOR - Puteaux")
+
+	code, _, _ = ts.postForm(t, "/calendar/feed", url.Values{"agent": {"42"}})
+	assertStatus(t, code, http.StatusNotFound)
+	code, _, _ = ts.postForm(t, "/calendar/feed", nil)
+	assertStatus(t, code, http.StatusBadRequest)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+)
+
+// Every form POST must send the token of the user session,
+// a page from another site can't know it.
+// Forms use {{ template "csrf" . }} (base.tmpl.html),
+// JavaScript can read <meta name="csrf-token"> and send it
+// in the X-CSRF-Token header
+
+const (
+	csrfSessionKey = "csrfToken"
+	csrfFormField  = "csrf_token"
+	csrfHeader     = "X-CSRF-Token"
+)
+
+// csrfToken returns the token of the session,
+// a new one is created the first time
+func (app *application) csrfToken(r *http.Request) string {
+	token := app.sessionManager.GetString(r.Context(), csrfSessionKey)
+	if token != "" {
+		return token
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		// Without a token every POST is refused,
+		// better than accepting them
+		app.logger.ErrorContext(r.Context(), "csrf: token", "error", err)
+		return ""
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(b)
+	app.sessionManager.Put(r.Context(), csrfSessionKey, token)
+
+	return token
+}
+
+// csrf refuses POST, PUT, PATCH and DELETE requests
+// without the right token. Must be used after LoadAndSave
+func (app *application) csrf(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		sent := r.Header.Get(csrfHeader)
+		if sent == "" {
+			sent = r.PostFormValue(csrfFormField)
+		}
+
+		want := app.sessionManager.GetString(r.Context(), csrfSessionKey)
+
+		if want == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(want)) != 1 {
+			app.logger.WarnContext(r.Context(), "csrf: invalid token",
+				"method", r.Method,
+				"uri", r.URL.RequestURI())
+
+			app.errorResponse(w, r, http.StatusForbidden,
+				"This form has expired or was sent from another site. "+
+					"Go back, reload the page and try again.")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
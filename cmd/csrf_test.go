@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCSRF(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	// Every POST form has the token
+	_, _, body := ts.get(t, "/source/create")
+	assertContains(t, body, `name="csrf_token" value="`+ts.token(t)+`"`)
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode int
+	}{
+		{"No token", "", http.StatusForbidden},
+		{"Wrong token", "wrong", http.StatusForbidden},
+		{"Valid token", ts.token(t), http.StatusSeeOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{
+				"name":        {tt.name},
+				csrfFormField: {tt.token},
+			}
+
+			code, _, _ := ts.postForm(t, "/source/create", form)
+			assertStatus(t, code, tt.wantCode)
+		})
+	}
+
+	sources, _ := app.store.Sources().MenuSource(context.Background())
+	if len(sources) != 1 {
+		t.Errorf("got %d sources; want 1", len(sources))
+	}
+}
+
+func TestCSRFHeader(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	form := url.Values{"name": {"Billancourt"}}
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/source/create",
+		strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(csrfHeader, ts.token(t))
+
+	code, _, _ := ts.do(t, req)
+	assertStatus(t, code, http.StatusSeeOther)
+}
+
+func TestCSRFOtherSession(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	other := newTestServer(t, app.routes())
+
+	// A token is only valid for its own session
+	form := url.Values{
+		"name":        {"Billancourt"},
+		csrfFormField: {other.token(t)},
+	}
+
+	code, _, body := ts.postForm(t, "/source/create", form)
+	assertStatus(t, code, http.StatusForbidden)
+	assertContains(t, body, "reload the page")
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Dev mode (-dev) is for working on the templates:
+// they are parsed again from disk at each request and a
+// template error is shown in the browser with its line.
+// Never use it in production, it shows the source code
+
+// templates returns the template cache, or a new one
+// read from disk in dev mode
+func (app *application) templates() (map[string]*template.Template, error) {
+	if !app.dev {
+		return app.templateCache, nil
+	}
+
+	return newTemplateCache(app.uiFS, app.static)
+}
+
+// Template errors look like
+// template: sourceView.tmpl.html:42:13: executing "main" at <.Foo>: ...
+var templateErrorRx = regexp.MustCompile(`template: ([\w.\-]+):(\d+)(?::(\d+))?:`)
+
+// Lines shown before and after the line in error
+const templateErrorContext = 5
+
+type templateErrorLine struct {
+	Number int
+	Text   string
+	Error  bool
+}
+
+type templateErrorData struct {
+	Error string
+	File  string
+	Lines []templateErrorLine
+}
+
+// devTemplateError writes a page with the error and
+// the lines of the template around it
+func (app *application) devTemplateError(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.ErrorContext(r.Context(), "template error", "error", err)
+
+	data := templateErrorData{Error: err.Error()}
+
+	if m := templateErrorRx.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[2])
+		data.File, data.Lines = app.templateLines(m[1], line)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := devErrorPage.Execute(buf, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	buf.WriteTo(w)
+}
+
+// templateLines reads the template file from ui/html
+func (app *application) templateLines(name string, line int) (string, []templateErrorLine) {
+	file := "html/pages/" + name
+	if name == "base.tmpl.html" {
+		file = "html/base.tmpl.html"
+	}
+
+	content, err := fs.ReadFile(app.uiFS, file)
+	if err != nil {
+		return file, nil
+	}
+
+	lines := strings.Split(string(content), "\n")
+
+	first := max(line-templateErrorContext, 1)
+	last := min(line+templateErrorContext, len(lines))
+
+	out := []templateErrorLine{}
+	for n := first; n <= last; n++ {
+		out = append(out, templateErrorLine{
+			Number: n,
+			Text:   lines[n-1],
+			Error:  n == line,
+		})
+	}
+
+	return file, out
+}
+
+var devErrorPage = template.Must(template.New("devError").Parse(`<!DOCTYPE html>
+<html lang="en">
+  <head>
+    <meta charset="utf-8">
+    <title>Template error - CURATOR</title>
+    <style>
+      body { font-family: sans-serif; margin: 2rem; }
+      h1 { color: #c0392b; }
+      pre { background: #f7f9fa; padding: 1rem; overflow-x: auto; }
+      .line { display: block; }
+      .error { background: #f9d6d2; font-weight: bold; }
+      .number { color: grey; display: inline-block; width: 3rem; }
+    </style>
+  </head>
+  <body>
+    <h1>Template error</h1>
+    <pre>{{ .Error }}</pre>
+    {{ if .Lines }}
+    <h2>{{ .File }}</h2>
+    <pre>{{ range .Lines }}<span class="line{{ if .Error }} error{{ end }}"><span class="number">{{ .Number }}</span>{{ .Text }}</span>{{ end }}</pre>
+    {{ end }}
+    <p><small>Shown because CURATOR runs with -dev</small></p>
+  </body>
+</html>
+`))
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newDevApplication(t *testing.T, fsys fstest.MapFS) *application {
+	t.Helper()
+
+	app := newTestApplication(t)
+	app.dev = true
+	app.uiFS = fsys
+
+	static, err := newStaticFiles(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	static.dev = true
+	app.static = static
+
+	return app
+}
+
+func TestDevReload(t *testing.T) {
+	fsys := fstest.MapFS{
+		"html/base.tmpl.html":       {Data: []byte(`{{ define "base" }}{{ template "main" . }}{{ end }}`)},
+		"html/pages/home.tmpl.html": {Data: []byte(`{{ define "main" }}first{{ end }}`)},
+		"static/js/main.js":         {Data: []byte(`1`)},
+	}
+	app := newDevApplication(t, fsys)
+
+	rr := httptest.NewRecorder()
+	r := newTestRequest(t, app, http.MethodGet, "/")
+	app.render(rr, r, http.StatusOK, "home.tmpl.html", &templateData{})
+	assertContains(t, rr.Body.String(), "first")
+
+	fsys["html/pages/home.tmpl.html"].Data = []byte(`{{ define "main" }}second{{ end }}`)
+
+	rr = httptest.NewRecorder()
+	app.render(rr, r, http.StatusOK, "home.tmpl.html", &templateData{})
+	assertContains(t, rr.Body.String(), "second")
+
+	// Static hashes follow the file too
+	before := app.static.url("js/main.js")
+	fsys["static/js/main.js"].Data = []byte(`2`)
+	if app.static.url("js/main.js") == before {
+		t.Error("hash did not change")
+	}
+}
+
+func TestDevTemplateError(t *testing.T) {
+	fsys := fstest.MapFS{
+		"html/base.tmpl.html": {Data: []byte(`{{ define "base" }}{{ template "main" . }}{{ end }}`)},
+		"html/pages/home.tmpl.html": {Data: []byte(`{{ define "main" }}
+line 2
+{{ .Missing.Field }}
+line 4
+{{ end }}`)},
+		"static/js/main.js": {Data: []byte(`1`)},
+	}
+	app := newDevApplication(t, fsys)
+
+	rr := httptest.NewRecorder()
+	r := newTestRequest(t, app, http.MethodGet, "/")
+	app.render(rr, r, http.StatusOK, "home.tmpl.html", &templateData{})
+
+	assertStatus(t, rr.Code, http.StatusInternalServerError)
+	body := rr.Body.String()
+	assertContains(t, body, "html/pages/home.tmpl.html")
+	assertContains(t, body, `<span class="line error"><span class="number">3</span>{{ .Missing.Field }}</span>`)
+}
+
+func TestProductionTemplateErrorHidden(t *testing.T) {
+	app := newTestApplication(t)
+
+	rr := httptest.NewRecorder()
+	r := newTestRequest(t, app, http.MethodGet, "/")
+	app.render(rr, r, http.StatusOK, "missing.tmpl.html", &templateData{})
+
+	assertStatus(t, rr.Code, http.StatusInternalServerError)
+	if strings.Contains(rr.Body.String(), "Template error") {
+		t.Error("template error shown outside dev mode")
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"CURATOR/database"
+	"CURATOR/internal/validator"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Equipment of a source: bays, transformers, breakers...
+// A piece of equipment can be under another one (a breaker
+// in a bay). Infos are linked to it and it keeps their history
+
+type equipmentForm struct {
+	Name   string
+	Kind   string
+	Parent string
+
+	validator.Validator
+}
+
+// equipmentSource returns the source of the URL ({sid})
+// and its equipment. ok is false when the response is sent
+func (app *application) equipmentSource(w http.ResponseWriter, r *http.Request) (*database.Source, []*database.Equipment, bool) {
+	sID, err := strconv.Atoi(chi.URLParam(r, "sid"))
+	if err != nil || sID < 1 {
+		app.notFound(w, r)
+		return nil, nil, false
+	}
+
+	source, err := app.store.Sources().SourceGet(r.Context(), sID)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return nil, nil, false
+	}
+
+	list, err := app.store.Equipment().EquipmentList(r.Context(), sID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return nil, nil, false
+	}
+
+	return source, list, true
+}
+
+// findEquipment returns the equipment {id} of the URL if it's in list
+func findEquipment(r *http.Request, list []*database.Equipment) *database.Equipment {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return nil
+	}
+
+	for _, e := range list {
+		if e.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+// check validates the form. id is the equipment updated (0 when
+// created): it can't be put under itself or its children
+func (form *equipmentForm) check(list []*database.Equipment, id int) int {
+	form.CheckField(validator.NotBlank(form.Name), "name", "Cannot be empty")
+	form.CheckField(validator.MaxChars(form.Name, 100), "name",
+		"100 characters max")
+	form.CheckField(validator.PermittedValue(form.Kind, database.EquipmentKinds...),
+		"kind", "Choose a kind")
+
+	if form.Parent == "" {
+		return 0
+	}
+
+	parent, _ := strconv.Atoi(form.Parent)
+	under := map[int]bool{}
+	if id != 0 {
+		under = database.Subtree(list, id)
+	}
+
+	for _, e := range list {
+		if e.ID == parent && !under[parent] {
+			return parent
+		}
+	}
+
+	form.AddFieldError("parent", "Choose another parent")
+	return 0
+}
+
+// Tree of the equipment and the form to add one
+func (app *application) equipmentList(w http.ResponseWriter, r *http.Request) {
+	source, list, ok := app.equipmentSource(w, r)
+	if !ok {
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Source = source
+	data.EquipmentList = list
+	data.Kinds = database.EquipmentKinds
+	data.Form = equipmentForm{Kind: database.EquipmentKinds[0]}
+
+	app.render(w, r, http.StatusOK, "equipment.tmpl.html", data)
+}
+
+func (app *application) equipmentCreatePost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	source, list, ok := app.equipmentSource(w, r)
+	if !ok {
+		return
+	}
+
+	form := equipmentForm{
+		Name:   strings.TrimSpace(r.PostForm.Get("name")),
+		Kind:   r.PostForm.Get("kind"),
+		Parent: r.PostForm.Get("parent"),
+	}
+
+	parent := form.check(list, 0)
+
+	if !form.Valid() {
+		data := app.newTemplateData(r)
+		data.Source = source
+		data.EquipmentList = list
+		data.Kinds = database.EquipmentKinds
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "equipment.tmpl.html", data)
+		return
+	}
+
+	_, err = app.store.Equipment().EquipmentInsert(r.Context(), &database.Equipment{
+		SourceID: source.ID,
+		ParentID: parent,
+		Kind:     form.Kind,
+		Name:     form.Name,
+	})
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Equipment added")
+
+	http.Redirect(w, r, fmt.Sprintf("/source/%d/equipment", source.ID),
+		http.StatusSeeOther)
+}
+
+// Defect history of the equipment and of everything under it
+func (app *application) equipmentView(w http.ResponseWriter, r *http.Request) {
+	source, list, ok := app.equipmentSource(w, r)
+	if !ok {
+		return
+	}
+
+	equipment := findEquipment(r, list)
+	if equipment == nil {
+		app.notFound(w, r)
+		return
+	}
+
+	ids := []int{}
+	for id := range database.Subtree(list, equipment.ID) {
+		ids = append(ids, id)
+	}
+
+	infos, err := app.store.Infos().InfoListEquipment(r.Context(), ids)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Source = source
+	data.Equipment = equipment
+	data.Infos = infos
+
+	app.render(w, r, http.StatusOK, "equipmentView.tmpl.html", data)
+}
+
+func (app *application) equipmentUpdate(w http.ResponseWriter, r *http.Request) {
+	source, list, ok := app.equipmentSource(w, r)
+	if !ok {
+		return
+	}
+
+	equipment := findEquipment(r, list)
+	if equipment == nil {
+		app.notFound(w, r)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Source = source
+	data.Equipment = equipment
+	data.EquipmentList = list
+	data.Kinds = database.EquipmentKinds
+	form := equipmentForm{
+		Name: equipment.Name,
+		Kind: equipment.Kind,
+	}
+	if equipment.ParentID != 0 {
+		form.Parent = strconv.Itoa(equipment.ParentID)
+	}
+	data.Form = form
+
+	app.render(w, r, http.StatusOK, "equipmentUpdate.tmpl.html", data)
+}
+
+func (app *application) equipmentUpdatePost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	source, list, ok := app.equipmentSource(w, r)
+	if !ok {
+		return
+	}
+
+	equipment := findEquipment(r, list)
+	if equipment == nil {
+		app.notFound(w, r)
+		return
+	}
+
+	form := equipmentForm{
+		Name:   strings.TrimSpace(r.PostForm.Get("name")),
+		Kind:   r.PostForm.Get("kind"),
+		Parent: r.PostForm.Get("parent"),
+	}
+
+	parent := form.check(list, equipment.ID)
+
+	if !form.Valid() {
+		data := app.newTemplateData(r)
+		data.Source = source
+		data.Equipment = equipment
+		data.EquipmentList = list
+		data.Kinds = database.EquipmentKinds
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "equipmentUpdate.tmpl.html", data)
+		return
+	}
+
+	err = app.store.Equipment().EquipmentUpdate(r.Context(), &database.Equipment{
+		ID:       equipment.ID,
+		ParentID: parent,
+		Kind:     form.Kind,
+		Name:     form.Name,
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Equipment updated")
+
+	http.Redirect(w, r, fmt.Sprintf("/source/%d/equipment", source.ID),
+		http.StatusSeeOther)
+}
+
+// The children go up one level, the infos lose their equipment
+// but keep their material
+func (app *application) equipmentDeletePost(w http.ResponseWriter, r *http.Request) {
+	source, list, ok := app.equipmentSource(w, r)
+	if !ok {
+		return
+	}
+
+	equipment := findEquipment(r, list)
+	if equipment == nil {
+		app.notFound(w, r)
+		return
+	}
+
+	err := app.store.Equipment().EquipmentDelete(r.Context(), equipment.ID)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Equipment deleted")
+
+	http.Redirect(w, r, fmt.Sprintf("/source/%d/equipment", source.ID),
+		http.StatusSeeOther)
+}
+
+// equipmentField checks the equipment picked on an info form
+// is one of the source. Without material, the name of the
+// equipment is used. The list is for the picker
+func (app *application) equipmentField(r *http.Request, form *infoCreateForm, sourceID int) ([]*database.Equipment, int, error) {
+	list, err := app.store.Equipment().EquipmentList(r.Context(), sourceID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if form.Equipment == "" {
+		return list, 0, nil
+	}
+
+	id, _ := strconv.Atoi(form.Equipment)
+	for _, e := range list {
+		if e.ID == id {
+			if strings.TrimSpace(form.Material) == "" {
+				form.Material = e.Name
+			}
+			return list, id, nil
+		}
+	}
+
+	form.AddFieldError("equipment", "Choose another equipment")
+	return list, 0, nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"CURATOR/database"
+)
+
+func addEquipment(t *testing.T, app *application, sourceID, parentID int, kind, name string) int {
+	t.Helper()
+
+	id, err := app.store.Equipment().EquipmentInsert(context.Background(), &database.Equipment{
+		SourceID: sourceID,
+		ParentID: parentID,
+		Kind:     kind,
+		Name:     name,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
+
+func TestEquipment(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	sID := addSource(t, app, "Billancourt")
+	ts := newTestServer(t, app.routes())
+	path := fmt.Sprintf("/source/%d/equipment", sID)
+
+	code, _, body := ts.get(t, path)
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "No equipment yet.")
+
+	code, _, _ = ts.get(t, "/source/42/equipment")
+	assertStatus(t, code, http.StatusNotFound)
+
+	tests := []struct {
+		name string
+		form url.Values
+	}{
+		{"No name", url.Values{"name": {" "}, "kind": {"bay"}}},
+		{"Unknown kind", url.Values{"name": {"Bay 1"}, "kind": {"pylon"}}},
+		{"Unknown parent", url.Values{"name": {"Bay 1"}, "kind": {"bay"}, "parent": {"42"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := ts.postForm(t, path+"/create", tt.form)
+			assertStatus(t, code, http.StatusUnprocessableEntity)
+		})
+	}
+
+	code, _, _ = ts.postForm(t, path+"/create", url.Values{"name": {"Bay 1"}, "kind": {"bay"}})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	list, _ := app.store.Equipment().EquipmentList(ctx, sID)
+	if len(list) != 1 {
+		t.Fatalf("got %d equipment; want 1", len(list))
+	}
+	bay := list[0].ID
+
+	code, _, _ = ts.postForm(t, path+"/create", url.Values{
+		"name":   {"Transfo 1"},
+		"kind":   {"transformer"},
+		"parent": {fmt.Sprint(bay)},
+	})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	list, _ = app.store.Equipment().EquipmentList(ctx, sID)
+	if len(list) != 2 || list[1].Name != "Transfo 1" || list[1].Depth != 1 {
+		t.Fatalf("got %+v", list)
+	}
+	transfo := list[1].ID
+
+	// A bay can't go under its own transformer
+	code, _, _ = ts.postForm(t, fmt.Sprintf("%s/update/%d", path, bay), url.Values{
+		"name":   {"Bay 1"},
+		"kind":   {"bay"},
+		"parent": {fmt.Sprint(transfo)},
+	})
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+
+	// Equipment of another source isn't found here
+	other := addSource(t, app, "Nanterre")
+	code, _, _ = ts.get(t, fmt.Sprintf("/source/%d/equipment/view/%d", other, bay))
+	assertStatus(t, code, http.StatusNotFound)
+
+	code, _, _ = ts.postForm(t, fmt.Sprintf("%s/update/%d", path, transfo), url.Values{
+		"name":   {"TR1"},
+		"kind":   {"transformer"},
+		"parent": {fmt.Sprint(bay)},
+	})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	// Deleted, its children go up one level
+	code, _, _ = ts.postForm(t, fmt.Sprintf("%s/delete/%d", path, bay), nil)
+	assertStatus(t, code, http.StatusSeeOther)
+
+	list, _ = app.store.Equipment().EquipmentList(ctx, sID)
+	if len(list) != 1 || list[0].Name != "TR1" || list[0].ParentID != 0 {
+		t.Fatalf("got %+v", list)
+	}
+}
+
+func TestInfoEquipment(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	sID := addSource(t, app, "Billancourt")
+	bay := addEquipment(t, app, sID, 0, "bay", "Bay 1")
+	breaker := addEquipment(t, app, sID, bay, "breaker", "Breaker 3")
+	ts := newTestServer(t, app.routes())
+	path := fmt.Sprintf("/source/%d/info/create", sID)
+
+	code, _, body := ts.get(t, path)
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, fmt.Sprintf(`<option value="%d"`, breaker))
+
+	// Equipment of another source
+	other := addSource(t, app, "Nanterre")
+	foreign := addEquipment(t, app, other, 0, "bay", "Bay 9")
+
+	form := validInfoForm()
+	form.Set("material", "")
+	form.Set("equipment", fmt.Sprint(foreign))
+	code, _, body = ts.postForm(t, path, form)
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+	assertContains(t, body, "Choose another equipment")
+
+	// Without material, the name of the equipment is used
+	form.Set("equipment", fmt.Sprint(breaker))
+	code, _, _ = ts.postForm(t, path, form)
+	assertStatus(t, code, http.StatusSeeOther)
+
+	addInfo(t, app, sID, "Fence", "waiting")
+
+	infos, _ := app.store.Infos().InfoList(ctx, sID)
+	if len(infos) != 2 || infos[0].EquipmentID != breaker ||
+		infos[0].Material != "Breaker 3" || infos[1].EquipmentID != 0 {
+		t.Fatalf("got %+v, %+v", infos[0], infos[1])
+	}
+
+	// Grouped by equipment
+	code, _, body = ts.get(t, fmt.Sprintf("/source/view/%d", sID))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "No equipment")
+	if strings.Index(body, "Breaker 3") > strings.Index(body, "Fence") {
+		t.Error("the infos of the equipment aren't first")
+	}
+
+	// The bay shows the infos of its breaker
+	code, _, body = ts.get(t, fmt.Sprintf("/source/%d/equipment/view/%d", sID, bay))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Oil leak")
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"CURATOR/internal/logging"
+)
+
+// Every error sent to the user goes through errorResponse.
+// Browsers get error.tmpl.html, API clients (JSON) get
+// {"error": {"status": 404, "message": "Not Found"}}
+
+// pageError is what error.tmpl.html displays
+type pageError struct {
+	Status    int    `json:"status"`
+	Title     string `json:"-"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+
+	// Field errors of a form (422), JSON only
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// Default messages shown under the status text
+var errorMessages = map[int]string{
+	http.StatusBadRequest:          "The request could not be understood.",
+	http.StatusForbidden:           "You are not allowed to do this.",
+	http.StatusNotFound:            "This page does not exist or has been deleted.",
+	http.StatusUnprocessableEntity: "The submitted data is not valid.",
+	http.StatusInternalServerError: "Something went wrong on our side. The error has been logged.",
+}
+
+// errorResponse writes an error with the given status.
+// An empty message uses the default one
+func (app *application) errorResponse(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if message == "" {
+		message = errorMessages[status]
+	}
+
+	pErr := &pageError{
+		Status:    status,
+		Title:     http.StatusText(status),
+		Message:   message,
+		RequestID: logging.RequestID(r.Context()),
+	}
+
+	if wantsJSON(r) {
+		app.writeJSONError(w, pErr)
+		return
+	}
+
+	// render() isn't used here: if error.tmpl.html fails
+	// it would call serverError again and loop
+	cache, err := app.templates()
+	if err != nil {
+		app.logger.ErrorContext(r.Context(), "rendering error page",
+			"error", err)
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	ts, ok := cache["error.tmpl.html"]
+	if !ok {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Error = pErr
+
+	buf := new(bytes.Buffer)
+	if err := ts.ExecuteTemplate(buf, "base", data); err != nil {
+		app.logger.ErrorContext(r.Context(), "rendering error page",
+			"error", err)
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	buf.WriteTo(w)
+}
+
+func (app *application) writeJSONError(w http.ResponseWriter, pErr *pageError) {
+	body, err := json.Marshal(map[string]*pageError{"error": pErr})
+	if err != nil {
+		http.Error(w, http.StatusText(pErr.Status), pErr.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(pErr.Status)
+	w.Write(body)
+}
+
+// fieldErrors sends the errors of a form to an API client
+func (app *application) fieldErrors(w http.ResponseWriter, r *http.Request, fields map[string]string) {
+	app.writeJSONError(w, &pageError{
+		Status:    http.StatusUnprocessableEntity,
+		Message:   errorMessages[http.StatusUnprocessableEntity],
+		RequestID: logging.RequestID(r.Context()),
+		Fields:    fields,
+	})
+}
+
+// wantsJSON is true when the client asked for JSON
+// (Accept header) or called a JSON route
+func wantsJSON(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/jsonGraph") {
+		return true
+	}
+
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "application/json") &&
+		!strings.Contains(accept, "text/html")
+}
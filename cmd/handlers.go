@@ -7,36 +7,24 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"CURATOR/database"
 	"CURATOR/internal/validator"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/jackc/pgx/v4/pgxpool"
 )
 
-func (app *application) dbConn(ctx context.Context) *pgxpool.Conn {
-	conn, err := app.DB.Acquire(ctx)
-	if err != nil {
-		app.errorLog.Println("Unable to connect to DB.")
-	}
-
-	return conn
-}
-
 //
 // Home
 //
 
 func (app *application) home(w http.ResponseWriter, r *http.Request) {
 
-	conn := app.dbConn(r.Context())
-	defer conn.Release()
-
 	// MenuSource func @ database/sources.go
-	sources, err := app.sources.MenuSource(conn)
+	sources, err := app.menuSources(r)
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 		return
 	}
 
@@ -44,7 +32,7 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 	// the JS function that generate the graphs can work proprely
 	jData, err := json.Marshal(sources)
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 		return
 	}
 
@@ -53,28 +41,47 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 	data.Sources = sources
 	data.JSource = jData
 
-	app.render(w, http.StatusOK, "home.tmpl.html", data)
+	app.render(w, r, http.StatusOK, "home.tmpl.html", data)
 }
 
 func (app *application) jsonData(w http.ResponseWriter, r *http.Request) {
-	conn := app.dbConn(r.Context())
-	defer conn.Release()
-
-	sources, err := app.sources.MenuSource(conn)
+	sources, err := app.menuSources(r)
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
+		return
 	}
 
 	jsonGraph, err := json.Marshal(sources)
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
+		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	w.Write(jsonGraph)
 }
 
+// menuSources returns the sources of the dashboard with the
+// number of open infos per tag
+func (app *application) menuSources(r *http.Request) ([]*database.Source, error) {
+	sources, err := app.store.Sources().MenuSource(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := app.store.Tags().TagCounts(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, source := range sources {
+		source.Tags = counts[source.ID]
+	}
+
+	return sources, nil
+}
+
 //
 // Sources Handlers
 //
@@ -82,48 +89,180 @@ func (app *application) jsonData(w http.ResponseWriter, r *http.Request) {
 type sourceCreateForm struct {
 	Name string
 
+	// Where it is @ cmd/map.go, Location is read
+	// from Latitude and Longitude
+	Address   string
+	Latitude  string
+	Longitude string
+	Location  *database.Location
+
+	// Saved even if other sources look alike
+	Confirm bool
+
 	validator.Validator
 }
 
+// errSimilar rolls back a source that looks like others
+var errSimilar = errors.New("similar sources")
+
+// errSameSource rolls back a move to the source of the info
+var errSameSource = errors.New("same source")
+
+// saveSource inserts the source of the form (id 0) or updates it.
+// Unless the user confirmed, it's rolled back with errSimilar
+// when other sources have a similar name, they're returned.
+// A name already taken gives database.ErrDuplicateSource
+func (app *application) saveSource(r *http.Request, id int, form sourceCreateForm) (int, []*database.Source, error) {
+	var similar []*database.Source
+
+	err := app.store.WithTx(r.Context(), func(tx database.Store) error {
+		sources := tx.Sources()
+		check := !form.Confirm
+
+		if id == 0 {
+			var err error
+			id, err = sources.SourceInsert(r.Context(), &database.Source{
+				Name: form.Name, Address: form.Address, Location: form.Location})
+			if err != nil {
+				return err
+			}
+		} else {
+			old, err := sources.SourceGet(r.Context(), id)
+			if err != nil {
+				return err
+			}
+
+			// No warning when the name stays the same
+			if database.SourceKey(old.Name) == database.SourceKey(form.Name) {
+				check = false
+			}
+
+			err = sources.SourceUpdate(r.Context(), &database.Source{ID: id,
+				Name: form.Name, Address: form.Address, Location: form.Location})
+			if err != nil {
+				return err
+			}
+		}
+
+		if !check {
+			return nil
+		}
+
+		var err error
+		similar, err = sources.SourceSimilar(r.Context(), form.Name, id)
+		if err != nil {
+			return err
+		}
+		if len(similar) > 0 {
+			return errSimilar
+		}
+
+		return nil
+	})
+
+	return id, similar, err
+}
+
 // Generate source view with a table of all infos within
 func (app *application) sourceView(w http.ResponseWriter, r *http.Request) {
-	conn := app.dbConn(r.Context())
-	defer conn.Release()
-
 	key := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(key)
 	if err != nil || id < 1 {
-		app.notFound(w)
+		app.notFound(w, r)
 		return
 	}
 
 	// Call database/sources.go function
 	// Fetch 'id' from URL create before
-	source, err := app.sources.SourceGet(id, conn)
+	source, err := app.store.Sources().SourceGet(r.Context(), id)
+	if errors.Is(err, database.ErrNoRecord) {
+		// A merged source sends to the one it was merged into
+		newID, aErr := app.store.Sources().SourceAlias(r.Context(), id)
+		if aErr == nil {
+			http.Redirect(w, r, fmt.Sprintf("/source/view/%d", newID),
+				http.StatusMovedPermanently)
+			return
+		}
+		err = aErr
+	}
 	if err != nil {
 		if errors.Is(err, database.ErrNoRecord) {
-			app.notFound(w)
+			app.notFound(w, r)
 		} else {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
+		return
 	}
 
 	// Call database/infos.go function
 	// with source id
-	info, err := app.infos.InfoList(id, conn)
+	info, err := app.store.Infos().InfoList(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, database.ErrNoRecord) {
-			app.notFound(w)
+			app.notFound(w, r)
 		} else {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
+		return
+	}
+
+	aliases, err := app.store.Sources().SourceAliases(r.Context(), id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	history, err := app.store.Audit().AuditList(r.Context(), id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	tags, err := app.store.Tags().TagList(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	infoTags, err := app.tagsOf(r, info)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
 	}
 
 	data := app.newTemplateData(r)
+
+	// ?tag=ID keeps the infos with this tag
+	if tagID, err := strconv.Atoi(r.URL.Query().Get("tag")); err == nil {
+		for _, tag := range tags {
+			if tag.ID == tagID {
+				data.Tag = tag
+			}
+		}
+		if data.Tag == nil {
+			app.notFound(w, r)
+			return
+		}
+
+		filtered := []*database.Info{}
+		for _, i := range info {
+			for _, tag := range infoTags[i.ID] {
+				if tag.ID == tagID {
+					filtered = append(filtered, i)
+				}
+			}
+		}
+		info = filtered
+	}
+
 	data.Infos = info
 	data.Source = source
+	data.Aliases = aliases
+	data.History = history
+	data.Tags = tags
+	data.InfoTags = infoTags
 
-	app.render(w, http.StatusOK, "sourceView.tmpl.html", data)
+	app.render(w, r, http.StatusOK, "sourceView.tmpl.html", data)
 
 }
 
@@ -133,73 +272,223 @@ func (app *application) sourceCreate(w http.ResponseWriter, r *http.Request) {
 	data := app.newTemplateData(r)
 	data.Form = sourceCreateForm{}
 
-	app.render(w, http.StatusOK, "sourceCreate.tmpl.html", data)
+	app.render(w, r, http.StatusOK, "sourceCreate.tmpl.html", data)
 }
 
 func (app *application) sourceCreatePost(w http.ResponseWriter, r *http.Request) {
 
-	conn := app.dbConn(r.Context())
-	defer conn.Release()
-
 	// parseForm fetch variable from URL
 	err := r.ParseForm()
 	if err != nil {
-		app.clientError(w, http.StatusBadRequest)
+		app.clientError(w, r, http.StatusBadRequest)
+		return
 	}
 
 	form := sourceCreateForm{
-		Name: r.PostForm.Get("name"),
+		Name:      r.PostForm.Get("name"),
+		Confirm:   r.PostForm.Get("confirm") == "true",
+		Address:   strings.TrimSpace(r.PostForm.Get("address")),
+		Latitude:  strings.TrimSpace(r.PostForm.Get("latitude")),
+		Longitude: strings.TrimSpace(r.PostForm.Get("longitude")),
 	}
 
-	// No empty field helper
+	// No empty field helper. Messages are translated
+	// by the template @ internal/i18n/catalog.go
 	emptyField := "Cannot be empty"
 
 	form.CheckField(validator.NotBlank(form.Name),
 		"name", emptyField)
+	form.Location = locationField(&form)
 
 	if !form.Valid() {
 		data := app.newTemplateData(r)
 		data.Form = form
-		app.render(w, http.StatusUnprocessableEntity,
+		app.render(w, r, http.StatusUnprocessableEntity,
 			"sourceCreate.tmpl.html", data)
 		return
 	}
 
 	// if no error, than data it sent to DB
-	id, err := app.sources.SourceInsert(form.Name, conn)
+	id, similar, err := app.saveSource(r, 0, form)
 	if err != nil {
-		app.serverError(w, err)
+		data := app.newTemplateData(r)
+
+		switch {
+		case errors.Is(err, database.ErrDuplicateSource):
+			form.AddFieldError("name", "A source with this name already exists")
+			data.Form = form
+			app.render(w, r, http.StatusUnprocessableEntity,
+				"sourceCreate.tmpl.html", data)
+		case errors.Is(err, errSimilar):
+			// Asks before creating a near-duplicate
+			data.Form = form
+			data.Similar = similar
+			app.render(w, r, http.StatusOK, "sourceCreate.tmpl.html", data)
+		default:
+			app.serverError(w, r, err)
+		}
 		return
 	}
 
+	app.sessionManager.Put(r.Context(), "flash", "Source created")
+
 	http.Redirect(w, r, fmt.Sprintf("/source/view/%d", id),
 		http.StatusSeeOther)
 }
 
-// Fetch source id from URL and send delete command to PSQL
+type sourceDeleteForm struct {
+	// "move" the infos to Target or "archive" them,
+	// empty when the source has no infos
+	Action string
+	Target string
+
+	validator.Validator
+}
+
+// Page asking what to do with the infos of the
+// source before it goes to the trash
+func (app *application) sourceDelete(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(key)
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	data, err := app.sourceDeleteData(r, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	data.Form = sourceDeleteForm{Action: "move"}
+
+	app.render(w, r, http.StatusOK, "sourceDelete.tmpl.html", data)
+}
+
+// sourceDeleteData fills the delete page: the source,
+// its infos and the other sources where they can go
+func (app *application) sourceDeleteData(r *http.Request, id int) (*templateData, error) {
+	source, err := app.store.Sources().SourceGet(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := app.store.Infos().InfoList(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	targets, err := app.sourceTargets(r, id)
+	if err != nil {
+		return nil, err
+	}
+
+	data := app.newTemplateData(r)
+	data.Source = source
+	data.Infos = infos
+	data.Sources = targets
+
+	return data, nil
+}
+
+// Move or archive the infos, then put the source in the trash.
+// Everything is done in one transaction
 func (app *application) sourceDeletePost(w http.ResponseWriter, r *http.Request) {
 
-	conn := app.dbConn(r.Context())
-	defer conn.Release()
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
 
 	key := chi.URLParam(r, "id")
 
 	id, err := strconv.Atoi(key)
 	if err != nil || id < 1 {
-		app.notFound(w)
+		app.notFound(w, r)
 		return
 	}
 
-	err = app.sources.SourceDelete(id, conn)
+	form := sourceDeleteForm{
+		Action: r.PostForm.Get("action"),
+		Target: r.PostForm.Get("target"),
+	}
+
+	form.CheckField(validator.PermittedValue(form.Action, "", "move", "archive"),
+		"action", "Choose what to do with the infos")
+
+	target, _ := strconv.Atoi(form.Target)
+	if form.Action == "move" {
+		form.CheckField(target > 0 && target != id,
+			"target", "Choose another source")
+	}
+
+	if !form.Valid() {
+		data, err := app.sourceDeleteData(r, id)
+		if err != nil {
+			if errors.Is(err, database.ErrNoRecord) {
+				app.notFound(w, r)
+			} else {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity,
+			"sourceDelete.tmpl.html", data)
+		return
+	}
+
+	// Both sources are locked so no info can be
+	// attached to them meanwhile
+	err = app.store.WithTx(r.Context(), func(tx database.Store) error {
+		err := tx.Sources().SourceLock(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		switch form.Action {
+		case "move":
+			err = tx.Sources().SourceLock(r.Context(), target)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Infos().InfoMoveAll(r.Context(), id, target)
+		case "archive":
+			_, err = tx.Infos().InfoArchiveAll(r.Context(), id)
+		}
+		if err != nil {
+			return err
+		}
+
+		// Fails if open infos are left
+		return tx.Sources().SourceDelete(r.Context(), id,
+			app.currentUser(r))
+	})
 	if err != nil {
 		if errors.Is(err, database.ErrNoRecord) {
-			app.notFound(w)
+			app.notFound(w, r)
+		} else if errors.Is(err, database.ErrSourceNotEmpty) {
+			app.errorResponse(w, r, http.StatusConflict,
+				"This source still has open infos, move or archive them first.")
 		} else {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
 		return
 	}
 
+	app.sessionManager.Put(r.Context(), "flash", "Source moved to the trash")
+
+	if form.Action == "move" {
+		http.Redirect(w, r, fmt.Sprintf("/source/view/%d", target),
+			http.StatusSeeOther)
+		return
+	}
+
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 
 }
@@ -207,86 +496,253 @@ func (app *application) sourceDeletePost(w http.ResponseWriter, r *http.Request)
 // Fetch data from source id and save modifications
 // made by user and send them to PSQL
 func (app *application) sourceUpdate(w http.ResponseWriter, r *http.Request) {
-	conn := app.dbConn(r.Context())
-	defer conn.Release()
-
 	key := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(key)
 	if err != nil || id < 1 {
-		app.notFound(w)
+		app.notFound(w, r)
 		return
 	}
 
-	source, err := app.sources.SourceGet(id, conn)
+	source, err := app.store.Sources().SourceGet(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, database.ErrNoRecord) {
-			app.notFound(w)
+			app.notFound(w, r)
 		} else {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
 		return
 	}
 
 	data := app.newTemplateData(r)
 	data.Source = source
+	data.Form = sourceCreateForm{
+		Name:      source.Name,
+		Address:   source.Address,
+		Latitude:  formatCoordinate(source.Location, false),
+		Longitude: formatCoordinate(source.Location, true),
+	}
 
-	app.render(w, http.StatusOK, "sourceUpdate.tmpl.html", data)
+	app.render(w, r, http.StatusOK, "sourceUpdate.tmpl.html", data)
 }
 
 func (app *application) sourceUpdatePost(w http.ResponseWriter, r *http.Request) {
 
-	conn := app.dbConn(r.Context())
-	defer conn.Release()
-
 	err := r.ParseForm()
 	if err != nil {
-		app.clientError(w, http.StatusBadRequest)
+		app.clientError(w, r, http.StatusBadRequest)
+		return
 	}
 
 	key := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(key)
-	if err != nil {
-		app.notFound(w)
+	if err != nil || id < 1 {
+		app.notFound(w, r)
 		return
 	}
 
 	form := sourceCreateForm{
-		Name: r.PostForm.Get("name"),
+		Name:      r.PostForm.Get("name"),
+		Confirm:   r.PostForm.Get("confirm") == "true",
+		Address:   strings.TrimSpace(r.PostForm.Get("address")),
+		Latitude:  strings.TrimSpace(r.PostForm.Get("latitude")),
+		Longitude: strings.TrimSpace(r.PostForm.Get("longitude")),
 	}
 
-	emptyField := "Ce champ ne doit pas être vide"
+	emptyField := "Cannot be empty"
 
 	form.CheckField(validator.NotBlank(form.Name),
 		"name", emptyField)
+	form.Location = locationField(&form)
 
 	if !form.Valid() {
 		data := app.newTemplateData(r)
 		data.Form = form
-		app.render(w, http.StatusUnprocessableEntity,
+		data.Source = &database.Source{ID: id, Name: form.Name}
+		app.render(w, r, http.StatusUnprocessableEntity,
 			"sourceUpdate.tmpl.html", data)
 		return
 	}
 
-	app.sources.Name = form.Name
-
-	err = app.sources.SourceUpdate(id, conn)
+	_, similar, err := app.saveSource(r, id, form)
 	if err != nil {
-		app.serverError(w, err)
+		data := app.newTemplateData(r)
+		data.Source = &database.Source{ID: id, Name: form.Name}
+
+		switch {
+		case errors.Is(err, database.ErrNoRecord):
+			app.notFound(w, r)
+		case errors.Is(err, database.ErrDuplicateSource):
+			form.AddFieldError("name", "A source with this name already exists")
+			data.Form = form
+			app.render(w, r, http.StatusUnprocessableEntity,
+				"sourceUpdate.tmpl.html", data)
+		case errors.Is(err, errSimilar):
+			data.Form = form
+			data.Similar = similar
+			app.render(w, r, http.StatusOK, "sourceUpdate.tmpl.html", data)
+		default:
+			app.serverError(w, r, err)
+		}
 		return
 	}
 
+	app.sessionManager.Put(r.Context(), "flash", "Source updated")
+
 	http.Redirect(w, r, fmt.Sprintf("/source/view/%d", id),
 		http.StatusSeeOther)
 
 }
 
+type sourceMergeForm struct {
+	// ID of the source kept
+	Target string
+
+	validator.Validator
+}
+
+// sourceTargets returns every source but the one of id,
+// they are the choices of the merge and move forms
+func (app *application) sourceTargets(r *http.Request, id int) ([]*database.Source, error) {
+	sources, err := app.store.Sources().MenuSource(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	targets := []*database.Source{}
+	for _, s := range sources {
+		if s.ID != id {
+			targets = append(targets, s)
+		}
+	}
+
+	return targets, nil
+}
+
+// Page to merge a duplicate source into another
+func (app *application) sourceMerge(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(key)
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	app.renderSourceMerge(w, r, id, http.StatusOK, sourceMergeForm{})
+}
+
+func (app *application) renderSourceMerge(w http.ResponseWriter, r *http.Request, id, status int, form sourceMergeForm) {
+	source, err := app.store.Sources().SourceGet(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	targets, err := app.sourceTargets(r, id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Source = source
+	data.Sources = targets
+	data.Form = form
+
+	app.render(w, r, status, "sourceMerge.tmpl.html", data)
+}
+
+// The source of the URL is merged into the target:
+// infos, aliases and history move, then it's deleted.
+// An API client (Accept: application/json) gets JSON
+func (app *application) sourceMergePost(w http.ResponseWriter, r *http.Request) {
+
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	key := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(key)
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	form := sourceMergeForm{
+		Target: r.PostForm.Get("target"),
+	}
+
+	target, _ := strconv.Atoi(form.Target)
+	form.CheckField(target > 0 && target != id,
+		"target", "Choose another source")
+
+	if !form.Valid() {
+		if wantsJSON(r) {
+			app.fieldErrors(w, r, form.FieldErrors)
+			return
+		}
+		app.renderSourceMerge(w, r, id, http.StatusUnprocessableEntity, form)
+		return
+	}
+
+	err = app.store.WithTx(r.Context(), func(tx database.Store) error {
+		from, err := tx.Sources().SourceGet(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		to, err := tx.Sources().SourceGet(r.Context(), target)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Sources().SourceMerge(r.Context(), id, target)
+		if err != nil {
+			return err
+		}
+
+		// Written after the merge so it's in the
+		// history of the source kept
+		return tx.Audit().AuditInsert(r.Context(), &database.AuditEntry{
+			Actor:    app.currentUser(r),
+			Action:   database.AuditSourceMerge,
+			SourceID: target,
+			Detail:   fmt.Sprintf("%s (#%d) → %s", from.Name, from.ID, to.Name),
+		})
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	if wantsJSON(r) {
+		app.writeJSON(w, r, http.StatusOK, map[string]int{
+			"merged": id,
+			"into":   target,
+		})
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Sources merged")
+
+	http.Redirect(w, r, fmt.Sprintf("/source/view/%d", target),
+		http.StatusSeeOther)
+}
+
 //
 // Infos Handlers
 //
 
 type infoCreateForm struct {
 	ID       int
-	Agent    string
 	Material string
 	Priority string
 	Detail   string
@@ -295,6 +751,27 @@ type infoCreateForm struct {
 	Status   string
 	Estimate string
 
+	// IDs of the agents who reported the defect and
+	// who it's assigned to, "" for nobody
+	Reporter string
+	Assignee string
+
+	// Team assigned, due and planned dates (YYYY-MM-DD),
+	// "" for none
+	Team    string
+	Due     string
+	Planned string
+
+	// ID of the equipment picked, "" for none
+	Equipment string
+
+	// IDs of the tags checked
+	Tags map[string]bool
+
+	// Answer to the possible duplicates page: "new" creates
+	// the info anyway, an info ID links the report to it
+	Duplicate string
+
 	validator.Validator
 }
 
@@ -302,252 +779,784 @@ type infoCreateForm struct {
 // to source_id (FK)
 func (app *application) infoCreate(w http.ResponseWriter, r *http.Request) {
 
-	conn := app.dbConn(r.Context())
-	defer conn.Release()
-
 	// Fetch source id from URL
 	key := chi.URLParam(r, "id")
 
 	id, err := strconv.Atoi(key)
 	if err != nil || id < 1 {
-		app.notFound(w)
+		app.notFound(w, r)
 		return
 	}
 
-	source, err := app.sources.SourceGet(id, conn)
+	source, err := app.store.Sources().SourceGet(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, database.ErrNoRecord) {
-			app.notFound(w)
+			app.notFound(w, r)
 		} else {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
 		return
 	}
 
+	equipment, err := app.store.Equipment().EquipmentList(r.Context(), id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	tags, err := app.store.Tags().TagList(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	agents, err := app.store.Agents().AgentList(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
 	data := app.newTemplateData(r)
-	data.Form = infoCreateForm{}
+	// "waiting" is checked by default
+	data.Form = infoCreateForm{Status: "waiting"}
 	data.Source = source
+	data.EquipmentList = equipment
+	data.Tags = tags
+	data.Agents = agents
+	data.Teams = teamsOf(agents, nil)
 
-	app.render(w, http.StatusOK, "infoCreate.tmpl.html", data)
+	app.render(w, r, http.StatusOK, "infoCreate.tmpl.html", data)
 }
 
 func (app *application) infoCreatePost(w http.ResponseWriter, r *http.Request) {
 
-	conn := app.dbConn(r.Context())
-	defer conn.Release()
-
 	err := r.ParseForm()
 	if err != nil {
-		app.clientError(w, http.StatusBadRequest)
+		app.clientError(w, r, http.StatusBadRequest)
+		return
 	}
 
 	key := chi.URLParam(r, "id")
 
 	sID, err := strconv.Atoi(key)
 	if err != nil || sID < 1 {
-		app.notFound(w)
+		app.notFound(w, r)
+		return
+	}
+
+	// The source must exist before an info is attached to it
+	source, err := app.store.Sources().SourceGet(r.Context(), sID)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
 		return
 	}
 
 	// Les données récupérés depuis la page HTML sont envoyées
 	// vers la BD
 	form := infoCreateForm{
-		Agent:    r.PostForm.Get("agent"),
 		Material: r.PostForm.Get("material"),
 		Detail:   r.PostForm.Get("detail"),
 		Priority: r.PostForm.Get("priority"),
 		Estimate: r.PostForm.Get("estimate"),
 		Status:   r.PostForm.Get("status"),
+
+		Reporter:  r.PostForm.Get("reporter"),
+		Assignee:  r.PostForm.Get("assignee"),
+		Team:      r.PostForm.Get("team"),
+		Due:       r.PostForm.Get("due"),
+		Planned:   r.PostForm.Get("planned"),
+		Equipment: r.PostForm.Get("equipment"),
+		Duplicate: r.PostForm.Get("duplicate"),
+	}
+
+	// Fills the material when only the equipment is given
+	equipment, equipmentID, err := app.equipmentField(r, &form, sID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// tagsField @ cmd/tags.go
+	tags, tagIDs, err := app.tagsField(r, &form)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// agentsField @ cmd/agents.go, teamField and dateField
+	// @ cmd/assign.go
+	agents, reporter, assigneeID, err := app.agentsField(r, &form, nil)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
 	}
+	teamField(&form, agents, nil)
+	due := dateField(&form, "due", form.Due)
+	planned := dateField(&form, "planned", form.Planned)
 
 	// These can't be empty
 	// Below ensures that the user is alerted
 	emptyField := "Cannot be empty"
 
-	form.CheckField(validator.NotBlank(form.Agent),
-		"agent", emptyField)
+	form.CheckField(validator.NotBlank(form.Reporter),
+		"reporter", emptyField)
 	form.CheckField(validator.NotBlank(form.Material),
 		"material", emptyField)
 	form.CheckField(validator.NotBlank(form.Detail),
 		"detail", emptyField)
 	form.CheckField(validator.NotBlank(form.Priority),
 		"priority", emptyField)
+	form.CheckField(validator.IsInt(form.Priority),
+		"priority", "Must be a number")
 	form.CheckField(validator.NotBlank(form.Status),
 		"status", emptyField)
+	form.CheckField(validator.PermittedValue(form.Status, database.InfoStatuses...),
+		"status", "Choose a status")
 
 	if !form.Valid() {
 		data := app.newTemplateData(r)
 		data.Form = form
-		app.render(w, http.StatusUnprocessableEntity,
+		data.Source = source
+		data.EquipmentList = equipment
+		data.Tags = tags
+		data.Agents = agents
+		data.Teams = teamsOf(agents, nil)
+		app.render(w, r, http.StatusUnprocessableEntity,
 			"infoCreate.tmpl.html", data)
 		return
 	}
 
-	app.infos.Agent = form.Agent
-	app.infos.Material = form.Material
-	app.infos.Detail = form.Detail
-	app.infos.Estimate = form.Estimate
-	app.infos.Status = form.Status
-	app.infos.Priority, err = strconv.Atoi(form.Priority)
-	if err != nil {
-		app.notFound(w)
+	switch form.Duplicate {
+	case "":
+		// Same defect already reported? The user decides
+		similar, err := app.store.Infos().InfoSimilar(r.Context(),
+			sID, form.Material, form.Detail)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		if len(similar) > 0 {
+			data := app.newTemplateData(r)
+			data.Form = form
+			data.Source = source
+			data.Infos = similar
+			data.Tags = tags
+			app.render(w, r, http.StatusOK, "infoDuplicates.tmpl.html", data)
+			return
+		}
+	case "new":
+	default:
+		app.infoLink(w, r, sID, form, reporter.Name)
 		return
 	}
 
-	_, err = app.infos.Insert(sID, conn)
+	// A new Info per request, nothing is shared between users
+	info := &database.Info{
+		SourceID: sID,
+		Agent:    reporter.Name,
+		Material: form.Material,
+		Detail:   form.Detail,
+		Estimate: form.Estimate,
+		Status:   form.Status,
+
+		ReporterID:  reporter.ID,
+		AssigneeID:  assigneeID,
+		Team:        form.Team,
+		Due:         due,
+		Planned:     planned,
+		EquipmentID: equipmentID,
+	}
+	info.Priority, _ = strconv.Atoi(form.Priority)
+
+	// The info, its tags and who has it, or nothing
+	err = app.store.WithTx(r.Context(), func(tx database.Store) error {
+		id, err := tx.Infos().InfoInsert(r.Context(), info)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Tags().TagsSet(r.Context(), id, tagIDs)
+		if err != nil {
+			return err
+		}
+
+		return app.auditAssign(r, tx, info, "", "")
+	})
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 		return
 	}
 
+	app.sessionManager.Put(r.Context(), "flash", "Info created")
+
 	http.Redirect(w, r, fmt.Sprintf("/source/view/%d", sID),
 		http.StatusSeeOther)
 }
 
+// infoLink adds the report of the form to an open info
+// of the source instead of creating a second one.
+// actor is the name of the reporter
+func (app *application) infoLink(w http.ResponseWriter, r *http.Request, sID int, form infoCreateForm, actor string) {
+	id, err := strconv.Atoi(form.Duplicate)
+	if err != nil || id < 1 {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	err = app.store.WithTx(r.Context(), func(tx database.Store) error {
+		info, err := tx.Infos().InfoGet(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		if info.SourceID != sID || info.Status == "archived" {
+			return database.ErrNoRecord
+		}
+
+		return tx.Audit().AuditInsert(r.Context(), &database.AuditEntry{
+			Actor:    actor,
+			Action:   database.AuditInfoReport,
+			SourceID: sID,
+			InfoID:   id,
+			Detail:   form.Detail,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Report added to the existing info")
+
+	http.Redirect(w, r, fmt.Sprintf("/source/%d/info/view/%d", sID, id),
+		http.StatusSeeOther)
+}
+
 // Show detailed data from info
 func (app *application) infoView(w http.ResponseWriter, r *http.Request) {
-	conn := app.dbConn(r.Context())
-	defer conn.Release()
-
 	iKey := chi.URLParam(r, "id")
 
 	id, err := strconv.Atoi(iKey)
 	if err != nil || id < 1 {
-		app.notFound(w)
+		app.notFound(w, r)
 		return
 	}
 
-	info, err := app.infos.InfoGet(id, conn)
+	info, err := app.store.Infos().InfoGet(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, database.ErrNoRecord) {
-			app.notFound(w)
+			app.notFound(w, r)
 		} else {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
 		return
 	}
 
+	// Sources where the info can be moved
+	targets, err := app.sourceTargets(r, info.SourceID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// Moves and reports of the same defect
+	history, err := app.store.Audit().AuditListInfo(r.Context(), id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	tags, err := app.store.Tags().TagsOfInfos(r.Context(), []int{id})
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// For the assign form
+	agents, err := app.store.Agents().AgentList(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	form := infoCreateForm{Team: info.Team}
+	if info.AssigneeID != 0 {
+		form.Assignee = strconv.Itoa(info.AssigneeID)
+	}
+
 	data := app.newTemplateData(r)
 	data.Info = info
-
-	app.render(w, http.StatusOK, "infoView.tmpl.html", data)
+	data.Sources = targets
+	data.History = history
+	data.InfoTags = tags
+	data.Agents = agents
+	data.Teams = teamsOf(agents, info)
+	data.Form = form
+
+	app.render(w, r, http.StatusOK, "infoView.tmpl.html", data)
 }
 
-// delete info
+// Move the info to the trash
 func (app *application) infoDeletePost(w http.ResponseWriter, r *http.Request) {
 
-	conn := app.dbConn(r.Context())
-	defer conn.Release()
-
 	sKey := chi.URLParam(r, "sid")
 	iKey := chi.URLParam(r, "id")
 
 	id, err := strconv.Atoi(iKey)
 	if err != nil || id < 1 {
-		app.notFound(w)
+		app.notFound(w, r)
 		return
 	}
 
 	sID, err := strconv.Atoi(sKey)
 	if err != nil || sID < 1 {
-		app.notFound(w)
+		app.notFound(w, r)
 		return
 	}
 
-	err = app.infos.InfoDelete(id, conn)
+	err = app.store.Infos().InfoDelete(r.Context(), id, app.currentUser(r))
 	if err != nil {
 		if errors.Is(err, database.ErrNoRecord) {
-			app.notFound(w)
+			app.notFound(w, r)
 		} else {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
 		return
 	}
 
+	app.sessionManager.Put(r.Context(), "flash", "Info moved to the trash")
+
 	http.Redirect(w, r, fmt.Sprintf("/source/view/%d", sID),
 		http.StatusSeeOther)
 
 }
 
+// Move one info to another source. Both sources get
+// an entry in their history
+func (app *application) infoMovePost(w http.ResponseWriter, r *http.Request) {
+
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	iKey := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(iKey)
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	badTarget := func() {
+		if wantsJSON(r) {
+			app.fieldErrors(w, r, map[string]string{
+				"target": "Choose another source",
+			})
+			return
+		}
+		app.clientError(w, r, http.StatusBadRequest)
+	}
+
+	target, err := strconv.Atoi(r.PostForm.Get("target"))
+	if err != nil || target < 1 {
+		badTarget()
+		return
+	}
+
+	err = app.store.WithTx(r.Context(), func(tx database.Store) error {
+		info, err := tx.Infos().InfoGet(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		if info.SourceID == target {
+			return errSameSource
+		}
+		from, err := tx.Sources().SourceGet(r.Context(), info.SourceID)
+		if err != nil {
+			return err
+		}
+
+		// No info can be moved to a source being deleted
+		err = tx.Sources().SourceLock(r.Context(), target)
+		if err != nil {
+			return err
+		}
+		to, err := tx.Sources().SourceGet(r.Context(), target)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Infos().InfoMove(r.Context(), id, target)
+		if err != nil {
+			return err
+		}
+
+		detail := fmt.Sprintf("%s: %s → %s", info.Material, from.Name, to.Name)
+		for _, sID := range []int{from.ID, to.ID} {
+			err = tx.Audit().AuditInsert(r.Context(), &database.AuditEntry{
+				Actor:    app.currentUser(r),
+				Action:   database.AuditInfoMove,
+				SourceID: sID,
+				InfoID:   id,
+				Detail:   detail,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrNoRecord):
+			app.notFound(w, r)
+		case errors.Is(err, errSameSource):
+			badTarget()
+		default:
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	if wantsJSON(r) {
+		app.writeJSON(w, r, http.StatusOK, map[string]int{
+			"info":   id,
+			"source": target,
+		})
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Info moved")
+
+	http.Redirect(w, r, fmt.Sprintf("/source/%d/info/view/%d",
+		target, id), http.StatusSeeOther)
+}
+
 // Updates info. Same behavior as sourceUpdate
 func (app *application) infoUpdate(w http.ResponseWriter, r *http.Request) {
-	conn := app.dbConn(r.Context())
-	defer conn.Release()
-
 	key := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(key)
 	if err != nil || id < 1 {
-		app.notFound(w)
+		app.notFound(w, r)
 		return
 	}
 
 	// id ~> Info id
-	info, err := app.infos.InfoGet(id, conn)
+	info, err := app.store.Infos().InfoGet(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, database.ErrNoRecord) {
-			app.notFound(w)
+			app.notFound(w, r)
 		} else {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
 		return
 	}
 
+	equipment, err := app.store.Equipment().EquipmentList(r.Context(), info.SourceID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	form := infoCreateForm{
+		ID:       info.ID,
+		Material: info.Material,
+		Priority: strconv.Itoa(info.Priority),
+		Detail:   info.Detail,
+		Estimate: info.Estimate,
+		Status:   info.Status,
+	}
+	if info.EquipmentID != 0 {
+		form.Equipment = strconv.Itoa(info.EquipmentID)
+	}
+	if info.ReporterID != 0 {
+		form.Reporter = strconv.Itoa(info.ReporterID)
+	}
+	if info.AssigneeID != 0 {
+		form.Assignee = strconv.Itoa(info.AssigneeID)
+	}
+	form.Team = info.Team
+	if !info.Due.IsZero() {
+		form.Due = info.Due.Format(dueLayout)
+	}
+	if !info.Planned.IsZero() {
+		form.Planned = info.Planned.Format(dueLayout)
+	}
+
+	agents, err := app.store.Agents().AgentList(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	tags, err := app.store.Tags().TagList(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	checked, err := app.store.Tags().TagsOfInfos(r.Context(), []int{id})
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	form.Tags = map[string]bool{}
+	for _, tag := range checked[id] {
+		form.Tags[strconv.Itoa(tag.ID)] = true
+	}
+
 	data := app.newTemplateData(r)
 	data.Info = info
+	data.Form = form
+	data.EquipmentList = equipment
+	data.Tags = tags
+	data.Agents = agents
+	data.Teams = teamsOf(agents, info)
 
-	app.render(w, http.StatusOK, "infoUpdate.tmpl.html", data)
+	app.render(w, r, http.StatusOK, "infoUpdate.tmpl.html", data)
 }
 
 func (app *application) infoUpdatePost(w http.ResponseWriter, r *http.Request) {
 
-	conn := app.dbConn(r.Context())
-	defer conn.Release()
-
 	err := r.ParseForm()
 	if err != nil {
-		app.clientError(w, http.StatusBadRequest)
+		app.clientError(w, r, http.StatusBadRequest)
+		return
 	}
 
 	sKey := chi.URLParam(r, "sid")
 	sID, err := strconv.Atoi(sKey)
 	if err != nil || sID < 1 {
-		app.notFound(w)
+		app.notFound(w, r)
 		return
 	}
 
 	iKey := chi.URLParam(r, "id")
 	iID, err := strconv.Atoi(iKey)
 	if err != nil || iID < 1 {
-		app.notFound(w)
+		app.notFound(w, r)
+		return
+	}
+
+	current, err := app.store.Infos().InfoGet(r.Context(), iID)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	data, err := app.updateInfo(r, current)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	if data != nil {
+		app.render(w, r, http.StatusUnprocessableEntity,
+			"infoUpdate.tmpl.html", data)
 		return
 	}
 
+	app.sessionManager.Put(r.Context(), "flash", "Info updated")
+
+	http.Redirect(w, r, fmt.Sprintf("/source/%d/info/view/%d",
+		current.SourceID, iID), http.StatusSeeOther)
+}
+
+// updateInfo checks the info form of r.PostForm and saves it over
+// current. The edit page and the board (@ cmd/board.go) both go
+// through it. When the form isn't valid, the edit page to show
+// again is returned
+func (app *application) updateInfo(r *http.Request, current *database.Info) (*templateData, error) {
+	iID := current.ID
+	sID := current.SourceID
+
 	form := infoCreateForm{
-		Agent:    r.PostForm.Get("agent"),
 		Material: r.PostForm.Get("material"),
 		Detail:   r.PostForm.Get("detail"),
 		Priority: r.PostForm.Get("priority"),
 		Estimate: r.PostForm.Get("estimate"),
 		Status:   r.PostForm.Get("status"),
+
+		Reporter:  r.PostForm.Get("reporter"),
+		Assignee:  r.PostForm.Get("assignee"),
+		Team:      r.PostForm.Get("team"),
+		Due:       r.PostForm.Get("due"),
+		Planned:   r.PostForm.Get("planned"),
+		Equipment: r.PostForm.Get("equipment"),
 	}
 
-	app.infos.Agent = form.Agent
-	app.infos.Material = form.Material
-	app.infos.Detail = form.Detail
-	app.infos.Estimate = form.Estimate
-	app.infos.Status = form.Status
-	app.infos.Priority, err = strconv.Atoi(form.Priority)
+	// The equipment must be one of the source of the info
+	equipment, equipmentID, err := app.equipmentField(r, &form, sID)
 	if err != nil {
-		app.notFound(w)
+		return nil, err
+	}
+
+	tags, tagIDs, err := app.tagsField(r, &form)
+	if err != nil {
+		return nil, err
+	}
+
+	// The agents and the team it already had are kept
+	// even if inactive
+	agents, reporter, assigneeID, err := app.agentsField(r, &form, current)
+	if err != nil {
+		return nil, err
+	}
+	teamField(&form, agents, current)
+	due := dateField(&form, "due", form.Due)
+	planned := dateField(&form, "planned", form.Planned)
+
+	emptyField := "Cannot be empty"
+
+	form.CheckField(validator.NotBlank(form.Reporter),
+		"reporter", emptyField)
+	form.CheckField(validator.NotBlank(form.Material),
+		"material", emptyField)
+	form.CheckField(validator.NotBlank(form.Detail),
+		"detail", emptyField)
+	form.CheckField(validator.NotBlank(form.Priority),
+		"priority", emptyField)
+	form.CheckField(validator.IsInt(form.Priority),
+		"priority", "Must be a number")
+	form.CheckField(validator.NotBlank(form.Status),
+		"status", emptyField)
+	form.CheckField(validator.PermittedValue(form.Status, database.InfoStatuses...),
+		"status", "Choose a status")
+
+	if !form.Valid() {
+		// The page is filled with what the user sent (.Form),
+		// .Info only gives the IDs of the links
+		data := app.newTemplateData(r)
+		data.Form = form
+		data.Info = &database.Info{ID: iID, SourceID: sID}
+		data.EquipmentList = equipment
+		data.Tags = tags
+		data.Agents = agents
+		data.Teams = teamsOf(agents, current)
+		return data, nil
+	}
+
+	info := &database.Info{
+		ID:       iID,
+		SourceID: sID,
+		Agent:    reporter.Name,
+		Material: form.Material,
+		Detail:   form.Detail,
+		Estimate: form.Estimate,
+		Status:   form.Status,
+
+		ReporterID:  reporter.ID,
+		AssigneeID:  assigneeID,
+		Team:        form.Team,
+		Due:         due,
+		Planned:     planned,
+		EquipmentID: equipmentID,
+	}
+	info.Priority, _ = strconv.Atoi(form.Priority)
+
+	return nil, app.store.WithTx(r.Context(), func(tx database.Store) error {
+		err := tx.Infos().InfoUpdate(r.Context(), info)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Tags().TagsSet(r.Context(), iID, tagIDs)
+		if err != nil {
+			return err
+		}
+
+		// Reassigned: written in the history
+		return app.auditAssign(r, tx, info, current.Assignee, current.Team)
+	})
+}
+
+//
+// Trash Handlers
+//
+
+// Sources and infos deleted, until they are purged
+func (app *application) trash(w http.ResponseWriter, r *http.Request) {
+	sources, err := app.store.Sources().SourceTrash(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
 		return
 	}
 
-	err = app.infos.InfoUpdate(iID, conn)
+	infos, err := app.store.Infos().InfoTrash(r.Context())
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 		return
 	}
 
-	http.Redirect(w, r, fmt.Sprintf("/source/%d/info/view/%d",
-		sID, iID), http.StatusSeeOther)
+	data := app.newTemplateData(r)
+	data.Sources = sources
+	data.Infos = infos
+	data.Retention = int(app.trashRetention.Hours() / 24)
+
+	app.render(w, r, http.StatusOK, "trash.tmpl.html", data)
+}
+
+// trashAction runs restore or purge with the id of the URL
+// then goes back to the trash with a flash message
+func (app *application) trashAction(action func(ctx context.Context, id int) error, flash string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := chi.URLParam(r, "id")
+		id, err := strconv.Atoi(key)
+		if err != nil || id < 1 {
+			app.notFound(w, r)
+			return
+		}
+
+		err = action(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, database.ErrNoRecord) {
+				app.notFound(w, r)
+			} else if errors.Is(err, database.ErrDuplicateSource) {
+				app.errorResponse(w, r, http.StatusConflict,
+					"Another source has this name now, rename it before restoring this one.")
+			} else {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+
+		app.sessionManager.Put(r.Context(), "flash", flash)
+
+		http.Redirect(w, r, "/trash", http.StatusSeeOther)
+	}
+}
+
+func (app *application) sourceRestorePost(w http.ResponseWriter, r *http.Request) {
+	app.trashAction(app.store.Sources().SourceRestore, "Source restored")(w, r)
+}
+
+func (app *application) sourcePurgePost(w http.ResponseWriter, r *http.Request) {
+	app.trashAction(app.store.Sources().SourcePurge, "Source deleted for good")(w, r)
+}
+
+func (app *application) infoRestorePost(w http.ResponseWriter, r *http.Request) {
+	app.trashAction(app.store.Infos().InfoRestore, "Info restored")(w, r)
+}
+
+func (app *application) infoPurgePost(w http.ResponseWriter, r *http.Request) {
+	app.trashAction(app.store.Infos().InfoPurge, "Info deleted for good")(w, r)
 }
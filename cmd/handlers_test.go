@@ -0,0 +1,870 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHome(t *testing.T) {
+	app := newTestApplication(t)
+	addSource(t, app, "Billancourt")
+	ts := newTestServer(t, app.routes())
+
+	code, _, body := ts.get(t, "/")
+
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Billancourt")
+}
+
+func TestJSONGraph(t *testing.T) {
+	app := newTestApplication(t)
+	sID := addSource(t, app, "Billancourt")
+	addInfo(t, app, sID, "Transfo 1", "waiting")
+	addInfo(t, app, sID, "Transfo 2", "archived")
+	ts := newTestServer(t, app.routes())
+
+	code, header, body := ts.get(t, "/jsonGraph")
+
+	assertStatus(t, code, http.StatusOK)
+	if ct := header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("got Content-Type %q", ct)
+	}
+
+	var sources []struct {
+		Name     string `json:"name"`
+		Curatifs int    `json:"curatifs"`
+	}
+	if err := json.Unmarshal([]byte(body), &sources); err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 1 || sources[0].Curatifs != 1 {
+		t.Errorf("got %+v", sources)
+	}
+}
+
+func TestSourceView(t *testing.T) {
+	app := newTestApplication(t)
+	sID := addSource(t, app, "Billancourt")
+	addInfo(t, app, sID, "Transfo 1", "waiting")
+	ts := newTestServer(t, app.routes())
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{"Valid ID", fmt.Sprintf("/source/view/%d", sID), http.StatusOK, "Transfo 1"},
+		{"Non-existent ID", "/source/view/42", http.StatusNotFound, ""},
+		{"Negative ID", "/source/view/-1", http.StatusNotFound, ""},
+		{"Decimal ID", "/source/view/1.23", http.StatusNotFound, ""},
+		{"String ID", "/source/view/foo", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assertStatus(t, code, tt.wantCode)
+			if tt.wantBody != "" {
+				assertContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSourceCreate(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	code, _, body := ts.get(t, "/source/create")
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, `name="name"`)
+
+	code, header, _ := ts.postForm(t, "/source/create",
+		url.Values{"name": {"Billancourt"}})
+	assertStatus(t, code, http.StatusSeeOther)
+	if loc := header.Get("Location"); loc != "/source/view/1" {
+		t.Errorf("got Location %q", loc)
+	}
+
+	src, err := app.store.Sources().SourceGet(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Name != "Billancourt" {
+		t.Errorf("got name %q", src.Name)
+	}
+
+	// Validation failure
+	code, _, _ = ts.postForm(t, "/source/create", url.Values{"name": {"  "}})
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+}
+
+func TestSourceUpdate(t *testing.T) {
+	app := newTestApplication(t)
+	sID := addSource(t, app, "Billancourt")
+	ts := newTestServer(t, app.routes())
+	path := fmt.Sprintf("/source/update/%d", sID)
+
+	code, _, body := ts.get(t, path)
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Billancourt")
+
+	code, _, _ = ts.get(t, "/source/update/42")
+	assertStatus(t, code, http.StatusNotFound)
+
+	code, _, _ = ts.postForm(t, path, url.Values{"name": {""}})
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+
+	code, _, _ = ts.postForm(t, "/source/update/42",
+		url.Values{"name": {"Nanterre"}})
+	assertStatus(t, code, http.StatusNotFound)
+
+	code, _, _ = ts.postForm(t, path, url.Values{"name": {"Nanterre"}})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	src, _ := app.store.Sources().SourceGet(context.Background(), sID)
+	if src.Name != "Nanterre" {
+		t.Errorf("got name %q; want Nanterre", src.Name)
+	}
+}
+
+func TestSourceNames(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	id := addSource(t, app, "Évry Sud")
+	ts := newTestServer(t, app.routes())
+
+	// Same name once normalised
+	code, _, body := ts.postForm(t, "/source/create", url.Values{"name": {"  evry   SUD"}})
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+	assertContains(t, body, "A source with this name already exists")
+
+	// Close name: asks first, nothing is created
+	code, _, body = ts.postForm(t, "/source/create", url.Values{"name": {"Evry"}})
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "These sources look alike")
+	assertContains(t, body, fmt.Sprintf(`href="/source/view/%d"`, id))
+	if menu, _ := app.store.Sources().MenuSource(ctx); len(menu) != 1 {
+		t.Fatalf("got %d sources; want 1", len(menu))
+	}
+
+	code, _, _ = ts.postForm(t, "/source/create", url.Values{
+		"name":    {"Evry"},
+		"confirm": {"true"},
+	})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	// Keeping its own name is no warning
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/source/update/%d", id),
+		url.Values{"name": {"ÉVRY SUD"}})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	code, _, body = ts.postForm(t, fmt.Sprintf("/source/update/%d", id),
+		url.Values{"name": {"evry"}})
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+	assertContains(t, body, "A source with this name already exists")
+
+	// The name of a source in the trash can be taken
+	if err := app.store.Sources().SourceDelete(ctx, id, "Dupont"); err != nil {
+		t.Fatal(err)
+	}
+	addSource(t, app, "Evry Sud")
+
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/trash/source/%d/restore", id), nil)
+	assertStatus(t, code, http.StatusConflict)
+}
+
+func TestSourceDelete(t *testing.T) {
+	app := newTestApplication(t)
+	empty := addSource(t, app, "Empty")
+	full := addSource(t, app, "Full")
+	addInfo(t, app, full, "Transfo 1", "waiting")
+	ts := newTestServer(t, app.routes())
+
+	code, header, _ := ts.postForm(t,
+		fmt.Sprintf("/source/delete/%d", empty), nil)
+	assertStatus(t, code, http.StatusSeeOther)
+	if loc := header.Get("Location"); loc != "/" {
+		t.Errorf("got Location %q", loc)
+	}
+
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/source/delete/%d", empty), nil)
+	assertStatus(t, code, http.StatusNotFound)
+
+	// A source with infos is kept
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/source/delete/%d", full), nil)
+	assertStatus(t, code, http.StatusConflict)
+
+	if _, err := app.store.Sources().SourceGet(context.Background(), full); err != nil {
+		t.Errorf("source with infos was deleted: %v", err)
+	}
+}
+
+func TestSourceDeleteInfos(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	from := addSource(t, app, "Billancourt")
+	to := addSource(t, app, "Nanterre")
+	open := addInfo(t, app, from, "Transfo 1", "waiting")
+	archived := addInfo(t, app, from, "Transfo 2", "archived")
+	ts := newTestServer(t, app.routes())
+	path := fmt.Sprintf("/source/delete/%d", from)
+
+	// The page shows what will be affected
+	code, _, body := ts.get(t, path)
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Transfo 1")
+	assertContains(t, body, "Transfo 2")
+	assertContains(t, body, fmt.Sprintf(`<option value="%d"`, to))
+	if strings.Contains(body, fmt.Sprintf(`<option value="%d"`, from)) {
+		t.Error("the source itself is a target")
+	}
+
+	tests := []struct {
+		name   string
+		action string
+		target string
+	}{
+		{"Unknown action", "drop", ""},
+		{"No target", "move", ""},
+		{"Same source", "move", fmt.Sprint(from)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := ts.postForm(t, path, url.Values{
+				"action": {tt.action},
+				"target": {tt.target},
+			})
+			assertStatus(t, code, http.StatusUnprocessableEntity)
+		})
+	}
+
+	code, _, _ = ts.postForm(t, path, url.Values{
+		"action": {"move"},
+		"target": {"42"},
+	})
+	assertStatus(t, code, http.StatusNotFound)
+
+	// Move: every info goes to the other source
+	code, header, _ := ts.postForm(t, path, url.Values{
+		"action": {"move"},
+		"target": {fmt.Sprint(to)},
+	})
+	assertStatus(t, code, http.StatusSeeOther)
+	if loc := header.Get("Location"); loc != fmt.Sprintf("/source/view/%d", to) {
+		t.Errorf("got Location %q", loc)
+	}
+
+	for _, id := range []int{open, archived} {
+		info, err := app.store.Infos().InfoGet(ctx, id)
+		if err != nil || info.SourceID != to {
+			t.Errorf("info %d not moved: %+v, %v", id, info, err)
+		}
+	}
+	if _, err := app.store.Sources().SourceGet(ctx, from); err == nil {
+		t.Error("source not deleted")
+	}
+
+	// Archive: the infos stay with the source in the trash
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/source/delete/%d", to),
+		url.Values{"action": {"archive"}})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	count, _ := app.store.Infos().StatusCount(ctx)
+	if count["waiting"] != 0 {
+		t.Errorf("got %v; want no open info", count)
+	}
+	trash, _ := app.store.Sources().SourceTrash(ctx)
+	if len(trash) != 2 {
+		t.Errorf("got %d sources in the trash; want 2", len(trash))
+	}
+}
+
+func TestSourceMerge(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	from := addSource(t, app, "Billancourt")
+	to := addSource(t, app, "Nanterre")
+	info := addInfo(t, app, from, "Transfo 1", "waiting")
+	ts := newTestServer(t, app.routes())
+	path := fmt.Sprintf("/source/merge/%d", from)
+
+	code, _, body := ts.get(t, path)
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, fmt.Sprintf(`<option value="%d"`, to))
+
+	for _, target := range []string{"", "abc", fmt.Sprint(from)} {
+		code, _, _ := ts.postForm(t, path, url.Values{"target": {target}})
+		assertStatus(t, code, http.StatusUnprocessableEntity)
+	}
+
+	code, _, _ = ts.postForm(t, path, url.Values{"target": {"42"}})
+	assertStatus(t, code, http.StatusNotFound)
+
+	code, header, _ := ts.postForm(t, path, url.Values{"target": {fmt.Sprint(to)}})
+	assertStatus(t, code, http.StatusSeeOther)
+	if loc := header.Get("Location"); loc != fmt.Sprintf("/source/view/%d", to) {
+		t.Errorf("got Location %q", loc)
+	}
+
+	got, err := app.store.Infos().InfoGet(ctx, info)
+	if err != nil || got.SourceID != to {
+		t.Errorf("info not moved: %+v, %v", got, err)
+	}
+
+	// The old URL still works
+	code, header, _ = ts.get(t, fmt.Sprintf("/source/view/%d", from))
+	assertStatus(t, code, http.StatusMovedPermanently)
+	if loc := header.Get("Location"); loc != fmt.Sprintf("/source/view/%d", to) {
+		t.Errorf("got Location %q", loc)
+	}
+
+	code, _, body = ts.get(t, fmt.Sprintf("/source/view/%d", to))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Sources merged")
+	assertContains(t, body, "Also known as:")
+	assertContains(t, body, "Billancourt")
+	assertContains(t, body, "Source merged")
+}
+
+func TestSourceMergeJSON(t *testing.T) {
+	app := newTestApplication(t)
+	from := addSource(t, app, "Billancourt")
+	to := addSource(t, app, "Nanterre")
+	ts := newTestServer(t, app.routes())
+
+	post := func(target string) (int, string) {
+		form := url.Values{
+			"target":      {target},
+			csrfFormField: {ts.token(t)},
+		}
+		req, err := http.NewRequest(http.MethodPost,
+			ts.URL+fmt.Sprintf("/source/merge/%d", from),
+			strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+
+		code, _, body := ts.do(t, req)
+		return code, body
+	}
+
+	code, body := post(fmt.Sprint(from))
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+	assertContains(t, body, `"target":"Choose another source"`)
+
+	code, body = post(fmt.Sprint(to))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, fmt.Sprintf(`"into":%d`, to))
+	assertContains(t, body, fmt.Sprintf(`"merged":%d`, from))
+}
+
+func TestInfoMove(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	from := addSource(t, app, "Billancourt")
+	to := addSource(t, app, "Nanterre")
+	info := addInfo(t, app, from, "Transfo 1", "waiting")
+	ts := newTestServer(t, app.routes())
+	path := fmt.Sprintf("/source/%d/info/move/%d", from, info)
+
+	code, _, body := ts.get(t, fmt.Sprintf("/source/%d/info/view/%d", from, info))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, fmt.Sprintf(`<option value="%d"`, to))
+
+	code, _, _ = ts.postForm(t, path, url.Values{"target": {""}})
+	assertStatus(t, code, http.StatusBadRequest)
+
+	code, _, _ = ts.postForm(t, path, url.Values{"target": {"42"}})
+	assertStatus(t, code, http.StatusNotFound)
+
+	// Not to its own source
+	code, _, _ = ts.postForm(t, path, url.Values{"target": {fmt.Sprint(from)}})
+	assertStatus(t, code, http.StatusBadRequest)
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+path, strings.NewReader(url.Values{
+		"target": {fmt.Sprint(from)}, csrfFormField: {ts.token(t)},
+	}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	code, _, body = ts.do(t, req)
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+	assertContains(t, body, "Choose another source")
+
+	code, header, _ := ts.postForm(t, path, url.Values{"target": {fmt.Sprint(to)}})
+	assertStatus(t, code, http.StatusSeeOther)
+	if loc := header.Get("Location"); loc != fmt.Sprintf("/source/%d/info/view/%d", to, info) {
+		t.Errorf("got Location %q", loc)
+	}
+
+	got, err := app.store.Infos().InfoGet(ctx, info)
+	if err != nil || got.SourceID != to {
+		t.Errorf("info not moved: %+v, %v", got, err)
+	}
+
+	// Both sources keep a trace of the move
+	for _, id := range []int{from, to} {
+		history, err := app.store.Audit().AuditList(ctx, id)
+		if err != nil || len(history) != 1 {
+			t.Fatalf("source %d: got %d entries, %v; want 1", id, len(history), err)
+		}
+		if history[0].Detail != "Transfo 1: Billancourt → Nanterre" {
+			t.Errorf("got detail %q", history[0].Detail)
+		}
+	}
+}
+
+func validInfoForm() url.Values {
+	return url.Values{
+		"reporter": {"1"},
+		"material": {"Transfo 1"},
+		"detail":   {"Oil leak"},
+		"priority": {"2"},
+		"estimate": {"1000"},
+		"status":   {"waiting"},
+	}
+}
+
+func TestInfoCreate(t *testing.T) {
+	app := newTestApplication(t)
+	sID := addSource(t, app, "Billancourt")
+	ts := newTestServer(t, app.routes())
+	path := fmt.Sprintf("/source/%d/info/create", sID)
+
+	code, _, body := ts.get(t, path)
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, `name="material"`)
+
+	code, _, _ = ts.get(t, "/source/42/info/create")
+	assertStatus(t, code, http.StatusNotFound)
+
+	code, header, _ := ts.postForm(t, path, validInfoForm())
+	assertStatus(t, code, http.StatusSeeOther)
+	if loc := header.Get("Location"); loc != fmt.Sprintf("/source/view/%d", sID) {
+		t.Errorf("got Location %q", loc)
+	}
+
+	infos, _ := app.store.Infos().InfoList(context.Background(), sID)
+	if len(infos) != 1 || infos[0].Material != "Transfo 1" {
+		t.Fatalf("got %+v", infos)
+	}
+
+	code, _, _ = ts.postForm(t, "/source/42/info/create", validInfoForm())
+	assertStatus(t, code, http.StatusNotFound)
+}
+
+func TestInfoDuplicates(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	sID := addSource(t, app, "Billancourt")
+	ts := newTestServer(t, app.routes())
+	path := fmt.Sprintf("/source/%d/info/create", sID)
+
+	code, _, _ := ts.postForm(t, path, validInfoForm())
+	assertStatus(t, code, http.StatusSeeOther)
+	infos, _ := app.store.Infos().InfoList(ctx, sID)
+	first := infos[0].ID
+
+	// Same defect written a bit differently
+	form := validInfoForm()
+	form.Set("reporter", fmt.Sprint(addAgent(t, app, "Martin")))
+	form.Set("material", "transfo 1 ")
+	form.Set("detail", "Oil leaks")
+
+	code, _, body := ts.postForm(t, path, form)
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Possible duplicates")
+	assertContains(t, body, fmt.Sprintf(`name="duplicate" value="%d"`, first))
+	assertContains(t, body, `name="detail" value="Oil leaks"`)
+
+	// Linked to the first one, nothing created
+	form.Set("duplicate", fmt.Sprint(first))
+	code, header, _ := ts.postForm(t, path, form)
+	assertStatus(t, code, http.StatusSeeOther)
+	if loc := header.Get("Location"); loc != fmt.Sprintf("/source/%d/info/view/%d", sID, first) {
+		t.Errorf("got Location %q", loc)
+	}
+	if infos, _ = app.store.Infos().InfoList(ctx, sID); len(infos) != 1 {
+		t.Fatalf("got %d infos; want 1", len(infos))
+	}
+
+	code, _, body = ts.get(t, fmt.Sprintf("/source/%d/info/view/%d", sID, first))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Reported again")
+	assertContains(t, body, "Martin")
+
+	// Only an open info of the same source
+	other := addSource(t, app, "Nanterre")
+	form.Set("duplicate", fmt.Sprint(addInfo(t, app, other, "Transfo 1", "waiting")))
+	code, _, _ = ts.postForm(t, path, form)
+	assertStatus(t, code, http.StatusNotFound)
+
+	// Or a new info anyway
+	form.Set("duplicate", "new")
+	code, _, _ = ts.postForm(t, path, form)
+	assertStatus(t, code, http.StatusSeeOther)
+	if infos, _ = app.store.Infos().InfoList(ctx, sID); len(infos) != 2 {
+		t.Fatalf("got %d infos; want 2", len(infos))
+	}
+
+	// A different defect goes straight through
+	form = validInfoForm()
+	form.Set("material", "Disjoncteur 3")
+	form.Set("detail", "Broken handle")
+	code, _, _ = ts.postForm(t, path, form)
+	assertStatus(t, code, http.StatusSeeOther)
+}
+
+func TestInfoCreateInvalid(t *testing.T) {
+	app := newTestApplication(t)
+	sID := addSource(t, app, "Billancourt")
+	ts := newTestServer(t, app.routes())
+	path := fmt.Sprintf("/source/%d/info/create", sID)
+
+	tests := []struct {
+		name  string
+		field string
+		value string
+	}{
+		{"Blank reporter", "reporter", ""},
+		{"Unknown reporter", "reporter", "42"},
+		{"Unknown assignee", "assignee", "42"},
+		{"Blank material", "material", " "},
+		{"Blank detail", "detail", ""},
+		{"Blank priority", "priority", ""},
+		{"Priority not a number", "priority", "high"},
+		{"Blank status", "status", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := validInfoForm()
+			form.Set(tt.field, tt.value)
+
+			code, _, _ := ts.postForm(t, path, form)
+			assertStatus(t, code, http.StatusUnprocessableEntity)
+		})
+	}
+
+	infos, _ := app.store.Infos().InfoList(context.Background(), sID)
+	if len(infos) != 0 {
+		t.Errorf("got %d infos; want 0", len(infos))
+	}
+}
+
+func TestFormRepopulate(t *testing.T) {
+	app := newTestApplication(t)
+	sID := addSource(t, app, "Billancourt")
+	iID := addInfo(t, app, sID, "Transfo 1", "waiting")
+	ts := newTestServer(t, app.routes())
+
+	// Values sent are shown again with the error of the field
+	form := validInfoForm()
+	form.Set("priority", "high")
+	form.Set("status", "done")
+
+	code, _, body := ts.postForm(t,
+		fmt.Sprintf("/source/%d/info/create", sID), form)
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+	assertContains(t, body, `value="1" selected`)
+	assertContains(t, body, "Oil leak</textarea>")
+	assertContains(t, body, `value="high"`)
+	assertContains(t, body, `value="done" checked`)
+	assertContains(t, body, `<label class="field-error">Must be a number</label>`)
+
+	form = validInfoForm()
+	form.Set("reporter", "")
+	form.Set("material", "Transfo 2")
+
+	code, _, body = ts.postForm(t,
+		fmt.Sprintf("/source/%d/info/update/%d", sID, iID), form)
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+	assertContains(t, body, `value="Transfo 2"`)
+	assertContains(t, body, `<label class="field-error">Cannot be empty</label>`)
+
+	code, _, body = ts.postForm(t, "/source/create", url.Values{"name": {" "}})
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+	assertContains(t, body, `<label class="field-error">Cannot be empty</label>`)
+}
+
+func TestFlash(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	code, header, _ := ts.postForm(t, "/source/create",
+		url.Values{"name": {"Billancourt"}})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	path := header.Get("Location")
+
+	_, _, body := ts.get(t, path)
+	assertContains(t, body, `<div class="flash">Source created</div>`)
+
+	// Only shown once
+	_, _, body = ts.get(t, path)
+	if strings.Contains(body, `class="flash"`) {
+		t.Error("flash shown twice")
+	}
+
+	code, header, _ = ts.postForm(t, "/source/1/info/create", validInfoForm())
+	assertStatus(t, code, http.StatusSeeOther)
+
+	_, _, body = ts.get(t, header.Get("Location"))
+	assertContains(t, body, `<div class="flash">Info created</div>`)
+}
+
+func TestInfoView(t *testing.T) {
+	app := newTestApplication(t)
+	sID := addSource(t, app, "Billancourt")
+	iID := addInfo(t, app, sID, "Transfo 1", "waiting")
+	ts := newTestServer(t, app.routes())
+
+	code, _, body := ts.get(t, fmt.Sprintf("/source/%d/info/view/%d", sID, iID))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Transfo 1")
+
+	code, _, _ = ts.get(t, fmt.Sprintf("/source/%d/info/view/42", sID))
+	assertStatus(t, code, http.StatusNotFound)
+
+	code, _, _ = ts.get(t, fmt.Sprintf("/source/%d/info/view/abc", sID))
+	assertStatus(t, code, http.StatusNotFound)
+}
+
+func TestInfoUpdate(t *testing.T) {
+	app := newTestApplication(t)
+	sID := addSource(t, app, "Billancourt")
+	iID := addInfo(t, app, sID, "Transfo 1", "waiting")
+	ts := newTestServer(t, app.routes())
+	path := fmt.Sprintf("/source/%d/info/update/%d", sID, iID)
+
+	code, _, body := ts.get(t, path)
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Transfo 1")
+
+	code, _, _ = ts.get(t, fmt.Sprintf("/source/%d/info/update/42", sID))
+	assertStatus(t, code, http.StatusNotFound)
+
+	form := validInfoForm()
+	form.Set("priority", "not a number")
+	code, _, _ = ts.postForm(t, path, form)
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+
+	code, _, _ = ts.postForm(t,
+		fmt.Sprintf("/source/%d/info/update/42", sID), validInfoForm())
+	assertStatus(t, code, http.StatusNotFound)
+
+	form = validInfoForm()
+	form.Set("status", "done")
+	code, header, _ := ts.postForm(t, path, form)
+	assertStatus(t, code, http.StatusSeeOther)
+	if loc := header.Get("Location"); loc != fmt.Sprintf("/source/%d/info/view/%d", sID, iID) {
+		t.Errorf("got Location %q", loc)
+	}
+
+	info, _ := app.store.Infos().InfoGet(context.Background(), iID)
+	if info.Status != "done" || info.Detail != "Oil leak" {
+		t.Errorf("got %+v", info)
+	}
+}
+
+func TestInfoDelete(t *testing.T) {
+	app := newTestApplication(t)
+	sID := addSource(t, app, "Billancourt")
+	iID := addInfo(t, app, sID, "Transfo 1", "waiting")
+	ts := newTestServer(t, app.routes())
+	path := fmt.Sprintf("/source/%d/info/delete/%d", sID, iID)
+
+	code, header, _ := ts.postForm(t, path, nil)
+	assertStatus(t, code, http.StatusSeeOther)
+	if loc := header.Get("Location"); loc != fmt.Sprintf("/source/view/%d", sID) {
+		t.Errorf("got Location %q", loc)
+	}
+
+	code, _, _ = ts.postForm(t, path, nil)
+	assertStatus(t, code, http.StatusNotFound)
+}
+
+func TestTrash(t *testing.T) {
+	app := newTestApplication(t)
+	sID := addSource(t, app, "Billancourt")
+	iID := addInfo(t, app, sID, "Transfo 1", "waiting")
+	ts := newTestServer(t, app.routes())
+
+	code, _, body := ts.get(t, "/trash")
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "The trash is empty.")
+
+	// The name typed in the menu is kept with the deleted info
+	code, _, _ = ts.postForm(t, "/user", url.Values{"user": {"Hélène Dupont"}})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	code, _, _ = ts.postForm(t,
+		fmt.Sprintf("/source/%d/info/delete/%d", sID, iID), nil)
+	assertStatus(t, code, http.StatusSeeOther)
+
+	_, _, body = ts.get(t, fmt.Sprintf("/source/view/%d", sID))
+	if strings.Contains(body, "Transfo 1") {
+		t.Error("info in the trash shown in the source")
+	}
+
+	_, _, body = ts.get(t, "/trash")
+	assertContains(t, body, "Transfo 1")
+	assertContains(t, body, "Hélène Dupont")
+	assertContains(t, body, fmt.Sprintf(`action="/trash/info/%d/restore"`, iID))
+
+	// Restore
+	code, header, _ := ts.postForm(t,
+		fmt.Sprintf("/trash/info/%d/restore", iID), nil)
+	assertStatus(t, code, http.StatusSeeOther)
+	if loc := header.Get("Location"); loc != "/trash" {
+		t.Errorf("got Location %q", loc)
+	}
+
+	code, _, _ = ts.get(t, fmt.Sprintf("/source/%d/info/view/%d", sID, iID))
+	assertStatus(t, code, http.StatusOK)
+
+	// Only rows in the trash can be restored or purged
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/trash/info/%d/purge", iID), nil)
+	assertStatus(t, code, http.StatusNotFound)
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/trash/source/%d/restore", sID), nil)
+	assertStatus(t, code, http.StatusNotFound)
+
+	// Purge
+	ts.postForm(t, fmt.Sprintf("/source/%d/info/delete/%d", sID, iID), nil)
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/trash/info/%d/purge", iID), nil)
+	assertStatus(t, code, http.StatusSeeOther)
+
+	trash, _ := app.store.Infos().InfoTrash(context.Background())
+	if len(trash) != 0 {
+		t.Errorf("got trash %+v; want empty", trash)
+	}
+
+	// The source is empty now, it can go to the trash
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/source/delete/%d", sID), nil)
+	assertStatus(t, code, http.StatusSeeOther)
+
+	_, _, body = ts.get(t, "/")
+	assertContains(t, body, `<div class="flash">Source moved to the trash</div>`)
+	if strings.Contains(body, "Billancourt") {
+		t.Error("source in the trash shown in the home page")
+	}
+
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/trash/source/%d/restore", sID), nil)
+	assertStatus(t, code, http.StatusSeeOther)
+
+	_, _, body = ts.get(t, "/")
+	assertContains(t, body, "Billancourt")
+}
+
+func TestPurgeTrash(t *testing.T) {
+	app := newTestApplication(t)
+	app.trashRetention = time.Hour
+	ctx := context.Background()
+
+	sID := addSource(t, app, "Billancourt")
+	iID := addInfo(t, app, sID, "Transfo 1", "waiting")
+	app.store.Infos().InfoDelete(ctx, iID, "Dupont")
+
+	// Not old enough
+	if err := app.purgeTrash(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if trash, _ := app.store.Infos().InfoTrash(ctx); len(trash) != 1 {
+		t.Fatalf("got %d infos in the trash; want 1", len(trash))
+	}
+
+	app.trashRetention = -time.Hour
+	if err := app.purgeTrash(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if trash, _ := app.store.Infos().InfoTrash(ctx); len(trash) != 0 {
+		t.Errorf("got %d infos in the trash; want 0", len(trash))
+	}
+}
+
+func TestStatic(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	code, _, body := ts.get(t, "/static/js/main.js")
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "searchStatus")
+
+	code, _, _ = ts.get(t, "/static/js/missing.js")
+	assertStatus(t, code, http.StatusNotFound)
+
+	// Directories are not listed
+	code, _, _ = ts.get(t, "/static/js/")
+	assertStatus(t, code, http.StatusNotFound)
+}
+
+func TestStaticCache(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	hashed := app.static.url("js/main.js")
+	if !strings.Contains(hashed, "?v=") {
+		t.Fatalf("got %q; want a hashed URL", hashed)
+	}
+
+	_, _, body := ts.get(t, "/")
+	assertContains(t, body, hashed)
+
+	code, header, _ := ts.get(t, hashed)
+	assertStatus(t, code, http.StatusOK)
+	if cc := header.Get("Cache-Control"); !strings.Contains(cc, "immutable") {
+		t.Errorf("got Cache-Control %q", cc)
+	}
+
+	code, header, _ = ts.get(t, "/static/js/main.js")
+	assertStatus(t, code, http.StatusOK)
+	if cc := header.Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("got Cache-Control %q", cc)
+	}
+
+	etag := header.Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/static/js/main.js", nil)
+	req.Header.Set("If-None-Match", etag)
+	code, _, _ = ts.do(t, req)
+	assertStatus(t, code, http.StatusNotModified)
+}
+
+func TestUnknownRoute(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	code, _, body := ts.get(t, "/nothing/here")
+	assertStatus(t, code, http.StatusNotFound)
+	assertContains(t, body, "404")
+
+	code, _, _ = ts.get(t, "/source/1/info/delete/1")
+	assertStatus(t, code, http.StatusMethodNotAllowed)
+}
+
+func TestMetrics(t *testing.T) {
+	app := newTestApplication(t)
+	addSource(t, app, "Billancourt")
+	ts := newTestServer(t, app.routes())
+
+	ts.get(t, "/source/view/1")
+	code, _, body := ts.get(t, "/metrics")
+
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, `route="/source/view/{id}"`)
+	assertContains(t, body, `curator_source_open_infos{source="Billancourt",source_id="1"} 0`)
+}
@@ -2,52 +2,70 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"runtime/debug"
+	"time"
+
+	"CURATOR/internal/i18n"
 )
 
 // Web status are managed here
 
 // serverError writes the error message
 // and then sends 500 Internal Server Error to user
-func (app *application) serverError(w http.ResponseWriter, err error) {
-	trace := fmt.Sprintf("%s\n%s", err.Error(), debug.Stack())
+func (app *application) serverError(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.ErrorContext(r.Context(), err.Error(),
+		"method", r.Method,
+		"uri", r.URL.RequestURI(),
+		"trace", string(debug.Stack()))
 
-	app.errorLog.Print(trace)
+	app.errorResponse(w, r, http.StatusInternalServerError, "")
 }
 
 // clientError send a specific status and describes
 // to the user
-func (app *application) clientError(w http.ResponseWriter, status int) {
-	http.Error(w, http.StatusText(status), status)
+func (app *application) clientError(w http.ResponseWriter, r *http.Request, status int) {
+	app.errorResponse(w, r, status, "")
 }
 
 // notFound do the same thing but with 404 error
-func (app *application) notFound(w http.ResponseWriter) {
-	app.clientError(w, http.StatusNotFound)
+func (app *application) notFound(w http.ResponseWriter, r *http.Request) {
+	app.clientError(w, r, http.StatusNotFound)
 }
 
 // Allocates memory so that a template can be rendered
 // and checks if it exists before beeing sent
 // to http.ResponseWriter
-func (app *application) render(w http.ResponseWriter, status int, page string, data *templateData) {
+func (app *application) render(w http.ResponseWriter, r *http.Request, status int, page string, data *templateData) {
 
 	// Retrieves the appropriate template from cache
-	ts, ok := app.templateCache[page]
+	// (from disk in dev mode @ cmd/dev.go)
+	cache, err := app.templates()
+	if err != nil {
+		app.templateError(w, r, err)
+		return
+	}
+
+	ts, ok := cache[page]
 	if !ok {
 		err := fmt.Errorf("the template %s does not exist",
 			page)
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 		return
 	}
 
 	buf := new(bytes.Buffer)
 
 	// Executes the templates and than send to response bodyn
-	err := ts.ExecuteTemplate(buf, "base", data)
+	start := time.Now()
+	err = ts.ExecuteTemplate(buf, "base", data)
+	app.metrics.renders.WithLabelValues(page).
+		Observe(time.Since(start).Seconds())
 	if err != nil {
-		app.serverError(w, err)
+		app.templateError(w, r, err)
+		return
 	}
 
 	w.WriteHeader(status)
@@ -56,9 +74,41 @@ func (app *application) render(w http.ResponseWriter, status int, page string, d
 
 }
 
+// templateError shows the template and the line in dev mode,
+// the usual 500 page otherwise
+func (app *application) templateError(w http.ResponseWriter, r *http.Request, err error) {
+	if app.dev {
+		app.devTemplateError(w, r, err)
+		return
+	}
+
+	app.serverError(w, r, err)
+}
+
 // newTemplateData return a pointer to templateData
 // no initialize and it's used by all functions in handlers.go file
 // Make a better readability
 func (app *application) newTemplateData(r *http.Request) *templateData {
-	return &templateData{}
+	return &templateData{
+		// Shown once, PopString removes it from the session
+		Flash:     app.sessionManager.PopString(r.Context(), "flash"),
+		Locale:    i18n.FromContext(r.Context()),
+		Langs:     i18n.Langs,
+		TimeZones: app.zones(),
+		User:      app.userName(r),
+		CSRFToken: app.csrfToken(r),
+	}
+}
+
+// writeJSON sends v to an API client
+func (app *application) writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
 }
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"CURATOR/internal/i18n"
+)
+
+// The language of a page is, in this order:
+// the one chosen by the user (session), the "lang" cookie
+// (kept when the session expires), Accept-Language, English.
+// The time zone is chosen the same way, else it's -tz
+
+const (
+	langSessionKey = "lang"
+	langCookie     = "lang"
+
+	tzSessionKey = "tz"
+	tzCookie     = "tz"
+)
+
+// Zones of the menu, the one of -tz is added if missing
+var timeZones = []string{
+	"Europe/Paris",
+	"UTC",
+	"America/Guadeloupe",
+	"America/Martinique",
+	"America/Cayenne",
+	"Indian/Reunion",
+	"Indian/Mayotte",
+	"Pacific/Noumea",
+	"Pacific/Tahiti",
+}
+
+// Zones already loaded, time.LoadLocation reads a file
+var zoneCache sync.Map
+
+// loadZone returns the zone called name or nil if it doesn't exist
+func loadZone(name string) *time.Location {
+	// "" and "Local" are valid for time.LoadLocation
+	if name == "" || name == "Local" {
+		return nil
+	}
+
+	if loc, ok := zoneCache.Load(name); ok {
+		return loc.(*time.Location)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil
+	}
+	zoneCache.Store(name, loc)
+
+	return loc
+}
+
+// zones returns the zones of the menu
+func (app *application) zones() []string {
+	if app.location == nil {
+		return timeZones
+	}
+
+	for _, name := range timeZones {
+		if name == app.location.String() {
+			return timeZones
+		}
+	}
+
+	return append([]string{app.location.String()}, timeZones...)
+}
+
+// locale stores the language of the request in its context,
+// see i18n.FromContext
+func (app *application) locale(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := app.sessionManager.GetString(r.Context(), langSessionKey)
+
+		if !i18n.Supported(lang) {
+			lang = preferenceCookie(r, langCookie)
+		}
+
+		if !i18n.Supported(lang) {
+			lang = i18n.Match(r.Header.Get("Accept-Language"))
+		}
+
+		tz := loadZone(app.sessionManager.GetString(r.Context(), tzSessionKey))
+
+		if tz == nil {
+			tz = loadZone(preferenceCookie(r, tzCookie))
+		}
+
+		if tz == nil {
+			tz = app.location
+		}
+
+		ctx := i18n.WithLocale(r.Context(), i18n.Locale{Lang: lang, TZ: tz})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// langPost saves the language chosen in the menu
+// and goes back to the page
+func (app *application) langPost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	lang := r.PostForm.Get("lang")
+	if !i18n.Supported(lang) {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), langSessionKey, lang)
+	setPreferenceCookie(w, langCookie, lang)
+
+	http.Redirect(w, r, backURL(r), http.StatusSeeOther)
+}
+
+// tzPost saves the time zone chosen in the menu
+func (app *application) tzPost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	tz := r.PostForm.Get("tz")
+	if loadZone(tz) == nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), tzSessionKey, tz)
+	setPreferenceCookie(w, tzCookie, tz)
+
+	http.Redirect(w, r, backURL(r), http.StatusSeeOther)
+}
+
+// The choices of the user are kept for a year.
+// The value is escaped, a cookie can't hold "é" or ";"
+func setPreferenceCookie(w http.ResponseWriter, name, value string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    url.QueryEscape(value),
+		Path:     "/",
+		MaxAge:   int((365 * 24 * time.Hour).Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// backURL returns the path of the Referer when it's a page
+// of this server, else "/". A path starting with // or /\
+// would be read by the browser as another site
+func backURL(r *http.Request) string {
+	ref, err := url.Parse(r.Referer())
+	if err != nil || (ref.Host != "" && ref.Host != r.Host) ||
+		!strings.HasPrefix(ref.Path, "/") ||
+		strings.HasPrefix(ref.Path, "//") || strings.HasPrefix(ref.Path, "/\\") {
+		return "/"
+	}
+
+	back := url.URL{Path: ref.Path, RawQuery: ref.RawQuery}
+	return back.String()
+}
+
+// preferenceCookie returns the value saved by
+// setPreferenceCookie or ""
+func preferenceCookie(r *http.Request, name string) string {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+
+	value, err := url.QueryUnescape(c.Value)
+	if err != nil {
+		return ""
+	}
+	return value
+}
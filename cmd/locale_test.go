@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocale(t *testing.T) {
+	app := newTestApplication(t)
+	sID := addSource(t, app, "Billancourt")
+	addInfo(t, app, sID, "Transfo 1", "waiting")
+	ts := newTestServer(t, app.routes())
+	path := fmt.Sprintf("/source/view/%d", sID)
+
+	// English by default
+	_, _, body := ts.get(t, path)
+	assertContains(t, body, "<html lang='en'>")
+	assertContains(t, body, "<strong>Material</strong>")
+	assertContains(t, body, `data-required="Cannot be empty"`)
+
+	// Accept-Language
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+	req.Header.Set("Accept-Language", "fr-FR,fr;q=0.9,en;q=0.8")
+	_, _, body = ts.do(t, req)
+	assertContains(t, body, "<html lang='fr'>")
+	assertContains(t, body, "<strong>Ouvrage</strong>")
+	assertContains(t, body, "en attente")
+	assertContains(t, body, `data-required="Ce champ ne doit pas être vide"`)
+
+	// The menu wins over Accept-Language and goes back to the page
+	form := url.Values{"lang": {"fr"}, csrfFormField: {ts.token(t)}}
+	req, _ = http.NewRequest(http.MethodPost, ts.URL+"/lang",
+		strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Referer", ts.URL+path)
+	code, header, _ := ts.do(t, req)
+	assertStatus(t, code, http.StatusSeeOther)
+	if got := header.Get("Location"); got != path {
+		t.Errorf("got Location %q; want %q", got, path)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, ts.URL+path, nil)
+	req.Header.Set("Accept-Language", "en")
+	_, _, body = ts.do(t, req)
+	assertContains(t, body, "<strong>Ouvrage</strong>")
+
+	// Validator messages are translated
+	code, _, body = ts.postForm(t, "/source/create", url.Values{"name": {""}})
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+	assertContains(t, body, "Ce champ ne doit pas être vide")
+
+	// Unknown language
+	code, _, _ = ts.postForm(t, "/lang", url.Values{"lang": {"de"}})
+	assertStatus(t, code, http.StatusBadRequest)
+}
+
+func TestBackURL(t *testing.T) {
+	tests := []struct {
+		referer string
+		want    string
+	}{
+		{"", "/"},
+		{"http://localhost:3005/source/view/1?x=y", "/source/view/1?x=y"},
+		{"/source/view/1?x=y", "/source/view/1?x=y"},
+		{"http://evil.example/source/view/1?x=1", "/"},
+		{"//evil.example", "/"},
+		{"//evil.example/x", "/"},
+		{`/\evil.example`, "/"},
+		{"http://localhost:3005//evil.example/x", "/"},
+		{"http://localhost:3005/%2F%2Fevil.example/x", "/"},
+		{`http://localhost:3005/\evil.example/x`, "/"},
+		{"http://localhost:3005/%5Cevil.example/x", "/"},
+	}
+
+	for _, tt := range tests {
+		r, _ := http.NewRequest(http.MethodPost, "http://localhost:3005/lang", nil)
+		r.Header.Set("Referer", tt.referer)
+
+		if got := backURL(r); got != tt.want {
+			t.Errorf("backURL(%q) = %q; want %q", tt.referer, got, tt.want)
+		}
+	}
+}
+
+func TestTimeZone(t *testing.T) {
+	app := newTestApplication(t)
+	app.location = time.UTC
+	sID := addSource(t, app, "Billancourt")
+	iID := addInfo(t, app, sID, "Transfo 1", "waiting")
+	ts := newTestServer(t, app.routes())
+
+	// -tz is in the menu even if it's not in the list
+	_, _, body := ts.get(t, "/")
+	assertContains(t, body, `<option value="UTC" selected>`)
+
+	code, _, _ := ts.postForm(t, "/tz", url.Values{"tz": {"Indian/Reunion"}})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	_, _, body = ts.get(t, fmt.Sprintf("/source/%d/info/view/%d", sID, iID))
+	assertContains(t, body, `<option value="Indian/Reunion" selected>`)
+	assertContains(t, body, "Created: ")
+
+	// Never updated: no Updated date
+	if strings.Contains(body, "Updated: ") {
+		t.Error("Updated shown for an info never updated")
+	}
+
+	_, _, body = ts.get(t, fmt.Sprintf("/source/view/%d", sID))
+	assertContains(t, body, "just now")
+
+	for _, tz := range []string{"", "Local", "Mars/Olympus"} {
+		code, _, _ = ts.postForm(t, "/tz", url.Values{"tz": {tz}})
+		assertStatus(t, code, http.StatusBadRequest)
+	}
+}
@@ -2,27 +2,59 @@ package main
 
 import (
 	"context"
+	"flag"
 	"html/template"
-	"log"
+	"io/fs"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"time"
+	// Zone names work even without the system tzdata
+	_ "time/tzdata"
 
 	"CURATOR/database"
+	"CURATOR/internal/logging"
+	"CURATOR/ui"
 
+	"github.com/alexedwards/scs/v2"
 	// PostgreSQL driver
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 // Main struct, every struct within database folders connects here
 type application struct {
-	sources *database.Source
-	infos   *database.Info
+	// See database/store.go
+	store database.Store
+
+	sessionManager *scs.SessionManager
 
 	templateCache map[string]*template.Template
+	static        *staticFiles
+
+	// -dev @ cmd/dev.go, uiFS is where templates are read
+	dev  bool
+	uiFS fs.FS
+
+	// -tz, zone of the dates when the user hasn't chosen one
+	location *time.Location
+
+	// -trash-retention, time before a deleted row is purged
+	trashRetention time.Duration
+
+	// -map-tiles, the tiles behind the map @ cmd/map.go,
+	// nil draws it without
+	mapTiles fs.FS
+
+	// -base-url, scheme and host of the links that leave the
+	// browser (QR codes, labels, feeds) @ cmd/calendar.go
+	baseURL string
+
+	metrics *metrics
 
-	errorLog *log.Logger
-	infoLog  *log.Logger
+	logger *slog.Logger
 
 	DB *pgxpool.Pool
 }
@@ -34,54 +66,166 @@ const (
 
 func main() {
 
-	// Ldate = Local data & Ltime = Local time
-	infoLog := log.New(os.Stderr, "INFO\t", log.Ldate|log.Ltime)
-	errorLog := log.New(os.Stderr, "ERROR\t",
-		log.Ldate|log.Ltime|log.Lshortfile)
+	logFormat := flag.String("log-format", "text",
+		"Log output format: text or json")
+	logLevel := flag.String("log-level", "info",
+		"Minimum log level: debug, info, warn or error")
+	uiDir := flag.String("ui-dir", "",
+		"Read templates and static files from this directory "+
+			"instead of the ones built in the binary")
+	dev := flag.Bool("dev", false,
+		"Development mode: templates are read from -ui-dir (./ui by "+
+			"default) at each request and errors are shown in the browser")
+	tz := flag.String("tz", "Europe/Paris",
+		"Time zone of the dates shown, users can choose another one")
+	trashRetention := flag.Duration("trash-retention", 30*24*time.Hour,
+		"Time before deleted sources and infos are purged, 0 keeps them")
+	mapTiles := flag.String("map-tiles", "",
+		"Directory of map tiles ({z}/{x}/{y}.png) shown behind the "+
+			"sources, the map is drawn without them when empty")
+	baseURL := flag.String("base-url", "",
+		"Public URL of the server (ex.: https://curator.example.org) "+
+			"written in QR codes, labels and feeds, the Host of "+
+			"each request when empty")
+	flag.Parse()
+
+	// Fontion @ internal/logging
+	logger, err := logging.New(os.Stderr, *logFormat, *logLevel)
+	if err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+
+	location, err := time.LoadLocation(*tz)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	if *baseURL != "" {
+		u, err := url.Parse(*baseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			logger.Error("invalid -base-url", "url", *baseURL)
+			os.Exit(1)
+		}
+		*baseURL = strings.TrimSuffix(*baseURL, "/")
+	}
 
 	// executes the comm function with DB
-	db, err := openDB(dataURL)
+	db, err := openDB(dataURL, logger)
 	if err != nil {
-		errorLog.Fatal(err)
+		logger.Error(err.Error())
+		os.Exit(1)
 	}
 	defer db.Close()
 
+	// Creates or updates the tables @ database/migrations
+	err = database.Migrate(context.Background(), db)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	// ui/efs.go
+	if *dev && *uiDir == "" {
+		*uiDir = "./ui"
+	}
+
+	var uiFS fs.FS = ui.Files
+	if *uiDir != "" {
+		uiFS = os.DirFS(*uiDir)
+	}
+
+	// Fontion @ cmd/static.go
+	static, err := newStaticFiles(uiFS)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	static.dev = *dev
+
+	if *dev {
+		logger.Warn("development mode, don't use it in production",
+			"ui-dir", *uiDir)
+	}
+
 	// Fontion @ cmd/template.go
-	templateCache, err := newTemplateCache()
+	templateCache, err := newTemplateCache(uiFS, static)
 	if err != nil {
-		errorLog.Fatal(err)
+		logger.Error(err.Error())
+		os.Exit(1)
 	}
 
+	// Sessions are kept in PSQL @ database/sessions.go
+	sessionStore := &database.SessionStore{DB: db}
+	go cleanSessions(sessionStore, logger)
+
+	sessionManager := scs.New()
+	sessionManager.Store = sessionStore
+	sessionManager.Lifetime = 12 * time.Hour
+	sessionManager.Cookie.Name = "curator_session"
+	sessionManager.Cookie.HttpOnly = true
+	sessionManager.Cookie.SameSite = http.SameSiteLaxMode
+
 	app := &application{
-		DB:      db,
-		sources: &database.Source{},
-		infos:   &database.Info{},
+		DB:    db,
+		store: database.NewPGStore(db),
+
+		sessionManager: sessionManager,
 
 		templateCache: templateCache,
+		static:        static,
+
+		dev:  *dev,
+		uiFS: uiFS,
+
+		location:       location,
+		trashRetention: *trashRetention,
+		baseURL:        *baseURL,
+
+		logger: logger,
+	}
 
-		infoLog:  infoLog,
-		errorLog: errorLog,
+	if *mapTiles != "" {
+		app.mapTiles = os.DirFS(*mapTiles)
+	}
+
+	// See cmd/metrics.go
+	app.metrics = newMetrics(app)
+
+	if app.trashRetention > 0 {
+		go app.purgeTrashLoop()
 	}
 
 	// See routers.go
 	srv := &http.Server{
 		Addr:         addr,
 		Handler:      app.routes(),
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
 
-	infoLog.Printf("Starting server on %s", addr)
+	logger.Info("starting server", "addr", addr)
 	err = srv.ListenAndServe()
-	errorLog.Fatal(err)
+	logger.Error(err.Error())
+	os.Exit(1)
 
 }
 
-// Start communication with DB when needed
-func openDB(dataURL string) (*pgxpool.Pool, error) {
+// Start communication with DB when needed.
+// pgx logs go through the same logger as the handlers
+func openDB(dataURL string, logger *slog.Logger) (*pgxpool.Pool, error) {
 	ctx := context.Background()
 
-	db, err := pgxpool.Connect(ctx, dataURL)
+	config, err := pgxpool.ParseConfig(dataURL)
+	if err != nil {
+		return nil, err
+	}
+	config.ConnConfig.Logger = database.NewLogger(logger)
+	config.ConnConfig.LogLevel = pgx.LogLevelInfo
+
+	db, err := pgxpool.ConnectConfig(ctx, config)
 	if err != nil {
 		return nil, err
 	}
@@ -91,3 +235,47 @@ func openDB(dataURL string) (*pgxpool.Pool, error) {
 
 	return db, nil
 }
+
+// Removes the expired sessions every 10 minutes
+func cleanSessions(store *database.SessionStore, logger *slog.Logger) {
+	for range time.Tick(10 * time.Minute) {
+		err := store.DeleteExpired(context.Background())
+		if err != nil {
+			logger.Error("deleting expired sessions", "error", err)
+		}
+	}
+}
+
+// Empties the trash every hour
+func (app *application) purgeTrashLoop() {
+	for range time.Tick(time.Hour) {
+		err := app.purgeTrash(context.Background())
+		if err != nil {
+			app.logger.Error("purging the trash", "error", err)
+		}
+	}
+}
+
+// purgeTrash deletes for good the rows in the
+// trash for longer than -trash-retention
+func (app *application) purgeTrash(ctx context.Context) error {
+	before := time.Now().Add(-app.trashRetention)
+
+	return app.store.WithTx(ctx, func(tx database.Store) error {
+		infos, err := tx.Infos().InfoPurgeBefore(ctx, before)
+		if err != nil {
+			return err
+		}
+
+		sources, err := tx.Sources().SourcePurgeBefore(ctx, before)
+		if err != nil {
+			return err
+		}
+
+		if infos > 0 || sources > 0 {
+			app.logger.Info("trash purged", "infos", infos, "sources", sources)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"CURATOR/database"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// The map (/map) places the sources with a location, coloured
+// like the buttons of the home page. It's an SVG drawn here:
+// with -map-tiles the tiles of a self-hosted server are behind,
+// else a plain background, nothing is asked to the Internet.
+// /map/sources.geojson exports the sources for a GIS
+
+// Size of the map in pixels, and the closest zoom
+// (about a street) for a single source
+const (
+	mapWidth   = 800
+	mapHeight  = 500
+	mapPadding = 40
+	mapMaxZoom = 16
+	tileSize   = 256
+)
+
+// Web Mercator can't go to the poles
+const maxLatitude = 85.05112878
+
+// sourceLevel is the colour of a source by its open infos,
+// the same on the home page and the map
+func sourceLevel(open int) string {
+	switch {
+	case open >= 10:
+		return "red"
+	case open >= 6:
+		return "orange"
+	case open >= 1:
+		return "yellow"
+	}
+	return "green"
+}
+
+// locationField reads the address and the coordinates of a source
+// form. Both coordinates are blank (no location) or both are set,
+// "48,85" is read as "48.85"
+func locationField(form *sourceCreateForm) *database.Location {
+	if form.Latitude == "" && form.Longitude == "" {
+		return nil
+	}
+	if form.Latitude == "" || form.Longitude == "" {
+		form.AddFieldError("location", "Enter both the latitude and the longitude")
+		return nil
+	}
+
+	coordinate := func(key, value string, limit float64) float64 {
+		v, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+		if err != nil || math.IsNaN(v) || v < -limit || v > limit {
+			form.AddFieldError(key, fmt.Sprintf("Between -%g and %g", limit, limit))
+		}
+		return v
+	}
+
+	l := &database.Location{
+		Lat: coordinate("latitude", form.Latitude, 90),
+		Lng: coordinate("longitude", form.Longitude, 180),
+	}
+	if !form.Valid() {
+		return nil
+	}
+	return l
+}
+
+// formatCoordinate is a coordinate of a form, "" for none
+func formatCoordinate(l *database.Location, lng bool) string {
+	switch {
+	case l == nil:
+		return ""
+	case lng:
+		return strconv.FormatFloat(l.Lng, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(l.Lat, 'f', -1, 64)
+}
+
+// project returns the point of l in pixels of the whole
+// world at zoom (Web Mercator, as the tiles)
+func project(l *database.Location, zoom int) (x, y float64) {
+	lat := math.Max(-maxLatitude, math.Min(maxLatitude, l.Lat))
+	size := float64(tileSize) * math.Exp2(float64(zoom))
+	rad := lat * math.Pi / 180
+
+	x = (l.Lng + 180) / 360 * size
+	y = (1 - math.Log(math.Tan(rad)+1/math.Cos(rad))/math.Pi) / 2 * size
+	return x, y
+}
+
+type mapMarker struct {
+	Source *database.Source
+	Level  string
+	X, Y   float64
+}
+
+// mapTile is a tile behind the markers, X and Y are
+// its top left corner on the map
+type mapTile struct {
+	URL  string
+	X, Y float64
+}
+
+type mapPage struct {
+	Width, Height int
+	Zoom          int
+
+	Markers []*mapMarker
+	Tiles   []*mapTile
+
+	// Sources without a location, listed under the map
+	Unlocated []*database.Source
+
+	// ?source=ID, the map is centred on it
+	Source int
+}
+
+// fit returns the highest zoom where every location fits
+// in the map, and the top left corner of the map at this zoom.
+// Without locations it's the whole world
+func fit(locations []*database.Location) (zoom int, left, top float64) {
+	for zoom = mapMaxZoom; zoom > 0 && len(locations) > 0; zoom-- {
+		minX, minY := math.Inf(1), math.Inf(1)
+		maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+		for _, l := range locations {
+			x, y := project(l, zoom)
+			minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+			minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+		}
+
+		if maxX-minX <= mapWidth-2*mapPadding &&
+			maxY-minY <= mapHeight-2*mapPadding {
+			return zoom, (minX + maxX - mapWidth) / 2, (minY + maxY - mapHeight) / 2
+		}
+	}
+
+	// The whole world, centred
+	size := float64(tileSize)
+	return 0, (size - mapWidth) / 2, (size - mapHeight) / 2
+}
+
+// tiles returns the tiles under the map. They go round the
+// world from east to west, not from north to south
+func tiles(zoom int, left, top float64) []*mapTile {
+	count := 1 << zoom
+	list := []*mapTile{}
+
+	for ty := int(math.Floor(top / tileSize)); float64(ty*tileSize) < top+mapHeight; ty++ {
+		if ty < 0 || ty >= count {
+			continue
+		}
+		for tx := int(math.Floor(left / tileSize)); float64(tx*tileSize) < left+mapWidth; tx++ {
+			list = append(list, &mapTile{
+				URL: fmt.Sprintf("/map/tiles/%d/%d/%d.png", zoom,
+					((tx%count)+count)%count, ty),
+				X: float64(tx*tileSize) - left,
+				Y: float64(ty*tileSize) - top,
+			})
+		}
+	}
+
+	return list
+}
+
+// ?source=ID centres the map on a source
+func (app *application) sourceMap(w http.ResponseWriter, r *http.Request) {
+	sources, err := app.store.Sources().MenuSource(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	page := &mapPage{Width: mapWidth, Height: mapHeight}
+
+	if value := r.URL.Query().Get("source"); value != "" {
+		page.Source, err = strconv.Atoi(value)
+		if err != nil {
+			app.clientError(w, r, http.StatusBadRequest)
+			return
+		}
+	}
+
+	located := []*database.Location{}
+	for _, src := range sources {
+		switch {
+		case src.Location == nil:
+			page.Unlocated = append(page.Unlocated, src)
+		case page.Source == 0 || src.ID == page.Source:
+			located = append(located, src.Location)
+		}
+	}
+	if page.Source != 0 && len(located) == 0 {
+		app.notFound(w, r)
+		return
+	}
+
+	var left, top float64
+	page.Zoom, left, top = fit(located)
+
+	for _, src := range sources {
+		if src.Location == nil {
+			continue
+		}
+		x, y := project(src.Location, page.Zoom)
+		x, y = x-left, y-top
+
+		// Off the map when centred on a source
+		if x < 0 || y < 0 || x > mapWidth || y > mapHeight {
+			continue
+		}
+
+		page.Markers = append(page.Markers, &mapMarker{
+			Source: src,
+			Level:  sourceLevel(src.Curatifs),
+			X:      x,
+			Y:      y,
+		})
+	}
+
+	if app.mapTiles != nil {
+		page.Tiles = tiles(page.Zoom, left, top)
+	}
+
+	data := app.newTemplateData(r)
+	data.Map = page
+
+	app.render(w, r, http.StatusOK, "map.tmpl.html", data)
+}
+
+// A tile of -map-tiles, {z}/{x}/{y}.png as most tile servers
+// and tile downloaders write them
+func (app *application) mapTile(w http.ResponseWriter, r *http.Request) {
+	if app.mapTiles == nil {
+		app.notFound(w, r)
+		return
+	}
+
+	name := []string{}
+	for _, key := range []string{"z", "x", "y"} {
+		n, err := strconv.Atoi(chi.URLParam(r, key))
+		if err != nil || n < 0 {
+			app.notFound(w, r)
+			return
+		}
+		name = append(name, strconv.Itoa(n))
+	}
+
+	tile, err := fs.ReadFile(app.mapTiles, strings.Join(name, "/")+".png")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Write(tile)
+}
+
+// GeoJSON (RFC 7946) of the sources: a point per source, its
+// geometry is null when it has no location
+type geoFeature struct {
+	Type       string         `json:"type"`
+	Geometry   *geoPoint      `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+type geoPoint struct {
+	Type string `json:"type"`
+
+	// Longitude first
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+type geoCollection struct {
+	Type     string        `json:"type"`
+	Features []*geoFeature `json:"features"`
+}
+
+// The sources with their open infos, for QGIS or uMap
+func (app *application) sourcesGeoJSON(w http.ResponseWriter, r *http.Request) {
+	sources, err := app.store.Sources().MenuSource(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	collection := &geoCollection{Type: "FeatureCollection",
+		Features: []*geoFeature{}}
+
+	for _, src := range sources {
+		feature := &geoFeature{
+			Type: "Feature",
+			Properties: map[string]any{
+				"id":      src.ID,
+				"name":    src.Name,
+				"address": src.Address,
+				"open":    src.Curatifs,
+				"level":   sourceLevel(src.Curatifs),
+				"url":     app.absoluteURL(r, fmt.Sprintf("/source/view/%d", src.ID)),
+			},
+		}
+		if l := src.Location; l != nil {
+			feature.Geometry = &geoPoint{Type: "Point",
+				Coordinates: [2]float64{l.Lng, l.Lat}}
+		}
+		collection.Features = append(collection.Features, feature)
+	}
+
+	js, err := json.Marshal(collection)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	w.Header().Set("Content-Disposition", `attachment; filename="sources.geojson"`)
+	w.Write(js)
+}
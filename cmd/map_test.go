@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"CURATOR/database"
+)
+
+func TestSourceLevel(t *testing.T) {
+	// Same thresholds as the buttons of the home page
+	for open, want := range map[int]string{
+		0: "green", 1: "yellow", 5: "yellow", 6: "orange",
+		9: "orange", 10: "red", 42: "red",
+	} {
+		if got := sourceLevel(open); got != want {
+			t.Errorf("%d open infos: got %q; want %q", open, got, want)
+		}
+	}
+}
+
+func TestLocationField(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lng string
+		want     *database.Location
+		errors   []string
+	}{
+		{"None", "", "", nil, nil},
+		{"Point", "48.8339", "2.2410", &database.Location{Lat: 48.8339, Lng: 2.241}, nil},
+		{"Comma", "48,8339", "-2,5", &database.Location{Lat: 48.8339, Lng: -2.5}, nil},
+		{"Only one", "48.8339", "", nil, []string{"location"}},
+		{"Out of range", "91", "-181", nil, []string{"latitude", "longitude"}},
+		{"Not a number", "north", "2.2410", nil, []string{"latitude"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := &sourceCreateForm{Latitude: tt.lat, Longitude: tt.lng}
+			got := locationField(form)
+
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("got %+v; want nil", got)
+			case tt.want != nil && (got == nil || *got != *tt.want):
+				t.Errorf("got %+v; want %+v", got, tt.want)
+			}
+
+			if len(form.FieldErrors) != len(tt.errors) {
+				t.Errorf("got errors %v; want %v", form.FieldErrors, tt.errors)
+			}
+			for _, key := range tt.errors {
+				if _, ok := form.FieldErrors[key]; !ok {
+					t.Errorf("no error for %q", key)
+				}
+			}
+		})
+	}
+}
+
+func TestFit(t *testing.T) {
+	billancourt := &database.Location{Lat: 48.8339, Lng: 2.2410}
+	nanterre := &database.Location{Lat: 48.8924, Lng: 2.2071}
+	lyon := &database.Location{Lat: 45.7640, Lng: 4.8357}
+
+	near, _, _ := fit([]*database.Location{billancourt, nanterre})
+	far, left, top := fit([]*database.Location{billancourt, lyon})
+	if near <= far {
+		t.Errorf("got zoom %d for Nanterre, %d for Lyon", near, far)
+	}
+
+	// Both ends inside the map, away from the edges
+	for _, l := range []*database.Location{billancourt, lyon} {
+		x, y := project(l, far)
+		x, y = x-left, y-top
+		if x < mapPadding-1 || x > mapWidth-mapPadding+1 ||
+			y < mapPadding-1 || y > mapHeight-mapPadding+1 {
+			t.Errorf("%+v at %.0f,%.0f", l, x, y)
+		}
+	}
+
+	if zoom, _, _ := fit([]*database.Location{billancourt}); zoom != mapMaxZoom {
+		t.Errorf("got zoom %d for a source; want %d", zoom, mapMaxZoom)
+	}
+	if zoom, _, _ := fit(nil); zoom != 0 {
+		t.Errorf("got zoom %d without sources; want 0", zoom)
+	}
+}
+
+func TestProject(t *testing.T) {
+	// At zoom 0 the world is a tile of 256 pixels
+	x, y := project(&database.Location{}, 0)
+	if math.Abs(x-128) > 1e-9 || math.Abs(y-128) > 1e-9 {
+		t.Errorf("got %f,%f; want 128,128", x, y)
+	}
+
+	// Tile 16/33175/22552 has Billancourt
+	x, y = project(&database.Location{Lat: 48.8339, Lng: 2.2410}, 16)
+	if int(x/tileSize) != 33175 || int(y/tileSize) != 22552 {
+		t.Errorf("got tile %d/%d", int(x/tileSize), int(y/tileSize))
+	}
+}
+
+// addLocation places the source sID on the map
+func addLocation(t *testing.T, app *application, sID int, lat, lng float64) {
+	t.Helper()
+
+	ctx := context.Background()
+	src, err := app.store.Sources().SourceGet(ctx, sID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.Location = &database.Location{Lat: lat, Lng: lng}
+
+	if err = app.store.Sources().SourceUpdate(ctx, src); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSourceLocationForm(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	ctx := context.Background()
+
+	code, _, _ := ts.postForm(t, "/source/create", url.Values{
+		"name": {"Billancourt"}, "address": {" 1 quai de Stalingrad "},
+		"latitude": {"48,8339"}, "longitude": {"2.2410"}})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	src, err := app.store.Sources().SourceGet(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Address != "1 quai de Stalingrad" {
+		t.Errorf("got address %q", src.Address)
+	}
+	if src.Location == nil || src.Location.Lat != 48.8339 || src.Location.Lng != 2.241 {
+		t.Errorf("got location %+v", src.Location)
+	}
+
+	code, _, body := ts.get(t, "/source/update/1")
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, `value="48.8339"`)
+	assertContains(t, body, `value="2.241"`)
+
+	_, _, body = ts.get(t, "/source/view/1")
+	assertContains(t, body, "1 quai de Stalingrad")
+	assertContains(t, body, "/map?source=1")
+
+	code, _, body = ts.postForm(t, "/source/update/1", url.Values{
+		"name": {"Billancourt"}, "latitude": {"48.8339"}})
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+	assertContains(t, body, "Enter both the latitude and the longitude")
+
+	// Both blank, off the map
+	code, _, _ = ts.postForm(t, "/source/update/1", url.Values{"name": {"Billancourt"}})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	src, _ = app.store.Sources().SourceGet(ctx, 1)
+	if src.Location != nil {
+		t.Errorf("got location %+v; want nil", src.Location)
+	}
+}
+
+func TestSourceMap(t *testing.T) {
+	app := newTestApplication(t)
+	billancourt := addSource(t, app, "Billancourt")
+	nanterre := addSource(t, app, "Nanterre")
+	addSource(t, app, "Lyon")
+	addLocation(t, app, billancourt, 48.8339, 2.2410)
+	addLocation(t, app, nanterre, 48.8924, 2.2071)
+	for i := 0; i < 6; i++ {
+		addInfo(t, app, nanterre, fmt.Sprintf("Transfo %d", i), "waiting")
+	}
+
+	ts := newTestServer(t, app.routes())
+
+	code, _, body := ts.get(t, "/map")
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, `class="map-green"`)
+	assertContains(t, body, `class="map-orange"`)
+	assertContains(t, body, fmt.Sprintf("/source/view/%d", nanterre))
+	assertContains(t, body, "Not on the map")
+	assertContains(t, body, "Lyon")
+	if strings.Contains(body, "<image") {
+		t.Error("tiles without -map-tiles")
+	}
+
+	_, _, body = ts.get(t, fmt.Sprintf("/map?source=%d", billancourt))
+	assertContains(t, body, "map-green map-selected")
+
+	// Lyon has no location
+	code, _, _ = ts.get(t, "/map?source=3")
+	assertStatus(t, code, http.StatusNotFound)
+	code, _, _ = ts.get(t, "/map?source=x")
+	assertStatus(t, code, http.StatusBadRequest)
+
+	code, _, _ = ts.get(t, "/map/tiles/16/33175/22552.png")
+	assertStatus(t, code, http.StatusNotFound)
+}
+
+func TestMapTiles(t *testing.T) {
+	app := newTestApplication(t)
+	addLocation(t, app, addSource(t, app, "Billancourt"), 48.8339, 2.2410)
+	app.mapTiles = fstest.MapFS{
+		"16/33175/22552.png": {Data: []byte("\x89PNG")},
+	}
+
+	ts := newTestServer(t, app.routes())
+
+	_, _, body := ts.get(t, "/map")
+	assertContains(t, body, `<image href="/map/tiles/16/33175/22552.png"`)
+
+	code, header, body := ts.get(t, "/map/tiles/16/33175/22552.png")
+	assertStatus(t, code, http.StatusOK)
+	if ct := header.Get("Content-Type"); ct != "image/png" {
+		t.Errorf("got Content-Type %q", ct)
+	}
+	if body != "\x89PNG" {
+		t.Errorf("got %q", body)
+	}
+
+	for _, path := range []string{
+		"/map/tiles/16/33175/22553.png",
+		"/map/tiles/16/-1/22552.png",
+		"/map/tiles/16/x/22552.png",
+	} {
+		code, _, _ = ts.get(t, path)
+		assertStatus(t, code, http.StatusNotFound)
+	}
+}
+
+func TestSourcesGeoJSON(t *testing.T) {
+	app := newTestApplication(t)
+	billancourt := addSource(t, app, "Billancourt")
+	addSource(t, app, "Lyon")
+	addLocation(t, app, billancourt, 48.8339, 2.2410)
+	addInfo(t, app, billancourt, "Transfo 1", "waiting")
+	addInfo(t, app, billancourt, "Transfo 2", "archived")
+
+	ts := newTestServer(t, app.routes())
+
+	code, header, body := ts.get(t, "/map/sources.geojson")
+	assertStatus(t, code, http.StatusOK)
+	if ct := header.Get("Content-Type"); ct != "application/geo+json" {
+		t.Errorf("got Content-Type %q", ct)
+	}
+
+	var collection struct {
+		Type     string
+		Features []struct {
+			Geometry *struct {
+				Type        string
+				Coordinates []float64
+			}
+			Properties struct {
+				Name  string
+				Open  int
+				Level string
+				URL   string
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(body), &collection); err != nil {
+		t.Fatal(err)
+	}
+
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 2 {
+		t.Fatalf("got %s", body)
+	}
+
+	// By name, Billancourt first
+	b, l := collection.Features[0], collection.Features[1]
+	if b.Geometry == nil || b.Geometry.Type != "Point" ||
+		b.Geometry.Coordinates[0] != 2.2410 || b.Geometry.Coordinates[1] != 48.8339 {
+		t.Errorf("got geometry %+v", b.Geometry)
+	}
+	if b.Properties.Open != 1 || b.Properties.Level != "yellow" {
+		t.Errorf("got properties %+v", b.Properties)
+	}
+	if !strings.HasSuffix(b.Properties.URL, fmt.Sprintf("/source/view/%d", billancourt)) {
+		t.Errorf("got url %q", b.Properties.URL)
+	}
+	if l.Properties.Name != "Lyon" || l.Geometry != nil {
+		t.Errorf("got %+v", l)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Every Prometheus metric exposed by CURATOR lives here.
+// They are registered in their own registry so /metrics
+// only shows what we want
+type metrics struct {
+	registry *prometheus.Registry
+
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	renders  *prometheus.HistogramVec
+}
+
+func newMetrics(app *application) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "curator",
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "curator",
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+
+		renders: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "curator",
+			Name:      "template_render_duration_seconds",
+			Help:      "Time spent executing a page template.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1},
+		}, []string{"page"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.duration,
+		m.renders,
+		newPoolCollector(app.DB),
+		&domainCollector{app: app},
+	)
+
+	return m
+}
+
+// handler serves the registry in Prometheus text format
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// instrument counts every request and measures its duration.
+// The label is the chi route pattern (ex.: /source/view/{id})
+// and not the raw URL so the number of series stays small
+func (m *metrics) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				route = pattern
+			}
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		m.requests.WithLabelValues(route, r.Method,
+			strconv.Itoa(status)).Inc()
+		m.duration.WithLabelValues(route, r.Method).
+			Observe(time.Since(start).Seconds())
+	})
+}
+
+//
+// pgxpool stats
+//
+
+// poolCollector reads pgxpool.Stat at each scrape
+type poolCollector struct {
+	db *pgxpool.Pool
+
+	acquired     *prometheus.Desc
+	idle         *prometheus.Desc
+	total        *prometheus.Desc
+	max          *prometheus.Desc
+	acquireCount *prometheus.Desc
+	emptyCount   *prometheus.Desc
+	waitSeconds  *prometheus.Desc
+}
+
+func newPoolCollector(db *pgxpool.Pool) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("curator_db_pool_"+name, help, nil, nil)
+	}
+
+	return &poolCollector{
+		db: db,
+
+		acquired: desc("acquired_conns",
+			"Connections currently in use."),
+		idle: desc("idle_conns",
+			"Connections currently idle."),
+		total: desc("total_conns",
+			"Connections currently open."),
+		max: desc("max_conns",
+			"Maximum size of the pool."),
+		acquireCount: desc("acquire_total",
+			"Number of successful acquires."),
+		emptyCount: desc("empty_acquire_total",
+			"Acquires that had to wait because the pool was empty."),
+		waitSeconds: desc("acquire_duration_seconds_total",
+			"Total time spent waiting for a connection."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquired
+	ch <- c.idle
+	ch <- c.total
+	ch <- c.max
+	ch <- c.acquireCount
+	ch <- c.emptyCount
+	ch <- c.waitSeconds
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.db == nil {
+		return
+	}
+
+	s := c.db.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquired,
+		prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle,
+		prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total,
+		prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max,
+		prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount,
+		prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyCount,
+		prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.waitSeconds,
+		prometheus.CounterValue, s.AcquireDuration().Seconds())
+}
+
+//
+// Domain gauges
+//
+
+var (
+	infosByStatusDesc = prometheus.NewDesc("curator_open_infos",
+		"Infos not archived, by status.", []string{"status"}, nil)
+	infosBySourceDesc = prometheus.NewDesc("curator_source_open_infos",
+		"Infos not archived, by source.", []string{"source_id", "source"}, nil)
+)
+
+// domainCollector queries PSQL at each scrape so the
+// gauges always match what the home page shows
+type domainCollector struct {
+	app *application
+}
+
+func (c *domainCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- infosByStatusDesc
+	ch <- infosBySourceDesc
+}
+
+func (c *domainCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	counts, err := c.app.store.Infos().StatusCount(ctx)
+	if err != nil {
+		c.app.logger.Error("metrics: domain gauges", "error", err)
+		return
+	}
+
+	for status, n := range counts {
+		ch <- prometheus.MustNewConstMetric(infosByStatusDesc,
+			prometheus.GaugeValue, float64(n), status)
+	}
+
+	sources, err := c.app.store.Sources().MenuSource(ctx)
+	if err != nil {
+		c.app.logger.Error("metrics: domain gauges", "error", err)
+		return
+	}
+
+	for _, s := range sources {
+		ch <- prometheus.MustNewConstMetric(infosBySourceDesc,
+			prometheus.GaugeValue, float64(s.Curatifs),
+			strconv.Itoa(s.ID), s.Name)
+	}
+}
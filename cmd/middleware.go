@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"CURATOR/internal/logging"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// requestID gives every request an ID. It's stored inside the
+// request context so every log line (handlers and database)
+// can be linked to the request. A X-Request-Id sent by a proxy
+// is kept, otherwise a new one is generated
+func (app *application) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-Id")
+		if id == "" || len(id) > 64 {
+			id = newRequestID()
+		}
+
+		w.Header().Set("X-Request-Id", id)
+
+		ctx := logging.WithRequestID(r.Context(), id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// logRequest writes one line per request once it's done
+func (app *application) logRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		app.logger.InfoContext(r.Context(), "request",
+			"method", r.Method,
+			"uri", r.URL.RequestURI(),
+			"status", ww.Status(),
+			"bytes", ww.BytesWritten(),
+			"duration", time.Since(start),
+			"remote", r.RemoteAddr)
+	})
+}
+
+// recoverPanic turns a panic inside a handler into a logged
+// 500 instead of a closed connection
+func (app *application) recoverPanic(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			// Used by net/http to abort a response on purpose
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			w.Header().Set("Connection", "close")
+			app.serverError(w, r, fmt.Errorf("panic: %v", rec))
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
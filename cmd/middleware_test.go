@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"CURATOR/internal/logging"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRequestID(t *testing.T) {
+	app := newTestApplication(t)
+
+	var got string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = logging.RequestID(r.Context())
+	})
+
+	rr := httptest.NewRecorder()
+	r := newTestRequest(t, app, http.MethodGet, "/")
+	app.requestID(next).ServeHTTP(rr, r)
+
+	if got == "" {
+		t.Fatal("no request ID in context")
+	}
+	if h := rr.Header().Get("X-Request-Id"); h != got {
+		t.Errorf("got header %q; want %q", h, got)
+	}
+
+	// An ID sent by a proxy is kept
+	rr = httptest.NewRecorder()
+	r = newTestRequest(t, app, http.MethodGet, "/")
+	r.Header.Set("X-Request-Id", "abc")
+	app.requestID(next).ServeHTTP(rr, r)
+
+	if got != "abc" {
+		t.Errorf("got %q; want abc", got)
+	}
+}
+
+func TestRecoverPanic(t *testing.T) {
+	app := newTestApplication(t)
+
+	// Through every middleware, the error page needs the session
+	mux := app.routes().(*chi.Mux)
+	mux.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	assertStatus(t, rr.Code, http.StatusInternalServerError)
+	if rr.Header().Get("Connection") != "close" {
+		t.Error("connection is not closed")
+	}
+	assertContains(t, rr.Body.String(), "Internal Server Error")
+}
+
+func TestJSONError(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/source/view/42", nil)
+	req.Header.Set("Accept", "application/json")
+
+	code, header, body := ts.do(t, req)
+
+	assertStatus(t, code, http.StatusNotFound)
+	if ct := header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("got Content-Type %q", ct)
+	}
+
+	var rs struct {
+		Error pageError `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(body), &rs); err != nil {
+		t.Fatal(err)
+	}
+	if rs.Error.Status != http.StatusNotFound {
+		t.Errorf("got %+v", rs.Error)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"CURATOR/database"
+	"CURATOR/internal/qr"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Stickers on the equipment: /s/{id} and /i/{id} are short links
+// to a source and an info. They only hold the id, so they still
+// work after a rename, a merge (sourceView follows the alias) or
+// a move of the info. Their QR codes are /s/{id}/qr.png (or .svg),
+// /source/{id}/labels is the sheet to print
+
+// Pixels a module of the PNG, about 2 cm wide printed at 300 dpi
+const qrScale = 8
+
+// qrLabel is a sticker of the sheet
+type qrLabel struct {
+	Title  string
+	Detail string
+
+	// Short link, written under the code for
+	// those who can't scan it
+	URL   string
+	Image string
+}
+
+// sourceParam returns the source of the URL, or writes
+// the error page and returns nil
+func (app *application) sourceParam(w http.ResponseWriter, r *http.Request) *database.Source {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return nil
+	}
+
+	source, err := app.store.Sources().SourceGet(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return nil
+	}
+
+	return source
+}
+
+// infoParam is sourceParam for an info
+func (app *application) infoParam(w http.ResponseWriter, r *http.Request) *database.Info {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return nil
+	}
+
+	info, err := app.store.Infos().InfoGet(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return nil
+	}
+
+	return info
+}
+
+// /s/{id}, sourceView sends a merged source to
+// the one it was merged into
+func (app *application) shortSource(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/source/view/%d", id), http.StatusFound)
+}
+
+// /i/{id}, the info may have moved to another source
+func (app *application) shortInfo(w http.ResponseWriter, r *http.Request) {
+	info := app.infoParam(w, r)
+	if info == nil {
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/source/%d/info/view/%d",
+		info.SourceID, info.ID), http.StatusFound)
+}
+
+// /s/{id}/qr.{format}, format is png or svg
+func (app *application) sourceQR(w http.ResponseWriter, r *http.Request) {
+	source := app.sourceParam(w, r)
+	if source == nil {
+		return
+	}
+
+	app.writeQR(w, r, fmt.Sprintf("/s/%d", source.ID))
+}
+
+// /i/{id}/qr.{format}
+func (app *application) infoQR(w http.ResponseWriter, r *http.Request) {
+	info := app.infoParam(w, r)
+	if info == nil {
+		return
+	}
+
+	app.writeQR(w, r, fmt.Sprintf("/i/%d", info.ID))
+}
+
+// writeQR answers the QR code of the short link path
+func (app *application) writeQR(w http.ResponseWriter, r *http.Request, path string) {
+	format := chi.URLParam(r, "format")
+	if format != "png" && format != "svg" {
+		app.notFound(w, r)
+		return
+	}
+
+	code, err := qr.Encode(app.absoluteURL(r, path))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if format == "png" {
+		w.Header().Set("Content-Type", "image/png")
+		err = code.PNG(&buf, qrScale)
+	} else {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		err = code.SVG(&buf)
+	}
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// The link never changes, unless it comes from the
+	// Host of the request: the code isn't shared then
+	if app.baseURL != "" {
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+	} else {
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+	}
+	w.Write(buf.Bytes())
+}
+
+// Sheet of stickers of a source: the source, then
+// each of its open infos
+func (app *application) sourceLabels(w http.ResponseWriter, r *http.Request) {
+	source := app.sourceParam(w, r)
+	if source == nil {
+		return
+	}
+	id := source.ID
+
+	infos, err := app.store.Infos().InfoList(r.Context(), id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	labels := []*qrLabel{{
+		Title:  source.Name,
+		Detail: source.Address,
+		URL:    app.absoluteURL(r, fmt.Sprintf("/s/%d", id)),
+		Image:  fmt.Sprintf("/s/%d/qr.svg", id),
+	}}
+
+	for _, info := range infos {
+		if info.Status == "archived" {
+			continue
+		}
+
+		detail := source.Name
+		if info.EquipmentName != "" && info.EquipmentName != info.Material {
+			detail += " - " + info.EquipmentName
+		}
+
+		labels = append(labels, &qrLabel{
+			Title:  fmt.Sprintf("#%d %s", info.ID, info.Material),
+			Detail: detail,
+			URL:    app.absoluteURL(r, fmt.Sprintf("/i/%d", info.ID)),
+			Image:  fmt.Sprintf("/i/%d/qr.svg", info.ID),
+		})
+	}
+
+	data := app.newTemplateData(r)
+	data.Source = source
+	data.Labels = labels
+
+	app.render(w, r, http.StatusOK, "labels.tmpl.html", data)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/png"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"CURATOR/internal/qr"
+)
+
+func TestShortLinks(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	billancourt := addSource(t, app, "Billancourt")
+	nanterre := addSource(t, app, "Nanterre")
+	iID := addInfo(t, app, billancourt, "Transfo 1", "waiting")
+
+	ts := newTestServer(t, app.routes())
+
+	redirects := func(path, want string) {
+		t.Helper()
+
+		code, header, _ := ts.get(t, path)
+		assertStatus(t, code, http.StatusFound)
+		if loc := header.Get("Location"); loc != want {
+			t.Errorf("%s: got Location %q; want %q", path, loc, want)
+		}
+	}
+
+	redirects(fmt.Sprintf("/s/%d", billancourt),
+		fmt.Sprintf("/source/view/%d", billancourt))
+	redirects(fmt.Sprintf("/i/%d", iID),
+		fmt.Sprintf("/source/%d/info/view/%d", billancourt, iID))
+
+	// A rename changes nothing, a move changes the source
+	code, _, _ := ts.postForm(t, fmt.Sprintf("/source/update/%d", billancourt),
+		url.Values{"name": {"Boulogne-Billancourt"}})
+	assertStatus(t, code, http.StatusSeeOther)
+	if err := app.store.Infos().InfoMove(ctx, iID, nanterre); err != nil {
+		t.Fatal(err)
+	}
+
+	redirects(fmt.Sprintf("/s/%d", billancourt),
+		fmt.Sprintf("/source/view/%d", billancourt))
+	redirects(fmt.Sprintf("/i/%d", iID),
+		fmt.Sprintf("/source/%d/info/view/%d", nanterre, iID))
+
+	for _, path := range []string{"/s/x", "/i/0", "/i/42"} {
+		code, _, _ = ts.get(t, path)
+		assertStatus(t, code, http.StatusNotFound)
+	}
+}
+
+func TestQRCodes(t *testing.T) {
+	app := newTestApplication(t)
+	sID := addSource(t, app, "Billancourt")
+	iID := addInfo(t, app, sID, "Transfo 1", "waiting")
+
+	ts := newTestServer(t, app.routes())
+
+	// The code of the short link on this server
+	code, header, body := ts.get(t, fmt.Sprintf("/i/%d/qr.svg", iID))
+	assertStatus(t, code, http.StatusOK)
+	if ct := header.Get("Content-Type"); ct != "image/svg+xml" {
+		t.Errorf("got Content-Type %q", ct)
+	}
+
+	want, err := qr.Encode(fmt.Sprintf("%s/i/%d", ts.URL, iID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var svg bytes.Buffer
+	want.SVG(&svg)
+	if body != svg.String() {
+		t.Error("the code isn't the one of the short link")
+	}
+	if cc := header.Get("Cache-Control"); !strings.HasPrefix(cc, "private") {
+		t.Errorf("got Cache-Control %q; want private without -base-url", cc)
+	}
+
+	// -base-url, whatever the host the code is asked from
+	app.baseURL = "https://curator.example.org"
+	code, header, body = ts.get(t, fmt.Sprintf("/i/%d/qr.svg", iID))
+	assertStatus(t, code, http.StatusOK)
+	want, err = qr.Encode(fmt.Sprintf("https://curator.example.org/i/%d", iID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svg.Reset()
+	want.SVG(&svg)
+	if body != svg.String() {
+		t.Error("the code isn't the one of -base-url")
+	}
+	if cc := header.Get("Cache-Control"); !strings.HasPrefix(cc, "public") {
+		t.Errorf("got Cache-Control %q; want public", cc)
+	}
+	app.baseURL = ""
+
+	code, header, body = ts.get(t, fmt.Sprintf("/s/%d/qr.png", sID))
+	assertStatus(t, code, http.StatusOK)
+	if ct := header.Get("Content-Type"); ct != "image/png" {
+		t.Errorf("got Content-Type %q", ct)
+	}
+	if _, err := png.Decode(strings.NewReader(body)); err != nil {
+		t.Error(err)
+	}
+
+	for _, path := range []string{
+		fmt.Sprintf("/s/%d/qr.gif", sID),
+		"/s/42/qr.png",
+		"/i/42/qr.svg",
+	} {
+		code, _, _ = ts.get(t, path)
+		assertStatus(t, code, http.StatusNotFound)
+	}
+}
+
+func TestSourceLabels(t *testing.T) {
+	app := newTestApplication(t)
+	sID := addSource(t, app, "Billancourt")
+	open := addInfo(t, app, sID, "Transfo 1", "waiting")
+	archived := addInfo(t, app, sID, "Transfo 2", "archived")
+
+	ts := newTestServer(t, app.routes())
+
+	code, _, body := ts.get(t, fmt.Sprintf("/source/%d/labels", sID))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, fmt.Sprintf(`src="/s/%d/qr.svg"`, sID))
+	assertContains(t, body, fmt.Sprintf(`src="/i/%d/qr.svg"`, open))
+	assertContains(t, body, fmt.Sprintf("%s/i/%d", ts.URL, open))
+	assertContains(t, body, "Transfo 1")
+	if strings.Contains(body, fmt.Sprintf("/i/%d/qr.svg", archived)) {
+		t.Error("archived info has a label")
+	}
+
+	app.baseURL = "https://curator.example.org"
+	_, _, body = ts.get(t, fmt.Sprintf("/source/%d/labels", sID))
+	assertContains(t, body, fmt.Sprintf("https://curator.example.org/i/%d", open))
+	app.baseURL = ""
+
+	code, _, _ = ts.get(t, "/source/42/labels")
+	assertStatus(t, code, http.StatusNotFound)
+
+	// Links to the labels and the codes
+	_, _, body = ts.get(t, fmt.Sprintf("/source/view/%d", sID))
+	assertContains(t, body, fmt.Sprintf("/source/%d/labels", sID))
+	_, _, body = ts.get(t, fmt.Sprintf("/source/%d/info/view/%d", sID, open))
+	assertContains(t, body, fmt.Sprintf("/i/%d/qr.png", open))
+}
@@ -4,17 +4,37 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 )
 
 // Chaque page commence avec chi.NewRouter()
 func (app *application) routes() http.Handler {
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	r.Use(app.requestID)
+	r.Use(app.logRequest)
+	r.Use(app.metrics.instrument)
+
+	// Sessions (cookie SameSite=Lax), language of the pages
+	// @ cmd/locale.go and CSRF check of every POST.
+	// recoverPanic comes after the session: the error page reads it
+	r.Use(app.sessionManager.LoadAndSave)
+	r.Use(app.recoverPanic)
+	r.Use(app.locale)
+	r.Use(app.csrf)
+
+	// Unknown pages get the same error page as the handlers
+	r.NotFound(app.notFound)
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		app.clientError(w, r, http.StatusMethodNotAllowed)
+	})
 
 	// Home page
 	r.Get("/", app.home)
 
+	// Language menu
+	r.Post("/lang", app.langPost)
+	r.Post("/tz", app.tzPost)
+	r.Post("/user", app.userPost)
+
 	// web page to retrieve data in json format
 	// from server to web page
 	r.Get("/jsonGraph", app.jsonData)
@@ -23,21 +43,81 @@ func (app *application) routes() http.Handler {
 	r.Get("/source/view/{id}", app.sourceView)
 	r.Get("/source/create", app.sourceCreate)
 	r.Post("/source/create", app.sourceCreatePost)
+	r.Get("/source/delete/{id}", app.sourceDelete)
 	r.Post("/source/delete/{id}", app.sourceDeletePost)
 	r.Get("/source/update/{id}", app.sourceUpdate)
 	r.Post("/source/update/{id}", app.sourceUpdatePost)
+	r.Get("/source/merge/{id}", app.sourceMerge)
+	r.Post("/source/merge/{id}", app.sourceMergePost)
 
 	// Info pages
 	r.Get("/source/{sid}/info/view/{id}", app.infoView)
 	r.Get("/source/{id}/info/create", app.infoCreate)
 	r.Post("/source/{id}/info/create", app.infoCreatePost)
 	r.Post("/source/{sid}/info/delete/{id}", app.infoDeletePost)
+	r.Post("/source/{sid}/info/move/{id}", app.infoMovePost)
+	r.Post("/source/{sid}/info/assign/{id}", app.infoAssignPost)
 	r.Get("/source/{sid}/info/update/{id}", app.infoUpdate)
 	r.Post("/source/{sid}/info/update/{id}", app.infoUpdatePost)
 
-	// Static files
-	fileServer := http.FileServer(http.Dir("./ui/static/"))
-	r.Handle("/static/*", http.StripPrefix("/static", fileServer))
+	// Board @ cmd/board.go
+	r.Get("/board", app.board)
+	r.Post("/source/{sid}/info/status/{id}", app.infoStatusPost)
+
+	// Calendar and its .ics feeds @ cmd/calendar.go
+	r.Get("/calendar", app.calendar)
+	r.Post("/calendar/feed", app.calendarFeedPost)
+	r.Get("/calendar/{token}.ics", app.calendarFeed)
+
+	// Map of the sources and their GeoJSON @ cmd/map.go
+	r.Get("/map", app.sourceMap)
+	r.Get("/map/sources.geojson", app.sourcesGeoJSON)
+	r.Get("/map/tiles/{z}/{x}/{y}.png", app.mapTile)
+
+	// Short links, their QR codes and the labels @ cmd/qr.go
+	r.Get("/s/{id}", app.shortSource)
+	r.Get("/i/{id}", app.shortInfo)
+	r.Get("/s/{id}/qr.{format}", app.sourceQR)
+	r.Get("/i/{id}/qr.{format}", app.infoQR)
+	r.Get("/source/{id}/labels", app.sourceLabels)
+
+	// Equipment pages @ cmd/equipment.go
+	r.Get("/source/{sid}/equipment", app.equipmentList)
+	r.Post("/source/{sid}/equipment/create", app.equipmentCreatePost)
+	r.Get("/source/{sid}/equipment/view/{id}", app.equipmentView)
+	r.Get("/source/{sid}/equipment/update/{id}", app.equipmentUpdate)
+	r.Post("/source/{sid}/equipment/update/{id}", app.equipmentUpdatePost)
+	r.Post("/source/{sid}/equipment/delete/{id}", app.equipmentDeletePost)
+
+	// Agents directory @ cmd/agents.go, "My infos"
+	// @ cmd/assign.go
+	r.Get("/my", app.myInfos)
+	r.Get("/agents", app.agents)
+	r.Post("/agents/create", app.agentCreatePost)
+	r.Get("/agents/view/{id}", app.agentView)
+	r.Get("/agents/update/{id}", app.agentUpdate)
+	r.Post("/agents/update/{id}", app.agentUpdatePost)
+
+	// Tags and search @ cmd/tags.go
+	r.Get("/tags", app.tags)
+	r.Post("/tags/create", app.tagCreatePost)
+	r.Get("/tags/update/{id}", app.tagUpdate)
+	r.Post("/tags/update/{id}", app.tagUpdatePost)
+	r.Post("/tags/delete/{id}", app.tagDeletePost)
+	r.Get("/search", app.search)
+
+	// Trash
+	r.Get("/trash", app.trash)
+	r.Post("/trash/source/{id}/restore", app.sourceRestorePost)
+	r.Post("/trash/source/{id}/purge", app.sourcePurgePost)
+	r.Post("/trash/info/{id}/restore", app.infoRestorePost)
+	r.Post("/trash/info/{id}/purge", app.infoPurgePost)
+
+	// Prometheus scrape endpoint
+	r.Handle("/metrics", app.metrics.handler())
+
+	// Static files @ cmd/static.go
+	r.Handle("/static/*", app.static.handler(app.notFound))
 
 	return r
 }
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// staticFiles serves ui/static/. Each file has a hash of its
+// content: {{ static "js/main.js" }} gives /static/js/main.js?v=<hash>
+// so browsers can keep it in cache until it changes
+type staticFiles struct {
+	fsys   fs.FS
+	hashes map[string]string
+
+	// In dev mode (-dev) files can change while the program
+	// runs, hashes are computed at each call
+	dev bool
+}
+
+// fsys is the ui/ folder (embedded or -ui-dir)
+func newStaticFiles(fsys fs.FS) (*staticFiles, error) {
+	sub, err := fs.Sub(fsys, "static")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &staticFiles{
+		fsys:   sub,
+		hashes: map[string]string{},
+	}
+
+	err = fs.WalkDir(sub, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		hash, err := hashFile(sub, name)
+		if err != nil {
+			return err
+		}
+
+		s.hashes[name] = hash
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func hashFile(fsys fs.FS, name string) (string, error) {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:])[:12], nil
+}
+
+// hash returns the hash of a file and false if it doesn't exist
+func (s *staticFiles) hash(name string) (string, bool) {
+	if s.dev {
+		info, err := fs.Stat(s.fsys, name)
+		if err != nil || info.IsDir() {
+			return "", false
+		}
+
+		hash, err := hashFile(s.fsys, name)
+		return hash, err == nil
+	}
+
+	hash, ok := s.hashes[name]
+	return hash, ok
+}
+
+// url is the "static" template function
+func (s *staticFiles) url(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+
+	hash, ok := s.hash(name)
+	if !ok {
+		return "/static/" + name
+	}
+
+	return "/static/" + name + "?v=" + hash
+}
+
+// handler must be mounted on /static/.
+// A request with the right ?v= can be cached forever,
+// else the browser has to check the ETag again
+func (s *staticFiles) handler(notFound http.HandlerFunc) http.Handler {
+	fileServer := http.FileServer(http.FS(s.fsys))
+
+	return http.StripPrefix("/static", http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+
+			hash, ok := s.hash(name)
+			if !ok {
+				// No directory listing
+				notFound(w, r)
+				return
+			}
+
+			// http.FileServer answers 304 itself when
+			// If-None-Match matches this header
+			w.Header().Set("ETag", `"`+hash+`"`)
+
+			if r.URL.Query().Get("v") == hash {
+				w.Header().Set("Cache-Control",
+					"public, max-age=31536000, immutable")
+			} else {
+				w.Header().Set("Cache-Control", "no-cache")
+			}
+
+			fileServer.ServeHTTP(w, r)
+		}))
+}
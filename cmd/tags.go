@@ -0,0 +1,265 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"CURATOR/database"
+	"CURATOR/internal/validator"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Tags classify the infos (electrical, civil works, safety...).
+// They're managed on /tags, checked on the info forms and
+// filter the source page and the search
+
+// A colour of <input type="color">
+var colorRX = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type tagForm struct {
+	Name  string
+	Color string
+
+	validator.Validator
+}
+
+func (form *tagForm) check() {
+	form.CheckField(validator.NotBlank(form.Name), "name", "Cannot be empty")
+	form.CheckField(validator.MaxChars(form.Name, 50), "name", "50 characters max")
+	form.CheckField(validator.Matches(form.Color, colorRX), "color", "Choose a colour")
+}
+
+// Every tag with its number of infos, and the form to add one
+func (app *application) tags(w http.ResponseWriter, r *http.Request) {
+	app.renderTags(w, r, http.StatusOK, tagForm{Color: "#7f8c8d"})
+}
+
+func (app *application) renderTags(w http.ResponseWriter, r *http.Request, status int, form tagForm) {
+	tags, err := app.store.Tags().TagList(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Tags = tags
+	data.Form = form
+
+	app.render(w, r, status, "tags.tmpl.html", data)
+}
+
+func (app *application) tagCreatePost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	form := tagForm{
+		Name:  strings.TrimSpace(r.PostForm.Get("name")),
+		Color: r.PostForm.Get("color"),
+	}
+
+	form.check()
+
+	if form.Valid() {
+		_, err = app.store.Tags().TagInsert(r.Context(),
+			&database.Tag{Name: form.Name, Color: strings.ToLower(form.Color)})
+		if errors.Is(err, database.ErrDuplicateTag) {
+			form.AddFieldError("name", "This tag already exists")
+		} else if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	if !form.Valid() {
+		app.renderTags(w, r, http.StatusUnprocessableEntity, form)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Tag created")
+
+	http.Redirect(w, r, "/tags", http.StatusSeeOther)
+}
+
+func (app *application) tagUpdate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	tag, err := app.store.Tags().TagGet(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Tag = tag
+	data.Form = tagForm{Name: tag.Name, Color: tag.Color}
+
+	app.render(w, r, http.StatusOK, "tagUpdate.tmpl.html", data)
+}
+
+func (app *application) tagUpdatePost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	form := tagForm{
+		Name:  strings.TrimSpace(r.PostForm.Get("name")),
+		Color: r.PostForm.Get("color"),
+	}
+
+	form.check()
+
+	if form.Valid() {
+		err = app.store.Tags().TagUpdate(r.Context(),
+			&database.Tag{ID: id, Name: form.Name, Color: strings.ToLower(form.Color)})
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+			return
+		} else if errors.Is(err, database.ErrDuplicateTag) {
+			form.AddFieldError("name", "This tag already exists")
+		} else if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	if !form.Valid() {
+		data := app.newTemplateData(r)
+		data.Tag = &database.Tag{ID: id, Name: form.Name}
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "tagUpdate.tmpl.html", data)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Tag updated")
+
+	http.Redirect(w, r, "/tags", http.StatusSeeOther)
+}
+
+// The infos only lose the tag
+func (app *application) tagDeletePost(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	err = app.store.Tags().TagDelete(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Tag deleted")
+
+	http.Redirect(w, r, "/tags", http.StatusSeeOther)
+}
+
+type searchForm struct {
+	Q   string
+	Tag string
+}
+
+// Infos of every source by text and/or tag
+func (app *application) search(w http.ResponseWriter, r *http.Request) {
+	form := searchForm{
+		Q:   strings.TrimSpace(r.URL.Query().Get("q")),
+		Tag: r.URL.Query().Get("tag"),
+	}
+
+	tags, err := app.store.Tags().TagList(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Tags = tags
+	data.Form = form
+
+	// Nothing asked yet, only the form
+	if form.Q != "" || form.Tag != "" {
+		tagID, _ := strconv.Atoi(form.Tag)
+
+		data.Infos, err = app.store.Infos().InfoSearch(r.Context(), form.Q, tagID)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		data.InfoTags, err = app.tagsOf(r, data.Infos)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	app.render(w, r, http.StatusOK, "search.tmpl.html", data)
+}
+
+// tagsOf returns the tags of the infos, by info ID
+func (app *application) tagsOf(r *http.Request, infos []*database.Info) (map[int][]*database.Tag, error) {
+	ids := make([]int, 0, len(infos))
+	for _, info := range infos {
+		ids = append(ids, info.ID)
+	}
+
+	return app.store.Tags().TagsOfInfos(r.Context(), ids)
+}
+
+// tagsField reads the tags checked on an info form. Every
+// tag is returned for the checkboxes
+func (app *application) tagsField(r *http.Request, form *infoCreateForm) ([]*database.Tag, []int, error) {
+	tags, err := app.store.Tags().TagList(r.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	known := map[string]int{}
+	for _, tag := range tags {
+		known[strconv.Itoa(tag.ID)] = tag.ID
+	}
+
+	form.Tags = map[string]bool{}
+	ids := []int{}
+
+	for _, value := range r.PostForm["tags"] {
+		id, ok := known[value]
+		if !ok {
+			form.AddFieldError("tags", "Choose existing tags")
+			continue
+		}
+		if !form.Tags[value] {
+			form.Tags[value] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return tags, ids, nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"CURATOR/database"
+)
+
+func addTag(t *testing.T, app *application, name string) int {
+	t.Helper()
+
+	id, err := app.store.Tags().TagInsert(context.Background(),
+		&database.Tag{Name: name, Color: "#2980b9"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
+
+func TestTags(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	ts := newTestServer(t, app.routes())
+
+	code, _, body := ts.get(t, "/tags")
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "No tag yet.")
+
+	tests := []struct {
+		name string
+		form url.Values
+	}{
+		{"No name", url.Values{"name": {" "}, "color": {"#2980b9"}}},
+		{"Bad colour", url.Values{"name": {"Safety"}, "color": {"blue"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := ts.postForm(t, "/tags/create", tt.form)
+			assertStatus(t, code, http.StatusUnprocessableEntity)
+		})
+	}
+
+	code, _, _ = ts.postForm(t, "/tags/create",
+		url.Values{"name": {"Safety"}, "color": {"#C0392B"}})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	// Case doesn't make another tag
+	code, _, body = ts.postForm(t, "/tags/create",
+		url.Values{"name": {"safety"}, "color": {"#c0392b"}})
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+	assertContains(t, body, "This tag already exists")
+
+	tags, _ := app.store.Tags().TagList(ctx)
+	if len(tags) != 1 || tags[0].Color != "#c0392b" {
+		t.Fatalf("got %+v", tags)
+	}
+	id := tags[0].ID
+	other := addTag(t, app, "Vegetation")
+
+	code, _, body = ts.get(t, fmt.Sprintf("/tags/update/%d", id))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Safety")
+
+	code, _, _ = ts.get(t, "/tags/update/42")
+	assertStatus(t, code, http.StatusNotFound)
+
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/tags/update/%d", id),
+		url.Values{"name": {"Vegetation"}, "color": {"#c0392b"}})
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/tags/update/%d", id),
+		url.Values{"name": {"Electrical safety"}, "color": {"#c0392b"}})
+	assertStatus(t, code, http.StatusSeeOther)
+
+	tag, _ := app.store.Tags().TagGet(ctx, id)
+	if tag.Name != "Electrical safety" {
+		t.Errorf("got %q", tag.Name)
+	}
+
+	// The infos only lose the tag
+	sID := addSource(t, app, "Billancourt")
+	iID := addInfo(t, app, sID, "Transfo 1", "waiting")
+	app.store.Tags().TagsSet(ctx, iID, []int{id, other})
+
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/tags/delete/%d", id), nil)
+	assertStatus(t, code, http.StatusSeeOther)
+
+	of, _ := app.store.Tags().TagsOfInfos(ctx, []int{iID})
+	if len(of[iID]) != 1 || of[iID][0].ID != other {
+		t.Errorf("got %+v", of[iID])
+	}
+
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/tags/delete/%d", id), nil)
+	assertStatus(t, code, http.StatusNotFound)
+}
+
+func TestInfoTags(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	sID := addSource(t, app, "Billancourt")
+	safety := addTag(t, app, "Safety")
+	civil := addTag(t, app, "Civil works")
+	ts := newTestServer(t, app.routes())
+	path := fmt.Sprintf("/source/%d/info/create", sID)
+
+	code, _, body := ts.get(t, path)
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, `name="tags"`)
+
+	form := validInfoForm()
+	form["tags"] = []string{"42"}
+	code, _, body = ts.postForm(t, path, form)
+	assertStatus(t, code, http.StatusUnprocessableEntity)
+	assertContains(t, body, "Choose existing tags")
+
+	form["tags"] = []string{fmt.Sprint(safety)}
+	code, _, _ = ts.postForm(t, path, form)
+	assertStatus(t, code, http.StatusSeeOther)
+
+	infos, _ := app.store.Infos().InfoList(ctx, sID)
+	if len(infos) != 1 {
+		t.Fatalf("got %d infos; want 1", len(infos))
+	}
+	iID := infos[0].ID
+
+	of, _ := app.store.Tags().TagsOfInfos(ctx, []int{iID})
+	if len(of[iID]) != 1 || of[iID][0].ID != safety {
+		t.Fatalf("got %+v", of[iID])
+	}
+
+	// The update page has the tags checked
+	code, _, body = ts.get(t, fmt.Sprintf("/source/%d/info/update/%d", sID, iID))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, fmt.Sprintf(`value="%d" checked`, safety))
+
+	form["tags"] = []string{fmt.Sprint(civil)}
+	code, _, _ = ts.postForm(t, fmt.Sprintf("/source/%d/info/update/%d", sID, iID), form)
+	assertStatus(t, code, http.StatusSeeOther)
+
+	code, _, body = ts.get(t, fmt.Sprintf("/source/%d/info/view/%d", sID, iID))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Civil works")
+
+	// Filter of the source page
+	addInfo(t, app, sID, "Bay 2", "waiting")
+
+	code, _, body = ts.get(t, fmt.Sprintf("/source/view/%d?tag=%d", sID, civil))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Transfo 1")
+	if strings.Contains(body, "Bay 2") {
+		t.Error("the info without the tag is shown")
+	}
+
+	code, _, body = ts.get(t, fmt.Sprintf("/source/view/%d?tag=%d", sID, safety))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "No info with this tag.")
+
+	code, _, _ = ts.get(t, fmt.Sprintf("/source/view/%d?tag=42", sID))
+	assertStatus(t, code, http.StatusNotFound)
+}
+
+func TestSearch(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	sID := addSource(t, app, "Billancourt")
+	other := addSource(t, app, "Nanterre")
+	safety := addTag(t, app, "Safety")
+	iID := addInfo(t, app, sID, "Transfo 1", "waiting")
+	addInfo(t, app, other, "Transfo 2", "waiting")
+	addInfo(t, app, other, "Bay 3", "waiting")
+	app.store.Tags().TagsSet(ctx, iID, []int{safety})
+	ts := newTestServer(t, app.routes())
+
+	code, _, body := ts.get(t, "/search")
+	assertStatus(t, code, http.StatusOK)
+	if strings.Contains(body, "No info found.") {
+		t.Error("results shown before searching")
+	}
+
+	code, _, body = ts.get(t, "/search?q=TRANSFO")
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Transfo 1")
+	assertContains(t, body, "Transfo 2")
+	assertContains(t, body, "Nanterre")
+
+	code, _, body = ts.get(t, fmt.Sprintf("/search?q=transfo&tag=%d", safety))
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "Transfo 1")
+	if strings.Contains(body, "Transfo 2") {
+		t.Error("the info without the tag is found")
+	}
+
+	code, _, body = ts.get(t, "/search?q=pylon")
+	assertStatus(t, code, http.StatusOK)
+	assertContains(t, body, "No info found.")
+}
+
+func TestJSONGraphTags(t *testing.T) {
+	app := newTestApplication(t)
+	ctx := context.Background()
+	sID := addSource(t, app, "Billancourt")
+	safety := addTag(t, app, "Safety")
+	open := addInfo(t, app, sID, "Transfo 1", "waiting")
+	archived := addInfo(t, app, sID, "Transfo 2", "archived")
+	app.store.Tags().TagsSet(ctx, open, []int{safety})
+	app.store.Tags().TagsSet(ctx, archived, []int{safety})
+	ts := newTestServer(t, app.routes())
+
+	code, _, body := ts.get(t, "/jsonGraph")
+	assertStatus(t, code, http.StatusOK)
+
+	var sources []struct {
+		Name string         `json:"name"`
+		Tags map[string]int `json:"tags"`
+	}
+	if err := json.Unmarshal([]byte(body), &sources); err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 1 || sources[0].Tags["Safety"] != 1 {
+		t.Errorf("got %+v", sources)
+	}
+}
@@ -2,11 +2,14 @@ package main
 
 import (
 	"html/template"
-	"path/filepath"
+	"io/fs"
+	"path"
+	"strings"
 
 	"time"
 
 	"CURATOR/database"
+	"CURATOR/internal/i18n"
 )
 
 // Template struct that generate and analyse data
@@ -20,45 +23,163 @@ type templateData struct {
 
 	JSource []byte
 
+	// Equipment of Source as a tree, and its kinds
+	Equipment     *database.Equipment
+	EquipmentList []*database.Equipment
+	Kinds         []string
+
+	// Agents directory, the agent of the page and
+	// the teams an info can be assigned to
+	Agents []*database.Agent
+	Agent  *database.Agent
+	Teams  []string
+
+	// Every tag, the one filtering the page and the tags
+	// of Infos by info ID
+	Tags     []*database.Tag
+	Tag      *database.Tag
+	InfoTags map[int][]*database.Tag
+
+	// Columns of the board @ cmd/board.go
+	Board []*boardColumn
+
+	// Month or week of the calendar @ cmd/calendar.go
+	Calendar *calendarPage
+
+	// Sources placed on the map @ cmd/map.go
+	Map *mapPage
+
+	// Stickers of a source @ cmd/qr.go
+	Labels []*qrLabel
+
+	// Existing sources that look like the name typed
+	Similar []*database.Source
+
+	// Names of the sources merged into Source and its history
+	Aliases []string
+	History []*database.AuditEntry
+
+	// Submitted values and field errors (validator.Validator)
 	Form any
+
+	// Message of the last action, ex.: "Info created"
+	Flash string
+
+	// Name typed in the menu @ cmd/user.go
+	User string
+
+	// Days in the trash before the purge
+	Retention int
+
+	// Language and time zone of the page, used by t,
+	// date, datetime and relative
+	Locale    i18n.Locale
+	Langs     []string
+	TimeZones []string
+
+	// Hidden field of every POST form @ cmd/csrf.go
+	CSRFToken string
+
+	// Set only by errorResponse @ cmd/errors.go
+	Error *pageError
 }
 
 // @ tables sources et infos, columns "Created" and "Updated"
 // have timestamp (UTC)
 // SELECT NOW()::timestamp;
 // 2023-02-10 19:28:53.116296
-// |________| needed
-func humanDate(t time.Time) string {
-	return t.Format("02/01/2006")
+// They are shown in the zone and the language of the page,
+// a zero time (database.ZeroTime) is "-"
+// {{ date $.Locale .Created }}      10 févr. 2023
+// {{ datetime $.Locale .Created }}  10 févr. 2023 20:28
+// {{ relative $.Locale .Created }}  il y a 3 jours
+func date(l i18n.Locale, t time.Time) string {
+	return l.Date(t)
+}
+
+func datetime(l i18n.Locale, t time.Time) string {
+	return l.DateTime(t)
+}
+
+func relative(l i18n.Locale, t time.Time) string {
+	return l.Relative(t)
+}
+
+// day is date for a calendar day (a due date): stored at
+// midnight UTC, it's the same day whatever the time zone
+func day(l i18n.Locale, t time.Time) string {
+	l.TZ = nil
+	return l.Date(t)
+}
+
+// month and weekday are the titles of the calendar:
+// {{ month $.Locale .Date }}   juin 2024
+// {{ weekday $.Locale .Date }} lun.
+func month(l i18n.Locale, t time.Time) string {
+	return l.Month(t)
+}
+
+func weekday(l i18n.Locale, t time.Time) string {
+	return l.Weekday(t.Weekday())
+}
+
+// level is the colour of a source by its open infos
+// {{ level .Curatifs }}  green, yellow, orange or red
+func level(open int) string {
+	return sourceLevel(open)
+}
+
+// isoTime is the value of <time datetime="...">
+func isoTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// t translates a message @ internal/i18n/catalog.go
+// {{ t $.Locale "Priority" }}
+func translate(l i18n.Locale, msg string, args ...any) string {
+	return l.T(msg, args...)
+}
+
+// indent shows the depth of an equipment in a <select>,
+// where CSS padding doesn't work
+func indent(depth int) string {
+	return strings.Repeat("\u00a0\u00a0\u00a0", depth)
 }
 
 // template.FuncMap is stocked in a global variable
-// so it's easier to used it with humanDate function
+// so it's easier to used it with the date functions
 var functions = template.FuncMap{
-	"humanDate": humanDate,
+	"date":     date,
+	"day":      day,
+	"month":    month,
+	"weekday":  weekday,
+	"datetime": datetime,
+	"relative": relative,
+	"isoTime":  isoTime,
+	"t":        translate,
+	"indent":   indent,
+	"level":    level,
 }
 
-func newTemplateCache() (map[string]*template.Template, error) {
+// fsys is the ui/ folder, embedded in the binary (ui.Files)
+// or a directory given with -ui-dir
+func newTemplateCache(fsys fs.FS, static *staticFiles) (map[string]*template.Template, error) {
 	cache := map[string]*template.Template{}
 
 	// Create a slice with all paths
-	pages, err := filepath.Glob("./ui/html/pages/*.tmpl.html")
+	pages, err := fs.Glob(fsys, "html/pages/*.tmpl.html")
 	if err != nil {
 		return nil, err
 	}
 
 	for _, page := range pages {
 		/// extracts the path file name
-		name := filepath.Base(page)
+		name := path.Base(page)
 
 		// Create a new empty template
 		ts, err := template.New(name).Funcs(functions).
-			ParseFiles("./ui/html/base.tmpl.html")
-		if err != nil {
-			return nil, err
-		}
-
-		ts, err = ts.ParseFiles(page)
+			Funcs(template.FuncMap{"static": static.url}).
+			ParseFS(fsys, "html/base.tmpl.html", page)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"CURATOR/database"
+	"CURATOR/database/memory"
+	"CURATOR/ui"
+
+	"github.com/alexedwards/scs/v2"
+)
+
+// newTestApplication returns an application using the
+// in-memory store (database/memory) and a silent logger.
+// The directory has one agent, Dupont (ID 1), who reports
+// the infos of validInfoForm and addInfo
+func newTestApplication(t *testing.T) *application {
+	t.Helper()
+
+	static, err := newStaticFiles(ui.Files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templateCache, err := newTemplateCache(ui.Files, static)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// scs keeps the sessions in memory by default
+	app := &application{
+		store:          memory.NewStore(),
+		sessionManager: scs.New(),
+		templateCache:  templateCache,
+		static:         static,
+		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	app.metrics = newMetrics(app)
+
+	addAgent(t, app, "Dupont")
+
+	return app
+}
+
+// newTestRequest is a request with an empty session, for tests
+// calling a handler or a middleware without app.routes()
+func newTestRequest(t *testing.T, app *application, method, target string) *http.Request {
+	t.Helper()
+
+	r := httptest.NewRequest(method, target, nil)
+
+	ctx, err := app.sessionManager.Load(r.Context(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r.WithContext(ctx)
+}
+
+type testServer struct {
+	*httptest.Server
+
+	csrfToken string
+}
+
+// Redirects are not followed so the tests can check them.
+// Cookies are kept, like a browser
+func newTestServer(t *testing.T, h http.Handler) *testServer {
+	t.Helper()
+
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.Client().Jar = jar
+
+	ts.Client().CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return &testServer{Server: ts}
+}
+
+var csrfTokenRX = regexp.MustCompile(`<meta name="csrf-token" content="(.+?)">`)
+
+// token opens the home page once to get the CSRF token of the session
+func (ts *testServer) token(t *testing.T) string {
+	t.Helper()
+
+	if ts.csrfToken == "" {
+		_, _, body := ts.get(t, "/")
+
+		m := csrfTokenRX.FindStringSubmatch(body)
+		if m == nil {
+			t.Fatal("no CSRF token in the page")
+		}
+		ts.csrfToken = html.UnescapeString(m[1])
+	}
+
+	return ts.csrfToken
+}
+
+func (ts *testServer) do(t *testing.T, req *http.Request) (int, http.Header, string) {
+	t.Helper()
+
+	rs, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Body.Close()
+
+	body, err := io.ReadAll(rs.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rs.StatusCode, rs.Header, string(bytes.TrimSpace(body))
+}
+
+func (ts *testServer) get(t *testing.T, urlPath string) (int, http.Header, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+urlPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ts.do(t, req)
+}
+
+// postForm sends the CSRF token of the session
+// unless the form already has one
+func (ts *testServer) postForm(t *testing.T, urlPath string, form url.Values) (int, http.Header, string) {
+	t.Helper()
+
+	if form == nil {
+		form = url.Values{}
+	}
+	if !form.Has(csrfFormField) {
+		form.Set(csrfFormField, ts.token(t))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL+urlPath,
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return ts.do(t, req)
+}
+
+// Helpers to fill the in-memory store before a test
+
+func addSource(t *testing.T, app *application, name string) int {
+	t.Helper()
+
+	id, err := app.store.Sources().SourceInsert(context.Background(),
+		&database.Source{Name: name})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
+
+func addAgent(t *testing.T, app *application, name string) int {
+	t.Helper()
+
+	id, err := app.store.Agents().AgentInsert(context.Background(),
+		&database.Agent{Name: name, Active: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
+
+func addInfo(t *testing.T, app *application, sourceID int, material, status string) int {
+	t.Helper()
+
+	id, err := app.store.Infos().InfoInsert(context.Background(), &database.Info{
+		SourceID: sourceID,
+		Agent:    "Dupont",
+		Material: material,
+		Detail:   "Detail of " + material,
+		Priority: 1,
+		Status:   status,
+
+		ReporterID: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
+
+func assertStatus(t *testing.T, got, want int) {
+	t.Helper()
+
+	if got != want {
+		t.Errorf("got status %d; want %d", got, want)
+	}
+}
+
+func assertContains(t *testing.T, body, want string) {
+	t.Helper()
+
+	if !strings.Contains(body, want) {
+		t.Errorf("body does not contain %q", want)
+	}
+}
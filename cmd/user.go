@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"CURATOR/internal/validator"
+)
+
+// CURATOR has no accounts. The user can type their name in the
+// menu, it's kept like the language (session and cookie) and
+// written in the rows they delete (deleted_by)
+
+const (
+	userSessionKey = "user"
+	userCookie     = "user"
+)
+
+// currentUser returns the name given in the menu,
+// or the IP address of the user
+func (app *application) currentUser(r *http.Request) string {
+	if name := app.userName(r); name != "" {
+		return name
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// userName returns the name given in the menu or ""
+func (app *application) userName(r *http.Request) string {
+	name := app.sessionManager.GetString(r.Context(), userSessionKey)
+	if name != "" {
+		return name
+	}
+
+	return preferenceCookie(r, userCookie)
+}
+
+// userPost saves the name typed in the menu
+func (app *application) userPost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimSpace(r.PostForm.Get("user"))
+
+	if !validator.NotBlank(name) || !validator.MaxChars(name, 50) {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), userSessionKey, name)
+	setPreferenceCookie(w, userCookie, name)
+
+	http.Redirect(w, r, backURL(r), http.StatusSeeOther)
+}
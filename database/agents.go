@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// Agent reports and is assigned infos. An inactive agent
+// (left, retired...) keeps their infos but isn't offered on
+// the forms anymore
+type Agent struct {
+	ID      int
+	Name    string
+	Team    string
+	Contact string
+	Active  bool
+	Created time.Time
+
+	// Open infos (not archived) assigned to them, set by AgentList
+	Open int
+}
+
+// AgentModel is the PSQL AgentStore
+type AgentModel struct {
+	DB DBTX
+}
+
+// Every agent by name, with their workload
+func (m *AgentModel) AgentList(ctx context.Context) ([]*Agent, error) {
+	query := `
+SELECT a.id, a.name, a.team, a.contact, a.active, a.created,
+       COUNT(i.id)
+  FROM agent AS a
+       LEFT JOIN info AS i ON i.assignee_id = a.id
+                          AND i.deleted_at IS NULL
+                          AND i.status <> 'archived'
+  GROUP BY a.id
+  ORDER BY lower(a.name)
+`
+	rows, err := m.DB.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	agents := []*Agent{}
+
+	for rows.Next() {
+		aObj := &Agent{}
+
+		err = rows.Scan(&aObj.ID, &aObj.Name, &aObj.Team, &aObj.Contact,
+			&aObj.Active, &aObj.Created, &aObj.Open)
+		if err != nil {
+			return nil, err
+		}
+
+		agents = append(agents, aObj)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return agents, nil
+}
+
+func (m *AgentModel) AgentGet(ctx context.Context, id int) (*Agent, error) {
+	query := `
+SELECT id, name, team, contact, active, created
+  FROM agent
+    WHERE id = $1
+`
+	aObj := &Agent{}
+	err := m.DB.QueryRow(ctx, query, id).Scan(&aObj.ID, &aObj.Name,
+		&aObj.Team, &aObj.Contact, &aObj.Active, &aObj.Created)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return aObj, nil
+}
+
+func (m *AgentModel) AgentInsert(ctx context.Context, agent *Agent) (int, error) {
+	query := `
+INSERT INTO agent (name, team, contact, active, created)
+VALUES ($1, $2, $3, $4, $5)
+  RETURNING id
+`
+	err := m.DB.QueryRow(ctx, query, agent.Name, agent.Team, agent.Contact,
+		agent.Active, time.Now().UTC()).Scan(&agent.ID)
+	if err != nil {
+		return 0, uniqueViolation(err, "agent_name_idx", ErrDuplicateAgent)
+	}
+
+	return agent.ID, nil
+}
+
+// The infos they reported take the new name
+func (m *AgentModel) AgentUpdate(ctx context.Context, agent *Agent) error {
+	query := `
+UPDATE agent
+  SET name = $2, team = $3, contact = $4, active = $5
+    WHERE id = $1
+`
+	infos := `
+UPDATE info
+  SET agent = $2
+    WHERE reporter_id = $1
+`
+	return WithTx(ctx, m.DB, func(tx pgx.Tx) error {
+		res, err := tx.Exec(ctx, query, agent.ID, agent.Name, agent.Team,
+			agent.Contact, agent.Active)
+		if err != nil {
+			return uniqueViolation(err, "agent_name_idx", ErrDuplicateAgent)
+		}
+
+		// Nothing changed, the id doesn't exist
+		if res.RowsAffected() == 0 {
+			return ErrNoRecord
+		}
+
+		_, err = tx.Exec(ctx, infos, agent.ID, agent.Name)
+		return err
+	})
+}
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAgentModel(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	sources := &SourceModel{DB: db}
+	infos := &InfoModel{DB: db}
+	m := &AgentModel{DB: db}
+
+	dupont, err := m.AgentInsert(ctx, &Agent{Name: "Dupont", Active: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	martin, err := m.AgentInsert(ctx, &Agent{Name: "Martin", Team: "Nord", Active: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Names are unique whatever the case
+	_, err = m.AgentInsert(ctx, &Agent{Name: "DUPONT"})
+	if !errors.Is(err, ErrDuplicateAgent) {
+		t.Errorf("got %v; want ErrDuplicateAgent", err)
+	}
+
+	sID, err := sources.SourceInsert(ctx, &Source{Name: "Billancourt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := infos.InfoInsert(ctx, &Info{SourceID: sID, Agent: "Dupont",
+		Material: "Transfo 1", Detail: "Oil leak", Priority: 1, Status: "waiting",
+		ReporterID: dupont, AssigneeID: martin})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := infos.InfoGet(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ReporterID != dupont || info.AssigneeID != martin || info.Assignee != "Martin" {
+		t.Errorf("got %+v", info)
+	}
+
+	agents, err := m.AgentList(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(agents) != 2 || agents[0].Open != 0 || agents[1].Open != 1 {
+		t.Errorf("got %+v", agents)
+	}
+
+	for _, agentID := range []int{dupont, martin} {
+		list, err := infos.InfoListAgent(ctx, agentID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 1 || list[0].SourceName != "Billancourt" {
+			t.Errorf("agent %d: got %+v", agentID, list)
+		}
+	}
+
+	// The infos they reported take the new name
+	err = m.AgentUpdate(ctx, &Agent{ID: dupont, Name: "Jean Dupont"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, _ = infos.InfoGet(ctx, id); info.Agent != "Jean Dupont" {
+		t.Errorf("got %q", info.Agent)
+	}
+
+	err = m.AgentUpdate(ctx, &Agent{ID: 42, Name: "Nobody"})
+	if !errors.Is(err, ErrNoRecord) {
+		t.Errorf("got %v; want ErrNoRecord", err)
+	}
+}
+
+func TestInfoAssign(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	sources := &SourceModel{DB: db}
+	infos := &InfoModel{DB: db}
+	agents := &AgentModel{DB: db}
+
+	martin, err := agents.AgentInsert(ctx, &Agent{Name: "Martin", Team: "Nord", Active: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sID, err := sources.SourceInsert(ctx, &Source{Name: "Billancourt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	due := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	later, err := infos.InfoInsert(ctx, &Info{SourceID: sID, Agent: "Martin",
+		Material: "Transfo 1", Detail: "Oil leak", Priority: 1, Status: "waiting",
+		Due: due})
+	if err != nil {
+		t.Fatal(err)
+	}
+	urgent, err := infos.InfoInsert(ctx, &Info{SourceID: sID, Agent: "Martin",
+		Material: "Transfo 2", Detail: "Noise", Priority: 0, Status: "waiting"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = infos.InfoAssign(ctx, later, martin, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err = infos.InfoAssign(ctx, urgent, 0, "Nord"); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := infos.InfoGet(ctx, later)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.AssigneeID != martin || info.Status != "affected" || !info.Due.Equal(due) {
+		t.Errorf("got %+v", info)
+	}
+
+	list, err := infos.InfoListAssigned(ctx, martin, "Nord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].ID != urgent || list[1].ID != later {
+		t.Errorf("got %+v", list)
+	}
+
+	err = infos.InfoAssign(ctx, 42, martin, "")
+	if !errors.Is(err, ErrNoRecord) {
+		t.Errorf("got %v; want ErrNoRecord", err)
+	}
+}
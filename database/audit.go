@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// Actions written in the audit table
+const (
+	AuditSourceMerge = "source.merge"
+	AuditInfoMove    = "info.move"
+
+	// Another agent reported the same defect
+	AuditInfoReport = "info.report"
+
+	// The agent or the team of the info changed,
+	// Detail is "old → new"
+	AuditInfoAssign = "info.assign"
+)
+
+// AuditEntry is one action of a user. SourceID and
+// InfoID are 0 when they don't apply
+type AuditEntry struct {
+	ID       int
+	Created  time.Time
+	Actor    string
+	Action   string
+	SourceID int
+	InfoID   int
+	Detail   string
+}
+
+// Label is the English text of the action, translated in the UI
+func (e *AuditEntry) Label() string {
+	switch e.Action {
+	case AuditSourceMerge:
+		return "Source merged"
+	case AuditInfoMove:
+		return "Info moved"
+	case AuditInfoReport:
+		return "Reported again"
+	case AuditInfoAssign:
+		return "Assigned"
+	}
+	return e.Action
+}
+
+// AuditModel is the PSQL AuditStore
+type AuditModel struct {
+	DB DBTX
+}
+
+// Write an entry, Created is set here
+func (m *AuditModel) AuditInsert(ctx context.Context, e *AuditEntry) error {
+	query := `
+INSERT INTO audit (created, actor, action, source_id, info_id, detail)
+VALUES ($1, $2, $3, NULLIF($4, 0), NULLIF($5, 0), $6)
+  RETURNING id, created
+`
+	return m.DB.QueryRow(ctx, query, time.Now().UTC(), e.Actor, e.Action,
+		e.SourceID, e.InfoID, e.Detail).Scan(&e.ID, &e.Created)
+}
+
+// History of a source, the last entry first
+func (m *AuditModel) AuditList(ctx context.Context, sourceID int) ([]*AuditEntry, error) {
+	return m.list(ctx, auditBySource, sourceID)
+}
+
+// History of an info, the last entry first
+func (m *AuditModel) AuditListInfo(ctx context.Context, infoID int) ([]*AuditEntry, error) {
+	return m.list(ctx, auditByInfo, infoID)
+}
+
+const (
+	auditBySource = `
+SELECT id, created, actor, action,
+       COALESCE(source_id, 0), COALESCE(info_id, 0), detail
+  FROM audit
+    WHERE source_id = $1
+  ORDER BY created DESC, id DESC
+`
+	auditByInfo = `
+SELECT id, created, actor, action,
+       COALESCE(source_id, 0), COALESCE(info_id, 0), detail
+  FROM audit
+    WHERE info_id = $1
+  ORDER BY created DESC, id DESC
+`
+)
+
+// list runs auditBySource or auditByInfo
+func (m *AuditModel) list(ctx context.Context, query string, id int) ([]*AuditEntry, error) {
+	rows, err := m.DB.Query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*AuditEntry{}
+
+	for rows.Next() {
+		e := &AuditEntry{}
+
+		err = rows.Scan(&e.ID, &e.Created, &e.Actor, &e.Action,
+			&e.SourceID, &e.InfoID, &e.Detail)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// Feed is the secret link of an .ics feed, the calendar of an
+// agent or of a source (the other ID is 0). Anyone with the
+// token can read it, a new token revokes the old one
+type Feed struct {
+	Token    string
+	AgentID  int
+	SourceID int
+	Created  time.Time
+}
+
+// FeedModel is the PSQL FeedStore
+type FeedModel struct {
+	DB DBTX
+}
+
+// FeedGet returns the feed of token, ErrNoRecord when it
+// doesn't exist (or was renewed)
+func (m *FeedModel) FeedGet(ctx context.Context, token string) (*Feed, error) {
+	query := `
+SELECT token, COALESCE(agent_id, 0), COALESCE(source_id, 0), created
+  FROM calendar_feed
+    WHERE token = $1
+`
+	return m.scan(ctx, query, token)
+}
+
+// FeedFind returns the feed of the agent, or of the source
+// when agentID is 0. ErrNoRecord when there's none yet
+func (m *FeedModel) FeedFind(ctx context.Context, agentID, sourceID int) (*Feed, error) {
+	query := `
+SELECT token, COALESCE(agent_id, 0), COALESCE(source_id, 0), created
+  FROM calendar_feed
+    WHERE agent_id = NULLIF($1, 0) OR source_id = NULLIF($2, 0)
+`
+	return m.scan(ctx, query, agentID, sourceID)
+}
+
+func (m *FeedModel) scan(ctx context.Context, query string, args ...any) (*Feed, error) {
+	feed := &Feed{}
+	err := m.DB.QueryRow(ctx, query, args...).Scan(&feed.Token,
+		&feed.AgentID, &feed.SourceID, &feed.Created)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+// FeedRenew gives feed.Token to the agent or the source of
+// feed, in place of the token it had
+func (m *FeedModel) FeedRenew(ctx context.Context, feed *Feed) error {
+	// One feed per agent and per source (calendar_feed_*_idx)
+	target := "(agent_id) WHERE agent_id IS NOT NULL"
+	if feed.AgentID == 0 {
+		target = "(source_id) WHERE source_id IS NOT NULL"
+	}
+
+	query := `
+INSERT INTO calendar_feed (token, agent_id, source_id, created)
+  VALUES ($1, NULLIF($2, 0), NULLIF($3, 0), $4)
+  ON CONFLICT ` + target + `
+    DO UPDATE SET token = EXCLUDED.token, created = EXCLUDED.created
+`
+	feed.Created = time.Now().UTC()
+
+	_, err := m.DB.Exec(ctx, query, feed.Token, feed.AgentID,
+		feed.SourceID, feed.Created)
+	if err != nil {
+		// No such agent or source (foreign_key_violation)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrNoRecord
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInfoCalendar(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	sources := &SourceModel{DB: db}
+	agents := &AgentModel{DB: db}
+	m := &InfoModel{DB: db}
+
+	martin, err := agents.AgentInsert(ctx, &Agent{Name: "Martin", Team: "Nord", Active: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sID, err := sources.SourceInsert(ctx, &Source{Name: "A"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	june := func(day int) time.Time {
+		return time.Date(2024, time.June, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	for _, info := range []*Info{
+		{Due: june(28), Planned: june(10), AssigneeID: martin},
+		{Due: june(3), Team: "Nord"},
+		{Planned: june(30)},
+		{Due: june(12), Status: "archived"},
+		{},
+	} {
+		info.SourceID = sID
+		info.Agent, info.Material, info.Detail = "x", "x", "x"
+		if info.Status == "" {
+			info.Status = "waiting"
+		}
+		if _, err := m.InfoInsert(ctx, info); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		from, to time.Time
+		agentID  int
+		want     int
+	}{
+		{june(1), june(30), 0, 2},
+		{june(1), june(30).AddDate(0, 0, 1), 0, 3},
+		{june(10), june(11), 0, 1},
+		{june(1), june(30), martin, 2},
+	}
+
+	for _, tt := range tests {
+		infos, err := m.InfoCalendar(ctx, tt.from, tt.to, 0, tt.agentID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(infos) != tt.want {
+			t.Errorf("%s - %s, agent %d: got %d infos; want %d",
+				tt.from.Format("Jan 2"), tt.to.Format("Jan 2"),
+				tt.agentID, len(infos), tt.want)
+		}
+	}
+
+	info, err := m.InfoGet(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Planned.Equal(june(10)) {
+		t.Errorf("got planned %v", info.Planned)
+	}
+}
+
+func TestFeedModel(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	agents := &AgentModel{DB: db}
+	m := &FeedModel{DB: db}
+
+	martin, err := agents.AgentInsert(ctx, &Agent{Name: "Martin", Active: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{"first", "second"} {
+		if err := m.FeedRenew(ctx, &Feed{Token: token, AgentID: martin}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The new token replaced the old one
+	if _, err = m.FeedGet(ctx, "first"); !errors.Is(err, ErrNoRecord) {
+		t.Errorf("got %v; want ErrNoRecord", err)
+	}
+	feed, err := m.FeedFind(ctx, martin, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Token != "second" || feed.AgentID != martin || feed.SourceID != 0 {
+		t.Errorf("got %+v", feed)
+	}
+
+	err = m.FeedRenew(ctx, &Feed{Token: "third", AgentID: 42})
+	if !errors.Is(err, ErrNoRecord) {
+		t.Errorf("got %v; want ErrNoRecord", err)
+	}
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// DBTX is what every function of this package needs to talk to PSQL.
+// *pgxpool.Pool, *pgxpool.Conn and pgx.Tx all satisfy it, so the
+// same function works inside or outside a transaction
+type DBTX interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// WithTx runs fn inside a transaction.
+// If fn returns an error (or panics) everything is rolled back,
+// otherwise it's committed. When db is already a transaction
+// a savepoint is used
+func WithTx(ctx context.Context, db DBTX, fn func(tx pgx.Tx) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Does nothing once the transaction is committed
+	defer tx.Rollback(ctx)
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWithTxRollback(t *testing.T) {
+	ctx := context.Background()
+	store := NewPGStore(newTestDB(t))
+
+	errStop := errors.New("stop")
+
+	err := store.WithTx(ctx, func(tx Store) error {
+		_, err := tx.Sources().SourceInsert(ctx, &Source{Name: "A"})
+		if err != nil {
+			return err
+		}
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("got %v; want errStop", err)
+	}
+
+	sources, err := store.Sources().MenuSource(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 0 {
+		t.Errorf("got %d sources; want 0 after rollback", len(sources))
+	}
+}
+
+func TestWithTxCommit(t *testing.T) {
+	ctx := context.Background()
+	store := NewPGStore(newTestDB(t))
+
+	err := store.WithTx(ctx, func(tx Store) error {
+		_, err := tx.Sources().SourceInsert(ctx, &Source{Name: "A"})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources, err := store.Sources().MenuSource(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 1 {
+		t.Errorf("got %d sources; want 1", len(sources))
+	}
+}
+
+func TestMigrateTwice(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := Migrate(context.Background(), db); err != nil {
+		t.Errorf("second Migrate: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// Kinds of equipment, in the order of the forms
+var EquipmentKinds = []string{"bay", "transformer", "breaker", "other"}
+
+// Equipment is a managed piece of a source. ParentID is 0
+// at the top of the tree
+type Equipment struct {
+	ID       int
+	SourceID int
+	ParentID int
+	Kind     string
+	Name     string
+	Created  time.Time
+
+	// Level in the tree, set by EquipmentList
+	Depth int
+}
+
+// Subtree returns the IDs of id and of everything under it.
+// list is the equipment of one source
+func Subtree(list []*Equipment, id int) map[int]bool {
+	ids := map[int]bool{id: true}
+
+	// The parents come first in EquipmentList, but any
+	// order works by looping until nothing is added
+	for added := true; added; {
+		added = false
+		for _, e := range list {
+			if !ids[e.ID] && ids[e.ParentID] {
+				ids[e.ID] = true
+				added = true
+			}
+		}
+	}
+
+	return ids
+}
+
+// EquipmentModel is the PSQL EquipmentStore
+type EquipmentModel struct {
+	DB DBTX
+}
+
+// equipmentTree is the equipment of the source $1 with its
+// depth and its path: ordered by path, every parent is
+// followed by its children, by name
+const equipmentTree = `
+WITH RECURSIVE tree AS (
+  SELECT id, source_id, parent_id, kind, name, created,
+         0 AS depth, ARRAY[lower(name), id::TEXT] AS path
+    FROM equipment
+      WHERE source_id = $1 AND parent_id IS NULL
+  UNION ALL
+  SELECT e.id, e.source_id, e.parent_id, e.kind, e.name, e.created,
+         t.depth + 1, t.path || ARRAY[lower(e.name), e.id::TEXT]
+    FROM equipment AS e
+         JOIN tree AS t ON e.parent_id = t.id
+)`
+
+// Equipment of a source as a tree
+func (m *EquipmentModel) EquipmentList(ctx context.Context, sourceID int) ([]*Equipment, error) {
+	query := equipmentTree + `
+SELECT id, source_id, COALESCE(parent_id, 0), kind, name, created, depth
+  FROM tree
+  ORDER BY path
+`
+	rows, err := m.DB.Query(ctx, query, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*Equipment{}
+
+	for rows.Next() {
+		e := &Equipment{}
+
+		err = rows.Scan(&e.ID, &e.SourceID, &e.ParentID, &e.Kind,
+			&e.Name, &e.Created, &e.Depth)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (m *EquipmentModel) EquipmentGet(ctx context.Context, id int) (*Equipment, error) {
+	query := `
+SELECT id, source_id, COALESCE(parent_id, 0), kind, name, created
+  FROM equipment
+    WHERE id = $1
+`
+	e := &Equipment{}
+	err := m.DB.QueryRow(ctx, query, id).Scan(&e.ID, &e.SourceID,
+		&e.ParentID, &e.Kind, &e.Name, &e.Created)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (m *EquipmentModel) EquipmentInsert(ctx context.Context, e *Equipment) (int, error) {
+	query := `
+INSERT INTO equipment (source_id, parent_id, kind, name, created)
+VALUES ($1, NULLIF($2, 0), $3, $4, $5)
+  RETURNING id
+`
+	err := m.DB.QueryRow(ctx, query, e.SourceID, e.ParentID, e.Kind,
+		e.Name, time.Now().UTC()).Scan(&e.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return e.ID, nil
+}
+
+// The handler checks the parent is in the same source
+// and isn't under the equipment itself
+func (m *EquipmentModel) EquipmentUpdate(ctx context.Context, e *Equipment) error {
+	query := `
+UPDATE equipment
+  SET parent_id = NULLIF($2, 0), kind = $3, name = $4
+    WHERE id = $1
+`
+	tag, err := m.DB.Exec(ctx, query, e.ID, e.ParentID, e.Kind, e.Name)
+	if err != nil {
+		return err
+	}
+
+	// Nothing changed, the id doesn't exist
+	if tag.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// The children go up to the parent of the equipment,
+// its infos keep their material without equipment
+func (m *EquipmentModel) EquipmentDelete(ctx context.Context, id int) error {
+	children := `
+UPDATE equipment
+  SET parent_id = (SELECT parent_id FROM equipment WHERE id = $1)
+    WHERE parent_id = $1
+`
+	remove := `
+DELETE FROM equipment
+  WHERE id = $1
+`
+	return WithTx(ctx, m.DB, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, children, id); err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, remove, id)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrNoRecord
+		}
+
+		return nil
+	})
+}
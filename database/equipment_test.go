@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSubtree(t *testing.T) {
+	list := []*Equipment{
+		{ID: 1}, {ID: 2, ParentID: 1}, {ID: 3, ParentID: 2},
+		{ID: 4}, {ID: 5, ParentID: 4},
+	}
+
+	got := Subtree(list, 1)
+	if len(got) != 3 || !got[1] || !got[2] || !got[3] {
+		t.Errorf("got %v; want 1, 2 and 3", got)
+	}
+	if got = Subtree(list, 5); len(got) != 1 || !got[5] {
+		t.Errorf("got %v; want 5", got)
+	}
+}
+
+func TestEquipmentModel(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	sources := &SourceModel{DB: db}
+	infos := &InfoModel{DB: db}
+	m := &EquipmentModel{DB: db}
+
+	sID, err := sources.SourceInsert(ctx, &Source{Name: "Billancourt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bay, err := m.EquipmentInsert(ctx, &Equipment{SourceID: sID, Kind: "bay", Name: "Bay 1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	breaker, err := m.EquipmentInsert(ctx, &Equipment{SourceID: sID, ParentID: bay,
+		Kind: "breaker", Name: "Breaker 3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.EquipmentInsert(ctx, &Equipment{SourceID: sID, Kind: "bay", Name: "Another bay"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Parents first, then their children, by name
+	list, err := m.EquipmentList(ctx, sID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || list[0].Name != "Another bay" || list[1].ID != bay ||
+		list[2].ID != breaker || list[2].Depth != 1 || list[2].ParentID != bay {
+		t.Fatalf("got %+v %+v %+v", list[0], list[1], list[2])
+	}
+
+	iID, err := infos.InfoInsert(ctx, &Info{SourceID: sID, Agent: "Dupont",
+		Material: "Breaker 3", Detail: "Stuck", Priority: 1, Status: "waiting",
+		EquipmentID: breaker})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = infos.InfoInsert(ctx, &Info{SourceID: sID, Agent: "Dupont",
+		Material: "Fence", Detail: "Hole", Priority: 0, Status: "waiting"}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := infos.InfoGet(ctx, iID)
+	if err != nil || info.EquipmentID != breaker || info.EquipmentName != "Breaker 3" {
+		t.Errorf("got %+v, %v", info, err)
+	}
+
+	// Grouped by equipment, even before a higher priority
+	byEquipment, _ := infos.InfoList(ctx, sID)
+	if len(byEquipment) != 2 || byEquipment[0].ID != iID {
+		t.Errorf("got %+v", byEquipment)
+	}
+
+	history, err := infos.InfoListEquipment(ctx, []int{bay, breaker})
+	if err != nil || len(history) != 1 || history[0].ID != iID {
+		t.Errorf("got %+v, %v", history, err)
+	}
+
+	// The breaker goes up, the info loses its equipment
+	if err = m.EquipmentDelete(ctx, bay); err != nil {
+		t.Fatal(err)
+	}
+	e, err := m.EquipmentGet(ctx, breaker)
+	if err != nil || e.ParentID != 0 {
+		t.Errorf("got %+v, %v", e, err)
+	}
+
+	if err = m.EquipmentDelete(ctx, breaker); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ = infos.InfoGet(ctx, iID); info.EquipmentID != 0 {
+		t.Errorf("got equipment %d; want 0", info.EquipmentID)
+	}
+}
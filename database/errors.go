@@ -9,4 +9,21 @@ import (
 // with PSQL records
 var (
 	ErrNoRecord = errors.New("models: No matching record found")
+
+	// A source can't be deleted while open infos (not archived)
+	// are attached to it
+	ErrSourceNotEmpty = errors.New("models: Source still has infos")
+
+	// A source can't be merged into itself
+	ErrSameSource = errors.New("models: Same source")
+
+	// Another source has the same name, once normalised
+	// (see SourceKey)
+	ErrDuplicateSource = errors.New("models: Duplicate source name")
+
+	// Tag names are unique, whatever the case
+	ErrDuplicateTag = errors.New("models: Duplicate tag name")
+
+	// Agent names are unique, whatever the case
+	ErrDuplicateAgent = errors.New("models: Duplicate agent name")
 )
@@ -6,9 +6,11 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v4"
-	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+// Status of an info, in the order of the forms and the board
+var InfoStatuses = []string{"waiting", "affected", "done", "archived"}
+
 type Info struct {
 	ID       int // primary key
 	Priority int
@@ -19,50 +21,103 @@ type Info struct {
 	Estimate string
 	Status   string
 
+	// 0 when the info isn't linked to an equipment.
+	// EquipmentName is filled by InfoGet and InfoList
+	EquipmentID   int
+	EquipmentName string
+
+	// Agents of the directory, 0 when unknown. Agent is the
+	// name of the reporter, Assignee is filled by InfoGet,
+	// InfoList and InfoListAgent
+	ReporterID int
+	AssigneeID int
+	Assignee   string
+
+	// Team assigned, "" for none. Due and Planned (the day of
+	// the intervention) are the zero time.Time when not set
+	Team    string
+	Due     time.Time
+	Planned time.Time
+
+	// Updated is ZeroTime until the first update
 	ZeroTime time.Time
 	Created  time.Time
 	Updated  time.Time
+
+	// Set when the info is in the trash.
+	// SourceName is only filled by InfoTrash and InfoSearch
+	DeletedAt  time.Time
+	DeletedBy  string
+	SourceName string
 }
 
-// It sends data to DB
-func (i *Info) Insert(id int, conn *pgxpool.Conn) (int, error) {
-	ctx := context.Background()
+// ZeroTime is the Updated of an info never updated,
+// it's the zero time.Time (IsZero is true)
+var ZeroTime = time.Date(0001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// dueParam sends NULL for an info without due (or planned) date
+func dueParam(due time.Time) *time.Time {
+	if due.IsZero() {
+		return nil
+	}
+	return &due
+}
+
+// InfoModel is the PSQL InfoStore
+type InfoModel struct {
+	DB DBTX
+}
+
+// It sends data to DB. info.SourceID must be set
+func (m *InfoModel) InfoInsert(ctx context.Context, info *Info) (int, error) {
 	query := `
 INSERT INTO info
     (source_id, agent, material, details, priority,
-	estimate, status, created)
+	estimate, status, created, equipment_id,
+	reporter_id, assignee_id, team, due, planned)
 	  VALUES
-	    ($1, $2, $3, $4, $5, $6, $7, $8)
+	    ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, 0),
+	     NULLIF($10, 0), NULLIF($11, 0), $12, $13, $14)
 		RETURNING id;
 `
-	err := conn.QueryRow(ctx, query, id, i.Agent,
-		i.Material, i.Detail, i.Priority,
-		i.Estimate, i.Status,
-		time.Now().UTC()).Scan(&i.ID)
+	err := m.DB.QueryRow(ctx, query, info.SourceID, info.Agent,
+		info.Material, info.Detail, info.Priority,
+		info.Estimate, info.Status,
+		time.Now().UTC(), info.EquipmentID,
+		info.ReporterID, info.AssigneeID,
+		info.Team, dueParam(info.Due),
+		dueParam(info.Planned)).Scan(&info.ID)
 	if err != nil {
 		return -1, err
 	}
 
-	return i.ID, nil
+	return info.ID, nil
 }
 
 // Retrieve data from a choosen info
-func (i *Info) InfoGet(id int, conn *pgxpool.Conn) (*Info, error) {
-	ctx := context.Background()
+func (m *InfoModel) InfoGet(ctx context.Context, id int) (*Info, error) {
 	query := `
-SELECT id, agent, material, priority, details, estimate,
-       source_id, created, updated, status
-FROM info
-  WHERE id = $1
+SELECT i.id, i.agent, i.material, i.priority, i.details, i.estimate,
+       i.source_id, i.created, i.updated, i.status,
+       COALESCE(i.equipment_id, 0), COALESCE(e.name, ''),
+       COALESCE(i.reporter_id, 0), COALESCE(i.assignee_id, 0),
+       COALESCE(a.name, ''), i.team, i.due, i.planned
+FROM info AS i
+     LEFT JOIN equipment AS e ON e.id = i.equipment_id
+     LEFT JOIN agent AS a ON a.id = i.assignee_id
+  WHERE i.id = $1 AND i.deleted_at IS NULL
 `
 	var estimate *string
-	var updated *time.Time
+	var updated, due, planned *time.Time
 
 	iObj := &Info{}
-	err := conn.QueryRow(ctx, query, id).Scan(&iObj.ID, &iObj.Agent,
+	err := m.DB.QueryRow(ctx, query, id).Scan(&iObj.ID, &iObj.Agent,
 		&iObj.Material, &iObj.Priority, &iObj.Detail,
 		&estimate, &iObj.SourceID,
-		&iObj.Created, &updated, &iObj.Status)
+		&iObj.Created, &updated, &iObj.Status,
+		&iObj.EquipmentID, &iObj.EquipmentName,
+		&iObj.ReporterID, &iObj.AssigneeID, &iObj.Assignee,
+		&iObj.Team, &due, &planned)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNoRecord
@@ -71,8 +126,7 @@ FROM info
 		}
 	}
 
-	iObj.ZeroTime = time.Date(0001, time.January,
-		1, 0, 0, 0, 0, time.UTC)
+	iObj.ZeroTime = ZeroTime
 
 	if updated != nil {
 		iObj.Updated = *updated
@@ -82,25 +136,40 @@ FROM info
 		iObj.Estimate = *estimate
 	}
 
+	if due != nil {
+		iObj.Due = *due
+	}
+	if planned != nil {
+		iObj.Planned = *planned
+	}
+
 	return iObj, nil
 }
 
-// Fetch a list with the minimum data from a info.
-// It's specialy used within source view web page
-func (i *Info) InfoList(id int, conn *pgxpool.Conn) ([]*Info, error) {
-	ctx := context.Background()
-	query := `
-SELECT id,
-       material,
-       created,
-       status,
-       source_id,
-       priority
-FROM info
-  WHERE source_id = $1
-  ORDER BY priority ASC
+// Infos of a source grouped by equipment, in the order of
+// EquipmentList. The infos without equipment come last
+func (m *InfoModel) InfoList(ctx context.Context, id int) ([]*Info, error) {
+	query := equipmentTree + `
+SELECT i.id,
+       i.material,
+       i.created,
+       i.updated,
+       i.status,
+       i.source_id,
+       i.priority,
+       COALESCE(i.equipment_id, 0),
+       COALESCE(t.name, ''),
+       COALESCE(i.assignee_id, 0),
+       COALESCE(a.name, ''),
+       i.team,
+       i.due
+FROM info AS i
+     LEFT JOIN tree AS t ON t.id = i.equipment_id
+     LEFT JOIN agent AS a ON a.id = i.assignee_id
+  WHERE i.source_id = $1 AND i.deleted_at IS NULL
+  ORDER BY t.path NULLS LAST, i.priority ASC, i.id
 `
-	rows, err := conn.Query(ctx, query, id)
+	rows, err := m.DB.Query(ctx, query, id)
 	if err != nil {
 		return nil, err
 	}
@@ -109,15 +178,26 @@ FROM info
 	infos := []*Info{}
 
 	for rows.Next() {
-		iObj := &Info{}
+		var updated, due *time.Time
+		iObj := &Info{ZeroTime: ZeroTime}
 
 		err = rows.Scan(&iObj.ID, &iObj.Material,
-			&iObj.Created, &iObj.Status,
-			&iObj.SourceID, &iObj.Priority)
+			&iObj.Created, &updated, &iObj.Status,
+			&iObj.SourceID, &iObj.Priority,
+			&iObj.EquipmentID, &iObj.EquipmentName,
+			&iObj.AssigneeID, &iObj.Assignee,
+			&iObj.Team, &due)
 		if err != nil {
 			return nil, err
 		}
 
+		if updated != nil {
+			iObj.Updated = *updated
+		}
+		if due != nil {
+			iObj.Due = *due
+		}
+
 		infos = append(infos, iObj)
 	}
 
@@ -128,35 +208,556 @@ FROM info
 	return infos, nil
 }
 
-func (i *Info) InfoDelete(id int, conn *pgxpool.Conn) error {
-	ctx := context.Background()
+// SearchLimit is the most infos InfoSearch returns
+const SearchLimit = 200
+
+// Infos of every source whose material or detail contains q
+// (any case), with the tag tagID when it's not 0. The last first
+func (m *InfoModel) InfoSearch(ctx context.Context, q string, tagID int) ([]*Info, error) {
 	query := `
-DELETE FROM info
-  WHERE id = $1
+SELECT i.id, i.material, i.details, i.status, i.priority,
+       i.source_id, s.name, i.created
+  FROM info AS i
+       JOIN source AS s ON s.id = i.source_id
+                       AND s.deleted_at IS NULL
+    WHERE i.deleted_at IS NULL
+      AND ($1 = '' OR strpos(lower(i.material), lower($1)) > 0
+                   OR strpos(lower(i.details), lower($1)) > 0)
+      AND ($2 = 0 OR EXISTS (SELECT 1 FROM info_tag AS it
+                               WHERE it.info_id = i.id AND it.tag_id = $2))
+  ORDER BY i.created DESC, i.id DESC
+  LIMIT $3
+`
+	rows, err := m.DB.Query(ctx, query, q, tagID, SearchLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	infos := []*Info{}
+
+	for rows.Next() {
+		iObj := &Info{ZeroTime: ZeroTime}
+
+		err = rows.Scan(&iObj.ID, &iObj.Material, &iObj.Detail,
+			&iObj.Status, &iObj.Priority, &iObj.SourceID,
+			&iObj.SourceName, &iObj.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		infos = append(infos, iObj)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// Open infos (not archived) reported by or assigned to the
+// agent, the most urgent first: their workload
+func (m *InfoModel) InfoListAgent(ctx context.Context, agentID int) ([]*Info, error) {
+	return m.listInfos(ctx, infosOfAgent, agentID)
+}
+
+// Open infos assigned to the agent, or to their team when no
+// agent is: the "My infos" queue. By priority, then due date
+func (m *InfoModel) InfoListAssigned(ctx context.Context, agentID int, team string) ([]*Info, error) {
+	return m.listInfos(ctx, infosAssigned, agentID, team)
+}
+
+// Infos of the board (/board), archived ones only when asked.
+// sourceID and agentID filter them when not 0, the agent
+// reported or is assigned to the info
+func (m *InfoModel) InfoBoard(ctx context.Context, sourceID, agentID int, archived bool) ([]*Info, error) {
+	return m.listInfos(ctx, infosBoard, sourceID, agentID, archived)
+}
+
+// Infos due or planned from the day from to the day to (excluded),
+// archived ones aside: the calendar (/calendar) and its .ics feeds.
+// sourceID and agentID filter them when not 0, an agent has the
+// infos of their queue ("My infos")
+func (m *InfoModel) InfoCalendar(ctx context.Context, from, to time.Time, sourceID, agentID int) ([]*Info, error) {
+	query := `
+SELECT i.id, i.agent, i.material, i.details, i.priority, i.status,
+       i.source_id, s.name, i.created, i.updated,
+       COALESCE(i.assignee_id, 0), COALESCE(a.name, ''),
+       i.team, i.due, i.planned
+  FROM info AS i
+       JOIN source AS s ON s.id = i.source_id
+                       AND s.deleted_at IS NULL
+       LEFT JOIN agent AS a ON a.id = i.assignee_id
+    WHERE i.deleted_at IS NULL AND i.status <> 'archived'
+      AND ((i.due >= $1::date AND i.due < $2::date)
+        OR (i.planned >= $1::date AND i.planned < $2::date))
+      AND ($3 = 0 OR i.source_id = $3)
+      AND ($4 = 0 OR i.assignee_id = $4
+        OR (i.assignee_id IS NULL AND i.team <> ''
+            AND i.team = (SELECT team FROM agent WHERE id = $4)))
+  ORDER BY i.priority ASC, i.id
+`
+	rows, err := m.DB.Query(ctx, query, from, to, sourceID, agentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	infos := []*Info{}
+
+	for rows.Next() {
+		var updated, due, planned *time.Time
+		iObj := &Info{ZeroTime: ZeroTime}
+
+		err = rows.Scan(&iObj.ID, &iObj.Agent, &iObj.Material,
+			&iObj.Detail, &iObj.Priority, &iObj.Status,
+			&iObj.SourceID, &iObj.SourceName, &iObj.Created, &updated,
+			&iObj.AssigneeID, &iObj.Assignee,
+			&iObj.Team, &due, &planned)
+		if err != nil {
+			return nil, err
+		}
+
+		if updated != nil {
+			iObj.Updated = *updated
+		}
+		if due != nil {
+			iObj.Due = *due
+		}
+		if planned != nil {
+			iObj.Planned = *planned
+		}
+
+		infos = append(infos, iObj)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// infoFilter is the WHERE of listInfos, one of the constants
+// below: the values only go in args
+type infoFilter string
+
+const (
+	// $1 agent
+	infosOfAgent infoFilter = `i.status <> 'archived'
+      AND (i.reporter_id = $1 OR i.assignee_id = $1)`
+
+	// $1 agent, $2 team
+	infosAssigned infoFilter = `i.status <> 'archived'
+      AND (i.assignee_id = $1
+        OR (i.assignee_id IS NULL AND i.team <> '' AND i.team = $2))`
+
+	// $1 source, $2 agent, $3 archived too
+	infosBoard infoFilter = `($1 = 0 OR i.source_id = $1)
+      AND ($2 = 0 OR i.reporter_id = $2 OR i.assignee_id = $2)
+      AND ($3 OR i.status <> 'archived')`
+)
+
+func (m *InfoModel) listInfos(ctx context.Context, where infoFilter, args ...any) ([]*Info, error) {
+	query := `
+SELECT i.id, i.agent, i.material, i.priority, i.status,
+       i.source_id, s.name, i.created,
+       COALESCE(i.reporter_id, 0), COALESCE(i.assignee_id, 0),
+       COALESCE(a.name, ''), i.team, i.due
+  FROM info AS i
+       JOIN source AS s ON s.id = i.source_id
+                       AND s.deleted_at IS NULL
+       LEFT JOIN agent AS a ON a.id = i.assignee_id
+    WHERE i.deleted_at IS NULL AND ` + string(where) + `
+  ORDER BY i.priority ASC, i.due ASC NULLS LAST, i.created, i.id
+`
+	rows, err := m.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	infos := []*Info{}
+
+	for rows.Next() {
+		var due *time.Time
+		iObj := &Info{ZeroTime: ZeroTime}
+
+		err = rows.Scan(&iObj.ID, &iObj.Agent, &iObj.Material,
+			&iObj.Priority, &iObj.Status, &iObj.SourceID,
+			&iObj.SourceName, &iObj.Created,
+			&iObj.ReporterID, &iObj.AssigneeID, &iObj.Assignee,
+			&iObj.Team, &due)
+		if err != nil {
+			return nil, err
+		}
+
+		if due != nil {
+			iObj.Due = *due
+		}
+
+		infos = append(infos, iObj)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// Every info of the equipment given (archived too),
+// the last created first: the history of a piece of equipment
+func (m *InfoModel) InfoListEquipment(ctx context.Context, equipmentIDs []int) ([]*Info, error) {
+	query := `
+SELECT i.id, i.agent, i.material, i.details, i.priority, i.status,
+       i.source_id, i.created, i.updated, i.equipment_id, e.name
+  FROM info AS i
+       JOIN equipment AS e ON e.id = i.equipment_id
+    WHERE i.equipment_id = ANY($1) AND i.deleted_at IS NULL
+  ORDER BY i.created DESC, i.id DESC
+`
+	rows, err := m.DB.Query(ctx, query, equipmentIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	infos := []*Info{}
+
+	for rows.Next() {
+		var updated *time.Time
+		iObj := &Info{ZeroTime: ZeroTime}
+
+		err = rows.Scan(&iObj.ID, &iObj.Agent, &iObj.Material,
+			&iObj.Detail, &iObj.Priority, &iObj.Status, &iObj.SourceID,
+			&iObj.Created, &updated, &iObj.EquipmentID, &iObj.EquipmentName)
+		if err != nil {
+			return nil, err
+		}
+
+		if updated != nil {
+			iObj.Updated = *updated
+		}
+
+		infos = append(infos, iObj)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// Open infos (not archived) of the source whose material and
+// detail both look like the ones given: maybe the same defect
+// reported twice. The closest first
+func (m *InfoModel) InfoSimilar(ctx context.Context, sourceID int, material, detail string) ([]*Info, error) {
+	query := `
+SELECT id, agent, material, details, priority, status, created
+  FROM (SELECT *,
+               similarity(source_key(material), source_key($2)) AS m,
+               similarity(source_key(details), source_key($3)) AS d
+          FROM info
+            WHERE source_id = $1 AND deleted_at IS NULL
+              AND status <> 'archived') AS i
+  WHERE m >= $4 AND d >= $4
+  ORDER BY m + d DESC, id
+  LIMIT 5
+`
+	rows, err := m.DB.Query(ctx, query, sourceID, material, detail,
+		SimilarityThreshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	infos := []*Info{}
+
+	for rows.Next() {
+		iObj := &Info{SourceID: sourceID, ZeroTime: ZeroTime}
+
+		err = rows.Scan(&iObj.ID, &iObj.Agent, &iObj.Material,
+			&iObj.Detail, &iObj.Priority, &iObj.Status, &iObj.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		infos = append(infos, iObj)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// Move the info to the trash, by is who deleted it
+func (m *InfoModel) InfoDelete(ctx context.Context, id int, by string) error {
+	query := `
+UPDATE info
+  SET deleted_at = $2, deleted_by = $3
+    WHERE id = $1 AND deleted_at IS NULL
+`
+	tag, err := m.DB.Exec(ctx, query, id, time.Now().UTC(), by)
+	if err != nil {
+		return err
+	}
+
+	// Nothing changed, the id doesn't exist
+	if tag.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// Assign the info to the agent (0 for none) and/or the team.
+// A waiting info becomes affected when someone has it
+func (m *InfoModel) InfoAssign(ctx context.Context, id, assigneeID int, team string) error {
+	query := `
+UPDATE info
+  SET assignee_id = NULLIF($2, 0), team = $3, updated = $4,
+      status = CASE WHEN status = 'waiting' AND (NULLIF($2, 0) IS NOT NULL OR $3 <> '')
+                    THEN 'affected' ELSE status END
+    WHERE id = $1 AND deleted_at IS NULL
 `
-	_, err := conn.Exec(ctx, query, id)
+	tag, err := m.DB.Exec(ctx, query, id, assigneeID, team, time.Now().UTC())
 	if err != nil {
 		return err
 	}
 
+	// Nothing changed, the id doesn't exist
+	if tag.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
 	return nil
 }
 
-// info update
-func (i *Info) InfoUpdate(id int, conn *pgxpool.Conn) error {
-	ctx := context.Background()
+// info update, info.ID is the row updated
+func (m *InfoModel) InfoUpdate(ctx context.Context, info *Info) error {
 	query := `
 UPDATE info
 SET agent = $1, material = $2, priority = $3, details = $4,
-	estimate = $5, updated = $6, status = $7
-WHERE id = $8
+	estimate = $5, updated = $6, status = $7, equipment_id = NULLIF($9, 0),
+	reporter_id = NULLIF($10, 0), assignee_id = NULLIF($11, 0),
+	team = $12, due = $13, planned = $14
+WHERE id = $8 AND deleted_at IS NULL
+`
+	tag, err := m.DB.Exec(ctx, query, info.Agent, info.Material,
+		info.Priority, info.Detail, info.Estimate,
+		time.Now().UTC(), info.Status, info.ID, info.EquipmentID,
+		info.ReporterID, info.AssigneeID, info.Team, dueParam(info.Due),
+		dueParam(info.Planned))
+	if err != nil {
+		return err
+	}
+
+	// Nothing changed, the id doesn't exist
+	if tag.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// Count open infos (not archived) grouped by status.
+// It's used by the metrics collector
+func (m *InfoModel) StatusCount(ctx context.Context) (map[string]int, error) {
+	query := `
+SELECT status, COUNT(*)
+FROM info
+  WHERE status <> 'archived' AND deleted_at IS NULL
+  GROUP BY status
+`
+	rows, err := m.DB.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+
+	for rows.Next() {
+		var status string
+		var count int
+
+		err = rows.Scan(&status, &count)
+		if err != nil {
+			return nil, err
+		}
+
+		counts[status] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// Move one info to another source
+func (m *InfoModel) InfoMove(ctx context.Context, id, sourceID int) error {
+	query := `
+UPDATE info
+  SET source_id = $2, updated = $3, equipment_id = NULL
+    WHERE id = $1 AND deleted_at IS NULL
+`
+	tag, err := m.DB.Exec(ctx, query, id, sourceID, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	// Nothing changed, the id doesn't exist
+	if tag.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// Move the infos of a source (archived ones too,
+// not the ones in the trash) to another. Returns how many moved
+func (m *InfoModel) InfoMoveAll(ctx context.Context, fromID, toID int) (int, error) {
+	query := `
+UPDATE info
+  SET source_id = $2, updated = $3, equipment_id = NULL
+    WHERE source_id = $1 AND deleted_at IS NULL
 `
-	_, err := conn.Exec(ctx, query, i.Agent, i.Material,
-		i.Priority, i.Detail, i.Estimate,
-		time.Now().UTC(), i.Status, id)
+	tag, err := m.DB.Exec(ctx, query, fromID, toID, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// Archive the open infos of a source. Returns how many changed
+func (m *InfoModel) InfoArchiveAll(ctx context.Context, sourceID int) (int, error) {
+	query := `
+UPDATE info
+  SET status = 'archived', updated = $2
+    WHERE source_id = $1 AND deleted_at IS NULL
+      AND status <> 'archived'
+`
+	tag, err := m.DB.Exec(ctx, query, sourceID, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+//
+// Trash
+//
+
+// Infos in the trash with the name of their source,
+// the last deleted first
+func (m *InfoModel) InfoTrash(ctx context.Context) ([]*Info, error) {
+	query := `
+SELECT i.id, i.material, i.status, i.priority, i.source_id,
+       s.name, i.created, i.deleted_at, i.deleted_by
+  FROM info AS i
+       JOIN source AS s ON s.id = i.source_id
+    WHERE i.deleted_at IS NOT NULL
+  ORDER BY i.deleted_at DESC
+`
+	rows, err := m.DB.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	infos := []*Info{}
+
+	for rows.Next() {
+		iObj := &Info{ZeroTime: ZeroTime}
+
+		err = rows.Scan(&iObj.ID, &iObj.Material, &iObj.Status,
+			&iObj.Priority, &iObj.SourceID, &iObj.SourceName,
+			&iObj.Created, &iObj.DeletedAt, &iObj.DeletedBy)
+		if err != nil {
+			return nil, err
+		}
+
+		infos = append(infos, iObj)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// Take the info out of the trash. If its source is
+// in the trash too, it's restored with it
+func (m *InfoModel) InfoRestore(ctx context.Context, id int) error {
+	query := `
+WITH i AS (
+  UPDATE info
+    SET deleted_at = NULL, deleted_by = ''
+      WHERE id = $1 AND deleted_at IS NOT NULL
+  RETURNING source_id
+), s AS (
+  UPDATE source
+    SET deleted_at = NULL, deleted_by = ''
+      WHERE id IN (SELECT source_id FROM i) AND deleted_at IS NOT NULL
+)
+SELECT COUNT(*) FROM i
+`
+	var count int
+
+	err := m.DB.QueryRow(ctx, query, id).Scan(&count)
+	if err != nil {
+		// Its source came back with a name taken meanwhile
+		return duplicateSource(err)
+	}
+
+	// Not in the trash
+	if count == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// Delete for good an info of the trash
+func (m *InfoModel) InfoPurge(ctx context.Context, id int) error {
+	query := `
+DELETE FROM info
+  WHERE id = $1 AND deleted_at IS NOT NULL
+`
+	tag, err := m.DB.Exec(ctx, query, id)
 	if err != nil {
 		return err
 	}
 
+	// Not in the trash
+	if tag.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
 	return nil
 }
+
+// Delete for good the infos put in the trash before t.
+// Returns the number of infos deleted
+func (m *InfoModel) InfoPurgeBefore(ctx context.Context, t time.Time) (int, error) {
+	query := `
+DELETE FROM info
+  WHERE deleted_at < $1
+`
+	tag, err := m.DB.Exec(ctx, query, t.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,205 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestInfoModel(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	sources := &SourceModel{DB: db}
+	m := &InfoModel{DB: db}
+
+	sID, err := sources.SourceInsert(ctx, &Source{Name: "Billancourt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := &Info{
+		SourceID: sID,
+		Agent:    "Dupont",
+		Material: "Transfo 1",
+		Detail:   "Oil leak",
+		Priority: 2,
+		Status:   "waiting",
+	}
+
+	id, err := m.InfoInsert(ctx, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := m.InfoGet(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Material != "Transfo 1" || got.SourceID != sID {
+		t.Errorf("got %+v", got)
+	}
+	// Never updated
+	if got.Updated != got.ZeroTime {
+		t.Errorf("got updated %v; want zero time", got.Updated)
+	}
+
+	info.ID = id
+	info.Status = "done"
+	info.Estimate = "1000"
+	if err = m.InfoUpdate(ctx, info); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = m.InfoGet(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != "done" || got.Estimate != "1000" {
+		t.Errorf("got %+v", got)
+	}
+	if got.Updated == got.ZeroTime {
+		t.Error("updated is not set")
+	}
+
+	list, err := m.InfoList(ctx, sID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Errorf("got %d infos; want 1", len(list))
+	}
+
+	if err = m.InfoDelete(ctx, id, "Dupont"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.InfoGet(ctx, id); !errors.Is(err, ErrNoRecord) {
+		t.Errorf("got %v; want ErrNoRecord", err)
+	}
+	if err = m.InfoDelete(ctx, id, "Dupont"); !errors.Is(err, ErrNoRecord) {
+		t.Errorf("got %v; want ErrNoRecord", err)
+	}
+}
+
+func TestInfoList(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	sources := &SourceModel{DB: db}
+	m := &InfoModel{DB: db}
+
+	sID, _ := sources.SourceInsert(ctx, &Source{Name: "A"})
+	other, _ := sources.SourceInsert(ctx, &Source{Name: "B"})
+
+	for _, p := range []struct {
+		source   int
+		priority int
+	}{{sID, 3}, {sID, 1}, {other, 2}} {
+		_, err := m.InfoInsert(ctx, &Info{SourceID: p.source, Agent: "x",
+			Material: "x", Detail: "x", Priority: p.priority,
+			Status: "waiting"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	infos, err := m.InfoList(ctx, sID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("got %d infos; want 2", len(infos))
+	}
+	if infos[0].Priority != 1 || infos[1].Priority != 3 {
+		t.Errorf("infos are not sorted by priority")
+	}
+
+	counts, err := m.StatusCount(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts["waiting"] != 3 {
+		t.Errorf("got %d waiting; want 3", counts["waiting"])
+	}
+}
+
+func TestInfoBoard(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	sources := &SourceModel{DB: db}
+	m := &InfoModel{DB: db}
+
+	sID, _ := sources.SourceInsert(ctx, &Source{Name: "A"})
+	other, _ := sources.SourceInsert(ctx, &Source{Name: "B"})
+
+	for _, p := range []struct {
+		source int
+		status string
+	}{{sID, "waiting"}, {sID, "archived"}, {other, "done"}} {
+		_, err := m.InfoInsert(ctx, &Info{SourceID: p.source, Agent: "x",
+			Material: "x", Detail: "x", Priority: 1, Status: p.status})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		source   int
+		archived bool
+		want     int
+	}{{0, false, 2}, {0, true, 3}, {sID, false, 1}, {other, true, 1}}
+
+	for _, tt := range tests {
+		infos, err := m.InfoBoard(ctx, tt.source, 0, tt.archived)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(infos) != tt.want {
+			t.Errorf("source %d, archived %t: got %d infos; want %d",
+				tt.source, tt.archived, len(infos), tt.want)
+		}
+	}
+}
+
+func TestInfoInsertUnknownSource(t *testing.T) {
+	m := &InfoModel{DB: newTestDB(t)}
+
+	_, err := m.InfoInsert(context.Background(), &Info{SourceID: 42,
+		Agent: "x", Material: "x", Detail: "x", Status: "waiting"})
+	if err == nil {
+		t.Error("got nil; want a FK error")
+	}
+}
+
+func TestInfoSimilar(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	sources := &SourceModel{DB: db}
+	m := &InfoModel{DB: db}
+
+	sID, err := sources.SourceInsert(ctx, &Source{Name: "Billancourt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := map[string]int{}
+	for _, info := range []*Info{
+		{Material: "Transfo 1", Detail: "Oil leak", Status: "waiting"},
+		{Material: "Transfo 1", Detail: "Oil leak", Status: "archived"},
+		{Material: "Transfo 1", Detail: "Broken fan", Status: "waiting"},
+		{Material: "Disjoncteur 3", Detail: "Oil leak", Status: "waiting"},
+	} {
+		info.SourceID, info.Agent, info.Priority = sID, "Dupont", 1
+		id, err := m.InfoInsert(ctx, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[info.Material+"/"+info.Detail+"/"+info.Status] = id
+	}
+
+	similar, err := m.InfoSimilar(ctx, sID, "transfo 1", "Oil leaks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(similar) != 1 || similar[0].ID != ids["Transfo 1/Oil leak/waiting"] {
+		t.Errorf("got %+v", similar)
+	}
+}
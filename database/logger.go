@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// pgx has its own logger interface. Logger sends what pgx
+// logs (queries, errors, ...) to slog so it ends up with
+// the same format and request ID as the rest of the program
+type Logger struct {
+	logger *slog.Logger
+}
+
+func NewLogger(logger *slog.Logger) *Logger {
+	return &Logger{logger: logger.With("component", "database")}
+}
+
+// Log implements pgx.Logger.
+// pgx logs every query at Info level, it's too noisy
+// so they are sent as Debug
+func (l *Logger) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]interface{}) {
+	var lvl slog.Level
+
+	switch level {
+	case pgx.LogLevelError:
+		lvl = slog.LevelError
+	case pgx.LogLevelWarn:
+		lvl = slog.LevelWarn
+	default:
+		lvl = slog.LevelDebug
+	}
+
+	if !l.logger.Enabled(ctx, lvl) {
+		return
+	}
+
+	attrs := make([]any, 0, len(data))
+	for k, v := range data {
+		attrs = append(attrs, slog.Any(k, v))
+	}
+
+	l.logger.Log(ctx, lvl, msg, attrs...)
+}
@@ -0,0 +1,1669 @@
+// Package memory is a database.Store kept in maps.
+// It behaves like the PSQL one and is used by the handler
+// tests so they run without a database.
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"CURATOR/database"
+)
+
+type data struct {
+	sources map[int]database.Source
+	infos   map[int]database.Info
+
+	// old_id of the merged source ~> alias
+	aliases   map[int]alias
+	audit     []database.AuditEntry
+	equipment map[int]database.Equipment
+	tags      map[int]database.Tag
+	agents    map[int]database.Agent
+	feeds     map[string]database.Feed
+
+	// info ID ~> tag IDs
+	infoTags map[int]map[int]bool
+
+	lastSourceID    int
+	lastInfoID      int
+	lastAuditID     int
+	lastEquipmentID int
+	lastTagID       int
+	lastAgentID     int
+}
+
+type alias struct {
+	sourceID int
+	name     string
+}
+
+func (d *data) clone() *data {
+	c := &data{
+		sources:      make(map[int]database.Source, len(d.sources)),
+		infos:        make(map[int]database.Info, len(d.infos)),
+		aliases:      make(map[int]alias, len(d.aliases)),
+		audit:        append([]database.AuditEntry{}, d.audit...),
+		equipment:    make(map[int]database.Equipment, len(d.equipment)),
+		tags:         make(map[int]database.Tag, len(d.tags)),
+		infoTags:     make(map[int]map[int]bool, len(d.infoTags)),
+		agents:       make(map[int]database.Agent, len(d.agents)),
+		feeds:        make(map[string]database.Feed, len(d.feeds)),
+		lastSourceID: d.lastSourceID,
+		lastInfoID:   d.lastInfoID,
+		lastAuditID:  d.lastAuditID,
+
+		lastEquipmentID: d.lastEquipmentID,
+		lastTagID:       d.lastTagID,
+		lastAgentID:     d.lastAgentID,
+	}
+
+	for k, v := range d.sources {
+		c.sources[k] = v
+	}
+	for k, v := range d.infos {
+		c.infos[k] = v
+	}
+	for k, v := range d.aliases {
+		c.aliases[k] = v
+	}
+	for k, v := range d.equipment {
+		c.equipment[k] = v
+	}
+	for k, v := range d.tags {
+		c.tags[k] = v
+	}
+	for k, v := range d.agents {
+		c.agents[k] = v
+	}
+	for k, v := range d.feeds {
+		c.feeds[k] = v
+	}
+	for k, v := range d.infoTags {
+		c.infoTags[k] = make(map[int]bool, len(v))
+		for tagID := range v {
+			c.infoTags[k][tagID] = true
+		}
+	}
+
+	return c
+}
+
+// deleteInfo also removes its tags (ON DELETE CASCADE of info_tag)
+func (d *data) deleteInfo(id int) {
+	delete(d.infos, id)
+	delete(d.infoTags, id)
+}
+
+// nameTaken reports if a live source other than id has
+// the same normalised name, as source_name_key_idx of PSQL
+func (d *data) nameTaken(name string, id int) bool {
+	key := database.SourceKey(name)
+	for _, src := range d.sources {
+		if src.ID != id && src.DeletedAt.IsZero() &&
+			database.SourceKey(src.Name) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// copyLocation returns a copy of l, the stores keep
+// their own
+func copyLocation(l *database.Location) *database.Location {
+	if l == nil {
+		return nil
+	}
+	c := *l
+	return &c
+}
+
+// Store implements database.Store.
+// Values are copied in and out so callers never share
+// a struct with the store
+type Store struct {
+	mu   *sync.Mutex
+	txMu *sync.Mutex
+	data **data
+
+	inTx bool
+}
+
+func NewStore() *Store {
+	d := &data{
+		sources:   map[int]database.Source{},
+		infos:     map[int]database.Info{},
+		aliases:   map[int]alias{},
+		equipment: map[int]database.Equipment{},
+		tags:      map[int]database.Tag{},
+		infoTags:  map[int]map[int]bool{},
+		agents:    map[int]database.Agent{},
+		feeds:     map[string]database.Feed{},
+	}
+
+	return &Store{
+		mu:   &sync.Mutex{},
+		txMu: &sync.Mutex{},
+		data: &d,
+	}
+}
+
+func (s *Store) Sources() database.SourceStore {
+	return &sourceStore{s}
+}
+
+func (s *Store) Infos() database.InfoStore {
+	return &infoStore{s}
+}
+
+func (s *Store) Equipment() database.EquipmentStore {
+	return &equipmentStore{s}
+}
+
+func (s *Store) Tags() database.TagStore {
+	return &tagStore{s}
+}
+
+func (s *Store) Agents() database.AgentStore {
+	return &agentStore{s}
+}
+
+func (s *Store) Feeds() database.FeedStore {
+	return &feedStore{s}
+}
+
+func (s *Store) Audit() database.AuditStore {
+	return &auditStore{s}
+}
+
+// WithTx runs fn one transaction at a time. If fn fails,
+// the data is put back as it was before.
+// Nested calls join the running transaction
+func (s *Store) WithTx(ctx context.Context, fn func(tx database.Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.Lock()
+	snapshot := (*s.data).clone()
+	s.mu.Unlock()
+
+	tx := *s
+	tx.inTx = true
+
+	if err := fn(&tx); err != nil {
+		s.mu.Lock()
+		*s.data = snapshot
+		s.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+//
+// Sources
+//
+
+type sourceStore struct {
+	*Store
+}
+
+func (s *sourceStore) MenuSource(ctx context.Context) ([]*database.Source, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	sources := []*database.Source{}
+
+	for _, src := range d.sources {
+		if !src.DeletedAt.IsZero() {
+			continue
+		}
+
+		sObj := src
+		sObj.Location = copyLocation(src.Location)
+		for _, info := range d.infos {
+			if info.SourceID == src.ID && info.Status != "archived" &&
+				info.DeletedAt.IsZero() {
+				sObj.Curatifs++
+			}
+		}
+
+		sources = append(sources, &sObj)
+	}
+
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].Name < sources[j].Name
+	})
+
+	return sources, nil
+}
+
+func (s *sourceStore) SourceGet(ctx context.Context, id int) (*database.Source, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	src, ok := (*s.data).sources[id]
+	if !ok || !src.DeletedAt.IsZero() {
+		return nil, database.ErrNoRecord
+	}
+	src.Location = copyLocation(src.Location)
+
+	return &src, nil
+}
+
+func (s *sourceStore) SourceInsert(ctx context.Context, src *database.Source) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	if d.nameTaken(src.Name, 0) {
+		return 0, database.ErrDuplicateSource
+	}
+
+	d.lastSourceID++
+	src.ID = d.lastSourceID
+
+	d.sources[src.ID] = database.Source{
+		ID:       src.ID,
+		Name:     src.Name,
+		Address:  src.Address,
+		Location: copyLocation(src.Location),
+		Created:  time.Now().UTC(),
+	}
+
+	return src.ID, nil
+}
+
+func (s *sourceStore) SourceUpdate(ctx context.Context, src *database.Source) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	sObj, ok := d.sources[src.ID]
+	if !ok || !sObj.DeletedAt.IsZero() {
+		return database.ErrNoRecord
+	}
+
+	if d.nameTaken(src.Name, src.ID) {
+		return database.ErrDuplicateSource
+	}
+
+	sObj.Name = src.Name
+	sObj.Address = src.Address
+	sObj.Location = copyLocation(src.Location)
+	d.sources[src.ID] = sObj
+
+	return nil
+}
+
+// Same as database.SourceModel, only a source without
+// open infos can be deleted
+func (s *sourceStore) SourceDelete(ctx context.Context, id int, by string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	sObj, ok := d.sources[id]
+	if !ok || !sObj.DeletedAt.IsZero() {
+		return database.ErrNoRecord
+	}
+
+	for _, info := range d.infos {
+		if info.SourceID == id && info.DeletedAt.IsZero() &&
+			info.Status != "archived" {
+			return database.ErrSourceNotEmpty
+		}
+	}
+
+	sObj.DeletedAt = time.Now().UTC()
+	sObj.DeletedBy = by
+	d.sources[id] = sObj
+
+	return nil
+}
+
+func (s *sourceStore) SourceSimilar(ctx context.Context, name string, excludeID int) ([]*database.Source, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	type match struct {
+		src   database.Source
+		score float64
+	}
+	matches := []match{}
+
+	for _, src := range d.sources {
+		if src.ID == excludeID || !src.DeletedAt.IsZero() {
+			continue
+		}
+		score := database.Similarity(src.Name, name)
+		if score >= database.SimilarityThreshold {
+			matches = append(matches, match{src, score})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].src.Name < matches[j].src.Name
+	})
+
+	sources := []*database.Source{}
+	for i := 0; i < len(matches) && i < 5; i++ {
+		sObj := database.Source{ID: matches[i].src.ID, Name: matches[i].src.Name}
+		sources = append(sources, &sObj)
+	}
+
+	return sources, nil
+}
+
+// SourceLock only checks the source exists,
+// WithTx already runs one transaction at a time
+func (s *sourceStore) SourceLock(ctx context.Context, id int) error {
+	_, err := s.SourceGet(ctx, id)
+	return err
+}
+
+func (s *sourceStore) SourceTrash(ctx context.Context) ([]*database.Source, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sources := []*database.Source{}
+
+	for _, src := range (*s.data).sources {
+		if !src.DeletedAt.IsZero() {
+			sObj := src
+			sources = append(sources, &sObj)
+		}
+	}
+
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].DeletedAt.After(sources[j].DeletedAt)
+	})
+
+	return sources, nil
+}
+
+func (s *sourceStore) SourceRestore(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	sObj, ok := d.sources[id]
+	if !ok || sObj.DeletedAt.IsZero() {
+		return database.ErrNoRecord
+	}
+
+	if d.nameTaken(sObj.Name, id) {
+		return database.ErrDuplicateSource
+	}
+
+	sObj.DeletedAt = time.Time{}
+	sObj.DeletedBy = ""
+	d.sources[id] = sObj
+
+	return nil
+}
+
+// Same as database.SourceModel, the infos of the source
+// are purged with it
+func (s *sourceStore) SourcePurge(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	sObj, ok := d.sources[id]
+	if !ok || sObj.DeletedAt.IsZero() {
+		return database.ErrNoRecord
+	}
+
+	d.purgeSource(id)
+
+	return nil
+}
+
+func (s *sourceStore) SourcePurgeBefore(ctx context.Context, t time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	count := 0
+	for id, src := range d.sources {
+		if !src.DeletedAt.IsZero() && src.DeletedAt.Before(t) {
+			d.purgeSource(id)
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (d *data) purgeSource(id int) {
+	for iID, info := range d.infos {
+		if info.SourceID == id {
+			d.deleteInfo(iID)
+		}
+	}
+
+	// ON DELETE CASCADE of source_alias, equipment and calendar_feed
+	for token, feed := range d.feeds {
+		if feed.SourceID == id {
+			delete(d.feeds, token)
+		}
+	}
+	for oldID, a := range d.aliases {
+		if a.sourceID == id {
+			delete(d.aliases, oldID)
+		}
+	}
+	for eID, e := range d.equipment {
+		if e.SourceID == id {
+			delete(d.equipment, eID)
+		}
+	}
+
+	delete(d.sources, id)
+}
+
+func (s *sourceStore) SourceMerge(ctx context.Context, fromID, toID int) error {
+	if fromID == toID {
+		return database.ErrSameSource
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	from, ok := d.sources[fromID]
+	if !ok || !from.DeletedAt.IsZero() {
+		return database.ErrNoRecord
+	}
+	if to, ok := d.sources[toID]; !ok || !to.DeletedAt.IsZero() {
+		return database.ErrNoRecord
+	}
+
+	for id, info := range d.infos {
+		if info.SourceID == fromID {
+			info.SourceID = toID
+			d.infos[id] = info
+		}
+	}
+	for oldID, a := range d.aliases {
+		if a.sourceID == fromID {
+			a.sourceID = toID
+			d.aliases[oldID] = a
+		}
+	}
+	for id, e := range d.equipment {
+		if e.SourceID == fromID {
+			e.SourceID = toID
+			d.equipment[id] = e
+		}
+	}
+	for i := range d.audit {
+		if d.audit[i].SourceID == fromID {
+			d.audit[i].SourceID = toID
+		}
+	}
+
+	// One feed a source, the one of toID stays
+	hasFeed := false
+	for _, feed := range d.feeds {
+		if feed.SourceID == toID {
+			hasFeed = true
+		}
+	}
+	for token, feed := range d.feeds {
+		if feed.SourceID != fromID {
+			continue
+		}
+		if hasFeed {
+			delete(d.feeds, token)
+		} else {
+			feed.SourceID = toID
+			d.feeds[token] = feed
+		}
+	}
+
+	d.aliases[fromID] = alias{sourceID: toID, name: from.Name}
+	delete(d.sources, fromID)
+
+	return nil
+}
+
+func (s *sourceStore) SourceAliases(ctx context.Context, id int) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := []string{}
+	for _, a := range (*s.data).aliases {
+		if a.sourceID == id {
+			names = append(names, a.name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (s *sourceStore) SourceAlias(ctx context.Context, oldID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := (*s.data).aliases[oldID]
+	if !ok {
+		return 0, database.ErrNoRecord
+	}
+
+	return a.sourceID, nil
+}
+
+//
+// Infos
+//
+
+type infoStore struct {
+	*Store
+}
+
+func (s *infoStore) InfoInsert(ctx context.Context, info *database.Info) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	// Same as the FK on info.source_id
+	if _, ok := d.sources[info.SourceID]; !ok {
+		return -1, database.ErrNoRecord
+	}
+	if !d.agentsExist(info.ReporterID, info.AssigneeID) {
+		return -1, database.ErrNoRecord
+	}
+
+	d.lastInfoID++
+	info.ID = d.lastInfoID
+
+	iObj := *info
+	iObj.Created = time.Now().UTC()
+	iObj.Updated = time.Time{}
+	iObj.EquipmentName = ""
+	iObj.Assignee = ""
+	d.infos[iObj.ID] = iObj
+
+	return info.ID, nil
+}
+
+func (s *infoStore) InfoGet(ctx context.Context, id int) (*database.Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, ok := (*s.data).infos[id]
+	if !ok || !info.DeletedAt.IsZero() {
+		return nil, database.ErrNoRecord
+	}
+
+	info.ZeroTime = database.ZeroTime
+	info.EquipmentName = (*s.data).equipment[info.EquipmentID].Name
+	info.Assignee = (*s.data).agents[info.AssigneeID].Name
+
+	return &info, nil
+}
+
+// Grouped by equipment like the PSQL InfoList
+func (s *infoStore) InfoList(ctx context.Context, sourceID int) ([]*database.Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	// Place of each equipment in the tree
+	place := map[int]int{}
+	for i, e := range d.equipmentTree(sourceID) {
+		place[e.ID] = i
+	}
+	rank := func(info *database.Info) int {
+		if p, ok := place[info.EquipmentID]; ok {
+			return p
+		}
+		return len(place)
+	}
+
+	infos := []*database.Info{}
+
+	for _, info := range d.infos {
+		if info.SourceID == sourceID && info.DeletedAt.IsZero() {
+			iObj := info
+			iObj.ZeroTime = database.ZeroTime
+			iObj.EquipmentName = d.equipment[info.EquipmentID].Name
+			iObj.Assignee = d.agents[info.AssigneeID].Name
+			infos = append(infos, &iObj)
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if ri, rj := rank(infos[i]), rank(infos[j]); ri != rj {
+			return ri < rj
+		}
+		if infos[i].Priority == infos[j].Priority {
+			return infos[i].ID < infos[j].ID
+		}
+		return infos[i].Priority < infos[j].Priority
+	})
+
+	return infos, nil
+}
+
+func (s *infoStore) InfoListEquipment(ctx context.Context, equipmentIDs []int) ([]*database.Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	ids := map[int]bool{}
+	for _, id := range equipmentIDs {
+		ids[id] = true
+	}
+
+	infos := []*database.Info{}
+
+	for _, info := range d.infos {
+		if info.EquipmentID != 0 && ids[info.EquipmentID] && info.DeletedAt.IsZero() {
+			iObj := info
+			iObj.ZeroTime = database.ZeroTime
+			iObj.EquipmentName = d.equipment[info.EquipmentID].Name
+			infos = append(infos, &iObj)
+		}
+	}
+
+	// Last created first
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Created.Equal(infos[j].Created) {
+			return infos[i].ID > infos[j].ID
+		}
+		return infos[i].Created.After(infos[j].Created)
+	})
+
+	return infos, nil
+}
+
+func (s *infoStore) InfoSearch(ctx context.Context, q string, tagID int) ([]*database.Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	q = strings.ToLower(q)
+	infos := []*database.Info{}
+
+	for _, info := range d.infos {
+		src, ok := d.sources[info.SourceID]
+		if !info.DeletedAt.IsZero() || !ok || !src.DeletedAt.IsZero() {
+			continue
+		}
+		if q != "" && !strings.Contains(strings.ToLower(info.Material), q) &&
+			!strings.Contains(strings.ToLower(info.Detail), q) {
+			continue
+		}
+		if tagID != 0 && !d.infoTags[info.ID][tagID] {
+			continue
+		}
+
+		iObj := info
+		iObj.ZeroTime = database.ZeroTime
+		iObj.SourceName = src.Name
+		infos = append(infos, &iObj)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Created.Equal(infos[j].Created) {
+			return infos[i].ID > infos[j].ID
+		}
+		return infos[i].Created.After(infos[j].Created)
+	})
+
+	if len(infos) > database.SearchLimit {
+		infos = infos[:database.SearchLimit]
+	}
+
+	return infos, nil
+}
+
+func (s *infoStore) InfoListAgent(ctx context.Context, agentID int) ([]*database.Info, error) {
+	return s.listOpen(func(info database.Info) bool {
+		return info.ReporterID == agentID || info.AssigneeID == agentID
+	}), nil
+}
+
+func (s *infoStore) InfoListAssigned(ctx context.Context, agentID int, team string) ([]*database.Info, error) {
+	return s.listOpen(func(info database.Info) bool {
+		return info.AssigneeID == agentID ||
+			(info.AssigneeID == 0 && info.Team != "" && info.Team == team)
+	}), nil
+}
+
+func (s *infoStore) InfoBoard(ctx context.Context, sourceID, agentID int, archived bool) ([]*database.Info, error) {
+	return s.listInfos(func(info database.Info) bool {
+		return (sourceID == 0 || info.SourceID == sourceID) &&
+			(agentID == 0 || info.ReporterID == agentID || info.AssigneeID == agentID) &&
+			(archived || info.Status != "archived")
+	}), nil
+}
+
+func (s *infoStore) InfoCalendar(ctx context.Context, from, to time.Time, sourceID, agentID int) ([]*database.Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	in := func(t time.Time) bool {
+		return !t.IsZero() && !t.Before(from) && t.Before(to)
+	}
+	team := d.agents[agentID].Team
+
+	infos := []*database.Info{}
+
+	for _, info := range d.infos {
+		src, ok := d.sources[info.SourceID]
+		if !info.DeletedAt.IsZero() || !ok || !src.DeletedAt.IsZero() ||
+			info.Status == "archived" || !(in(info.Due) || in(info.Planned)) {
+			continue
+		}
+		if sourceID != 0 && info.SourceID != sourceID {
+			continue
+		}
+		if agentID != 0 && info.AssigneeID != agentID &&
+			(info.AssigneeID != 0 || info.Team == "" || info.Team != team) {
+			continue
+		}
+
+		iObj := info
+		iObj.ZeroTime = database.ZeroTime
+		iObj.SourceName = src.Name
+		iObj.Assignee = d.agents[info.AssigneeID].Name
+		infos = append(infos, &iObj)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Priority != infos[j].Priority {
+			return infos[i].Priority < infos[j].Priority
+		}
+		return infos[i].ID < infos[j].ID
+	})
+
+	return infos, nil
+}
+
+func (s *infoStore) listOpen(match func(info database.Info) bool) []*database.Info {
+	return s.listInfos(func(info database.Info) bool {
+		return info.Status != "archived" && match(info)
+	})
+}
+
+// listInfos returns the infos matching, by priority
+// then due date (none last) like the PSQL listInfos
+func (s *infoStore) listInfos(match func(info database.Info) bool) []*database.Info {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	infos := []*database.Info{}
+
+	for _, info := range d.infos {
+		src, ok := d.sources[info.SourceID]
+		if !info.DeletedAt.IsZero() || !ok || !src.DeletedAt.IsZero() ||
+			!match(info) {
+			continue
+		}
+
+		iObj := info
+		iObj.ZeroTime = database.ZeroTime
+		iObj.SourceName = src.Name
+		iObj.Assignee = d.agents[info.AssigneeID].Name
+		infos = append(infos, &iObj)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		a, b := infos[i], infos[j]
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if !a.Due.Equal(b.Due) {
+			if a.Due.IsZero() || b.Due.IsZero() {
+				return b.Due.IsZero()
+			}
+			return a.Due.Before(b.Due)
+		}
+		if !a.Created.Equal(b.Created) {
+			return a.Created.Before(b.Created)
+		}
+		return a.ID < b.ID
+	})
+
+	return infos
+}
+
+func (s *infoStore) InfoSimilar(ctx context.Context, sourceID int, material, detail string) ([]*database.Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type match struct {
+		info  database.Info
+		score float64
+	}
+	matches := []match{}
+
+	for _, info := range (*s.data).infos {
+		if info.SourceID != sourceID || !info.DeletedAt.IsZero() ||
+			info.Status == "archived" {
+			continue
+		}
+
+		m := database.Similarity(info.Material, material)
+		d := database.Similarity(info.Detail, detail)
+		if m >= database.SimilarityThreshold && d >= database.SimilarityThreshold {
+			matches = append(matches, match{info, m + d})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].info.ID < matches[j].info.ID
+	})
+
+	infos := []*database.Info{}
+	for i := 0; i < len(matches) && i < 5; i++ {
+		iObj := matches[i].info
+		iObj.ZeroTime = database.ZeroTime
+		infos = append(infos, &iObj)
+	}
+
+	return infos, nil
+}
+
+func (s *infoStore) InfoUpdate(ctx context.Context, info *database.Info) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	iObj, ok := d.infos[info.ID]
+	if !ok || !iObj.DeletedAt.IsZero() {
+		return database.ErrNoRecord
+	}
+	if !d.agentsExist(info.ReporterID, info.AssigneeID) {
+		return database.ErrNoRecord
+	}
+
+	iObj.Agent = info.Agent
+	iObj.ReporterID = info.ReporterID
+	iObj.AssigneeID = info.AssigneeID
+	iObj.Team = info.Team
+	iObj.Due = info.Due
+	iObj.Planned = info.Planned
+	iObj.Material = info.Material
+	iObj.Priority = info.Priority
+	iObj.Detail = info.Detail
+	iObj.Estimate = info.Estimate
+	iObj.Status = info.Status
+	iObj.EquipmentID = info.EquipmentID
+	iObj.Updated = time.Now().UTC()
+	d.infos[info.ID] = iObj
+
+	return nil
+}
+
+func (s *infoStore) InfoAssign(ctx context.Context, id, assigneeID int, team string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	iObj, ok := d.infos[id]
+	if !ok || !iObj.DeletedAt.IsZero() {
+		return database.ErrNoRecord
+	}
+	if !d.agentsExist(assigneeID) {
+		return database.ErrNoRecord
+	}
+
+	iObj.AssigneeID = assigneeID
+	iObj.Team = team
+	if iObj.Status == "waiting" && (assigneeID != 0 || team != "") {
+		iObj.Status = "affected"
+	}
+	iObj.Updated = time.Now().UTC()
+	d.infos[id] = iObj
+
+	return nil
+}
+
+func (s *infoStore) InfoDelete(ctx context.Context, id int, by string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	iObj, ok := d.infos[id]
+	if !ok || !iObj.DeletedAt.IsZero() {
+		return database.ErrNoRecord
+	}
+
+	iObj.DeletedAt = time.Now().UTC()
+	iObj.DeletedBy = by
+	d.infos[id] = iObj
+
+	return nil
+}
+
+func (s *infoStore) StatusCount(ctx context.Context) (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := map[string]int{}
+	for _, info := range (*s.data).infos {
+		if info.Status != "archived" && info.DeletedAt.IsZero() {
+			counts[info.Status]++
+		}
+	}
+
+	return counts, nil
+}
+
+func (s *infoStore) InfoTrash(ctx context.Context) ([]*database.Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	infos := []*database.Info{}
+
+	for _, info := range d.infos {
+		if !info.DeletedAt.IsZero() {
+			iObj := info
+			iObj.ZeroTime = database.ZeroTime
+			iObj.SourceName = d.sources[info.SourceID].Name
+			infos = append(infos, &iObj)
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].DeletedAt.After(infos[j].DeletedAt)
+	})
+
+	return infos, nil
+}
+
+// Same as database.InfoModel, a source in the trash
+// is restored with its info
+func (s *infoStore) InfoRestore(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	iObj, ok := d.infos[id]
+	if !ok || iObj.DeletedAt.IsZero() {
+		return database.ErrNoRecord
+	}
+
+	sObj, ok := d.sources[iObj.SourceID]
+	if ok && !sObj.DeletedAt.IsZero() && d.nameTaken(sObj.Name, sObj.ID) {
+		return database.ErrDuplicateSource
+	}
+
+	iObj.DeletedAt = time.Time{}
+	iObj.DeletedBy = ""
+	d.infos[id] = iObj
+
+	if ok {
+		sObj.DeletedAt = time.Time{}
+		sObj.DeletedBy = ""
+		d.sources[sObj.ID] = sObj
+	}
+
+	return nil
+}
+
+func (s *infoStore) InfoPurge(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	iObj, ok := d.infos[id]
+	if !ok || iObj.DeletedAt.IsZero() {
+		return database.ErrNoRecord
+	}
+
+	d.deleteInfo(id)
+
+	return nil
+}
+
+func (s *infoStore) InfoPurgeBefore(ctx context.Context, t time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	count := 0
+	for id, info := range d.infos {
+		if !info.DeletedAt.IsZero() && info.DeletedAt.Before(t) {
+			d.deleteInfo(id)
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (s *infoStore) InfoMove(ctx context.Context, id, sourceID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	iObj, ok := d.infos[id]
+	if !ok || !iObj.DeletedAt.IsZero() {
+		return database.ErrNoRecord
+	}
+
+	// Same as the FK on info.source_id
+	if _, ok := d.sources[sourceID]; !ok {
+		return database.ErrNoRecord
+	}
+
+	// The equipment stays with its source
+	iObj.SourceID = sourceID
+	iObj.EquipmentID = 0
+	iObj.Updated = time.Now().UTC()
+	d.infos[id] = iObj
+
+	return nil
+}
+
+func (s *infoStore) InfoMoveAll(ctx context.Context, fromID, toID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	// Same as the FK on info.source_id
+	if _, ok := d.sources[toID]; !ok {
+		return 0, database.ErrNoRecord
+	}
+
+	count := 0
+	for id, info := range d.infos {
+		if info.SourceID == fromID && info.DeletedAt.IsZero() {
+			info.SourceID = toID
+			info.EquipmentID = 0
+			info.Updated = time.Now().UTC()
+			d.infos[id] = info
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (s *infoStore) InfoArchiveAll(ctx context.Context, sourceID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	count := 0
+	for id, info := range d.infos {
+		if info.SourceID == sourceID && info.DeletedAt.IsZero() &&
+			info.Status != "archived" {
+			info.Status = "archived"
+			info.Updated = time.Now().UTC()
+			d.infos[id] = info
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+//
+// Audit
+//
+
+type auditStore struct {
+	*Store
+}
+
+func (s *auditStore) AuditInsert(ctx context.Context, e *database.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	d.lastAuditID++
+	e.ID = d.lastAuditID
+	e.Created = time.Now().UTC()
+
+	d.audit = append(d.audit, *e)
+
+	return nil
+}
+
+func (s *auditStore) AuditList(ctx context.Context, sourceID int) ([]*database.AuditEntry, error) {
+	return s.list(func(e database.AuditEntry) bool { return e.SourceID == sourceID }), nil
+}
+
+func (s *auditStore) AuditListInfo(ctx context.Context, infoID int) ([]*database.AuditEntry, error) {
+	return s.list(func(e database.AuditEntry) bool { return e.InfoID == infoID }), nil
+}
+
+func (s *auditStore) list(match func(e database.AuditEntry) bool) []*database.AuditEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := []*database.AuditEntry{}
+
+	// Last entry first
+	audit := (*s.data).audit
+	for i := len(audit) - 1; i >= 0; i-- {
+		if match(audit[i]) {
+			e := audit[i]
+			entries = append(entries, &e)
+		}
+	}
+
+	return entries
+}
+
+//
+// Equipment
+//
+
+type equipmentStore struct {
+	*Store
+}
+
+// equipmentTree is the equipment of a source ordered like
+// the PSQL EquipmentList: each parent then its children by name
+func (d *data) equipmentTree(sourceID int) []*database.Equipment {
+	children := map[int][]database.Equipment{}
+	for _, e := range d.equipment {
+		if e.SourceID == sourceID {
+			children[e.ParentID] = append(children[e.ParentID], e)
+		}
+	}
+
+	list := []*database.Equipment{}
+
+	var walk func(parentID, depth int)
+	walk = func(parentID, depth int) {
+		c := children[parentID]
+		sort.Slice(c, func(i, j int) bool {
+			ni, nj := strings.ToLower(c[i].Name), strings.ToLower(c[j].Name)
+			if ni == nj {
+				return c[i].ID < c[j].ID
+			}
+			return ni < nj
+		})
+
+		for _, e := range c {
+			eObj := e
+			eObj.Depth = depth
+			list = append(list, &eObj)
+			walk(e.ID, depth+1)
+		}
+	}
+	walk(0, 0)
+
+	return list
+}
+
+func (s *equipmentStore) EquipmentList(ctx context.Context, sourceID int) ([]*database.Equipment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return (*s.data).equipmentTree(sourceID), nil
+}
+
+func (s *equipmentStore) EquipmentGet(ctx context.Context, id int) (*database.Equipment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := (*s.data).equipment[id]
+	if !ok {
+		return nil, database.ErrNoRecord
+	}
+
+	return &e, nil
+}
+
+func (s *equipmentStore) EquipmentInsert(ctx context.Context, e *database.Equipment) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	// Same as the FKs on source_id and parent_id
+	if _, ok := d.sources[e.SourceID]; !ok {
+		return 0, database.ErrNoRecord
+	}
+	if _, ok := d.equipment[e.ParentID]; e.ParentID != 0 && !ok {
+		return 0, database.ErrNoRecord
+	}
+
+	d.lastEquipmentID++
+	e.ID = d.lastEquipmentID
+
+	eObj := *e
+	eObj.Created = time.Now().UTC()
+	eObj.Depth = 0
+	d.equipment[e.ID] = eObj
+
+	return e.ID, nil
+}
+
+func (s *equipmentStore) EquipmentUpdate(ctx context.Context, e *database.Equipment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	eObj, ok := d.equipment[e.ID]
+	if !ok {
+		return database.ErrNoRecord
+	}
+
+	eObj.ParentID = e.ParentID
+	eObj.Kind = e.Kind
+	eObj.Name = e.Name
+	d.equipment[e.ID] = eObj
+
+	return nil
+}
+
+func (s *equipmentStore) EquipmentDelete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	eObj, ok := d.equipment[id]
+	if !ok {
+		return database.ErrNoRecord
+	}
+
+	for cID, c := range d.equipment {
+		if c.ParentID == id {
+			c.ParentID = eObj.ParentID
+			d.equipment[cID] = c
+		}
+	}
+
+	// ON DELETE SET NULL of info.equipment_id
+	for iID, info := range d.infos {
+		if info.EquipmentID == id {
+			info.EquipmentID = 0
+			d.infos[iID] = info
+		}
+	}
+
+	delete(d.equipment, id)
+
+	return nil
+}
+
+//
+// Tags
+//
+
+type tagStore struct {
+	*Store
+}
+
+func (s *tagStore) TagList(ctx context.Context) ([]*database.Tag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	tags := []*database.Tag{}
+
+	for _, tag := range d.tags {
+		tObj := tag
+		for infoID, ids := range d.infoTags {
+			if ids[tag.ID] && d.infos[infoID].DeletedAt.IsZero() {
+				tObj.Count++
+			}
+		}
+		tags = append(tags, &tObj)
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return strings.ToLower(tags[i].Name) < strings.ToLower(tags[j].Name)
+	})
+
+	return tags, nil
+}
+
+func (s *tagStore) TagGet(ctx context.Context, id int) (*database.Tag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tag, ok := (*s.data).tags[id]
+	if !ok {
+		return nil, database.ErrNoRecord
+	}
+
+	return &tag, nil
+}
+
+// nameTaken is tag_name_idx
+func (s *tagStore) nameTaken(name string, id int) bool {
+	for _, tag := range (*s.data).tags {
+		if tag.ID != id && strings.EqualFold(tag.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *tagStore) TagInsert(ctx context.Context, tag *database.Tag) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	if s.nameTaken(tag.Name, 0) {
+		return 0, database.ErrDuplicateTag
+	}
+
+	d.lastTagID++
+	tag.ID = d.lastTagID
+
+	d.tags[tag.ID] = database.Tag{
+		ID:      tag.ID,
+		Name:    tag.Name,
+		Color:   tag.Color,
+		Created: time.Now().UTC(),
+	}
+
+	return tag.ID, nil
+}
+
+func (s *tagStore) TagUpdate(ctx context.Context, tag *database.Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	tObj, ok := d.tags[tag.ID]
+	if !ok {
+		return database.ErrNoRecord
+	}
+	if s.nameTaken(tag.Name, tag.ID) {
+		return database.ErrDuplicateTag
+	}
+
+	tObj.Name = tag.Name
+	tObj.Color = tag.Color
+	d.tags[tag.ID] = tObj
+
+	return nil
+}
+
+func (s *tagStore) TagDelete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	if _, ok := d.tags[id]; !ok {
+		return database.ErrNoRecord
+	}
+
+	for _, ids := range d.infoTags {
+		delete(ids, id)
+	}
+	delete(d.tags, id)
+
+	return nil
+}
+
+func (s *tagStore) TagsOfInfos(ctx context.Context, infoIDs []int) (map[int][]*database.Tag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	tags := map[int][]*database.Tag{}
+
+	for _, infoID := range infoIDs {
+		for tagID := range d.infoTags[infoID] {
+			tObj := d.tags[tagID]
+			tags[infoID] = append(tags[infoID], &tObj)
+		}
+
+		list := tags[infoID]
+		sort.Slice(list, func(i, j int) bool {
+			return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
+		})
+	}
+
+	return tags, nil
+}
+
+func (s *tagStore) TagsSet(ctx context.Context, infoID int, tagIDs []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	// Same as the FKs of info_tag
+	if _, ok := d.infos[infoID]; !ok {
+		return database.ErrNoRecord
+	}
+
+	ids := map[int]bool{}
+	for _, id := range tagIDs {
+		if _, ok := d.tags[id]; !ok {
+			return database.ErrNoRecord
+		}
+		ids[id] = true
+	}
+
+	d.infoTags[infoID] = ids
+
+	return nil
+}
+
+func (s *tagStore) TagCounts(ctx context.Context) (map[int]map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	counts := map[int]map[string]int{}
+
+	for infoID, ids := range d.infoTags {
+		info := d.infos[infoID]
+		if !info.DeletedAt.IsZero() || info.Status == "archived" {
+			continue
+		}
+
+		for tagID := range ids {
+			if counts[info.SourceID] == nil {
+				counts[info.SourceID] = map[string]int{}
+			}
+			counts[info.SourceID][d.tags[tagID].Name]++
+		}
+	}
+
+	return counts, nil
+}
+
+//
+// Agents
+//
+
+type agentStore struct {
+	*Store
+}
+
+// agentsExist is the FKs of info.reporter_id and
+// info.assignee_id, 0 is NULL
+func (d *data) agentsExist(ids ...int) bool {
+	for _, id := range ids {
+		if _, ok := d.agents[id]; id != 0 && !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// nameTaken is agent_name_idx
+func (s *agentStore) nameTaken(name string, id int) bool {
+	for _, agent := range (*s.data).agents {
+		if agent.ID != id && strings.EqualFold(agent.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *agentStore) AgentList(ctx context.Context) ([]*database.Agent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	agents := []*database.Agent{}
+
+	for _, agent := range d.agents {
+		aObj := agent
+		for _, info := range d.infos {
+			if info.AssigneeID == agent.ID && info.DeletedAt.IsZero() &&
+				info.Status != "archived" {
+				aObj.Open++
+			}
+		}
+		agents = append(agents, &aObj)
+	}
+
+	sort.Slice(agents, func(i, j int) bool {
+		return strings.ToLower(agents[i].Name) < strings.ToLower(agents[j].Name)
+	})
+
+	return agents, nil
+}
+
+func (s *agentStore) AgentGet(ctx context.Context, id int) (*database.Agent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agent, ok := (*s.data).agents[id]
+	if !ok {
+		return nil, database.ErrNoRecord
+	}
+
+	return &agent, nil
+}
+
+func (s *agentStore) AgentInsert(ctx context.Context, agent *database.Agent) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	if s.nameTaken(agent.Name, 0) {
+		return 0, database.ErrDuplicateAgent
+	}
+
+	d.lastAgentID++
+	agent.ID = d.lastAgentID
+
+	d.agents[agent.ID] = database.Agent{
+		ID:      agent.ID,
+		Name:    agent.Name,
+		Team:    agent.Team,
+		Contact: agent.Contact,
+		Active:  agent.Active,
+		Created: time.Now().UTC(),
+	}
+
+	return agent.ID, nil
+}
+
+func (s *agentStore) AgentUpdate(ctx context.Context, agent *database.Agent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	aObj, ok := d.agents[agent.ID]
+	if !ok {
+		return database.ErrNoRecord
+	}
+	if s.nameTaken(agent.Name, agent.ID) {
+		return database.ErrDuplicateAgent
+	}
+
+	aObj.Name = agent.Name
+	aObj.Team = agent.Team
+	aObj.Contact = agent.Contact
+	aObj.Active = agent.Active
+	d.agents[agent.ID] = aObj
+
+	// The infos they reported take the new name
+	for id, info := range d.infos {
+		if info.ReporterID == agent.ID {
+			info.Agent = agent.Name
+			d.infos[id] = info
+		}
+	}
+
+	return nil
+}
+
+//
+// Calendar feeds
+//
+
+type feedStore struct {
+	*Store
+}
+
+func (s *feedStore) FeedGet(ctx context.Context, token string) (*database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	feed, ok := (*s.data).feeds[token]
+	if !ok {
+		return nil, database.ErrNoRecord
+	}
+
+	return &feed, nil
+}
+
+func (s *feedStore) FeedFind(ctx context.Context, agentID, sourceID int) (*database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, feed := range (*s.data).feeds {
+		if (agentID != 0 && feed.AgentID == agentID) ||
+			(sourceID != 0 && feed.SourceID == sourceID) {
+			return &feed, nil
+		}
+	}
+
+	return nil, database.ErrNoRecord
+}
+
+func (s *feedStore) FeedRenew(ctx context.Context, feed *database.Feed) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *s.data
+
+	// Same as the FKs and calendar_feed_*_idx
+	if _, ok := d.agents[feed.AgentID]; feed.AgentID != 0 && !ok {
+		return database.ErrNoRecord
+	}
+	if _, ok := d.sources[feed.SourceID]; feed.SourceID != 0 && !ok {
+		return database.ErrNoRecord
+	}
+
+	for token, old := range d.feeds {
+		if old.AgentID == feed.AgentID && old.SourceID == feed.SourceID {
+			delete(d.feeds, token)
+		}
+	}
+
+	feed.Created = time.Now().UTC()
+	d.feeds[feed.Token] = *feed
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSourceMerge(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	sources := &SourceModel{DB: db}
+	infos := &InfoModel{DB: db}
+	audit := &AuditModel{DB: db}
+
+	var ids []int
+	for _, name := range []string{"Billancourt", "Nanterre", "Puteaux"} {
+		id, err := sources.SourceInsert(ctx, &Source{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	a, b, c := ids[0], ids[1], ids[2]
+
+	iID, err := infos.InfoInsert(ctx, &Info{SourceID: a, Agent: "Dupont",
+		Material: "Transfo 1", Detail: "Oil leak", Priority: 1, Status: "waiting"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = audit.AuditInsert(ctx, &AuditEntry{Actor: "Dupont",
+		Action: AuditInfoMove, SourceID: a, InfoID: iID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = sources.SourceMerge(ctx, a, a); !errors.Is(err, ErrSameSource) {
+		t.Errorf("got %v; want ErrSameSource", err)
+	}
+	if err = sources.SourceMerge(ctx, a, 42); !errors.Is(err, ErrNoRecord) {
+		t.Errorf("got %v; want ErrNoRecord", err)
+	}
+
+	// A into B, then B into C: C gets everything
+	if err = sources.SourceMerge(ctx, a, b); err != nil {
+		t.Fatal(err)
+	}
+	if err = sources.SourceMerge(ctx, b, c); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := infos.InfoGet(ctx, iID)
+	if err != nil || info.SourceID != c {
+		t.Errorf("got %+v, %v", info, err)
+	}
+
+	aliases, err := sources.SourceAliases(ctx, c)
+	if err != nil || len(aliases) != 2 {
+		t.Errorf("got aliases %v, %v", aliases, err)
+	}
+	for _, old := range []int{a, b} {
+		if id, err := sources.SourceAlias(ctx, old); err != nil || id != c {
+			t.Errorf("alias of %d: got %d, %v; want %d", old, id, err, c)
+		}
+	}
+
+	history, err := audit.AuditList(ctx, c)
+	if err != nil || len(history) != 1 || history[0].InfoID != iID {
+		t.Errorf("got history %+v, %v", history, err)
+	}
+}
+
+func TestSourceMergeFeeds(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	sources := &SourceModel{DB: db}
+	feeds := &FeedModel{DB: db}
+
+	var ids []int
+	for _, name := range []string{"Billancourt", "Nanterre", "Puteaux"} {
+		id, err := sources.SourceInsert(ctx, &Source{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	a, b, c := ids[0], ids[1], ids[2]
+
+	for token, id := range map[string]int{"a": a, "c": c} {
+		if err := feeds.FeedRenew(ctx, &Feed{Token: token, SourceID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// B has no feed, the one of A follows
+	if err := sources.SourceMerge(ctx, a, b); err != nil {
+		t.Fatal(err)
+	}
+	feed, err := feeds.FeedGet(ctx, "a")
+	if err != nil || feed.SourceID != b {
+		t.Errorf("got %+v, %v; want source %d", feed, err, b)
+	}
+
+	// C has one, it stays the only one
+	if err = sources.SourceMerge(ctx, b, c); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = feeds.FeedGet(ctx, "a"); !errors.Is(err, ErrNoRecord) {
+		t.Errorf("got %v; want ErrNoRecord", err)
+	}
+	feed, err = feeds.FeedFind(ctx, 0, c)
+	if err != nil || feed.Token != "c" {
+		t.Errorf("got %+v, %v; want feed c", feed, err)
+	}
+}
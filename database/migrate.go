@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// SQL files are shipped inside the binary
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrate applies, in name order, every file of migrations/
+// not applied yet. Each file runs in its own transaction and is
+// recorded in schema_migrations
+func Migrate(ctx context.Context, db DBTX) error {
+	query := `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    name    TEXT PRIMARY KEY,
+    applied TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+)
+`
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err = WithTx(ctx, db, func(tx pgx.Tx) error {
+			tag, err := tx.Exec(ctx, `
+INSERT INTO schema_migrations (name)
+VALUES ($1)
+  ON CONFLICT DO NOTHING
+`, name)
+			if err != nil {
+				return err
+			}
+
+			// Already applied
+			if tag.RowsAffected() == 0 {
+				return nil
+			}
+
+			sql, err := migrations.ReadFile(name)
+			if err != nil {
+				return err
+			}
+
+			// No argument: pgx uses the simple protocol,
+			// so a file can hold several statements
+			_, err = tx.Exec(ctx, string(sql))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+-- Tables used since the first version of CURATOR.
+-- IF NOT EXISTS so an existing database is left as it is
+
+CREATE TABLE IF NOT EXISTS source (
+    id      SERIAL PRIMARY KEY,
+    name    TEXT NOT NULL,
+    created TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE TABLE IF NOT EXISTS info (
+    id        SERIAL PRIMARY KEY,
+    source_id INTEGER NOT NULL REFERENCES source (id),
+    agent     TEXT NOT NULL,
+    material  TEXT NOT NULL,
+    details   TEXT NOT NULL,
+    priority  INTEGER NOT NULL,
+    estimate  TEXT,
+    status    TEXT NOT NULL,
+    created   TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated   TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS info_source_id_idx ON info (source_id);
@@ -0,0 +1,10 @@
+-- User sessions (CSRF token, flash messages, preferences).
+-- Same layout as the scs PostgreSQL stores
+
+CREATE TABLE IF NOT EXISTS sessions (
+    token  TEXT PRIMARY KEY,
+    data   BYTEA NOT NULL,
+    expiry TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS sessions_expiry_idx ON sessions (expiry);
@@ -0,0 +1,13 @@
+-- Soft delete: a deleted source or info goes to the trash
+-- (deleted_at is set), it can be restored until it's purged
+
+ALTER TABLE source ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE source ADD COLUMN IF NOT EXISTS deleted_by TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE info ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE info ADD COLUMN IF NOT EXISTS deleted_by TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS source_deleted_at_idx
+    ON source (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS info_deleted_at_idx
+    ON info (deleted_at) WHERE deleted_at IS NOT NULL;
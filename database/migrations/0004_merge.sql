@@ -0,0 +1,27 @@
+-- Merged sources: the old name and id point to the source
+-- that was kept, so old links still work
+
+CREATE TABLE IF NOT EXISTS source_alias (
+    id        SERIAL PRIMARY KEY,
+    source_id INTEGER NOT NULL REFERENCES source (id) ON DELETE CASCADE,
+    old_id    INTEGER NOT NULL UNIQUE,
+    name      TEXT NOT NULL,
+    created   TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX IF NOT EXISTS source_alias_source_id_idx ON source_alias (source_id);
+
+-- Who did what. No foreign key, the entries are kept
+-- when a source or an info is purged
+
+CREATE TABLE IF NOT EXISTS audit (
+    id        SERIAL PRIMARY KEY,
+    created   TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    actor     TEXT NOT NULL,
+    action    TEXT NOT NULL,
+    source_id INTEGER,
+    info_id   INTEGER,
+    detail    TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS audit_source_id_idx ON audit (source_id);
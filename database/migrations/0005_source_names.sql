@@ -0,0 +1,30 @@
+-- Source names are unique once normalised: no case,
+-- no accents, single spaces. Sources in the trash don't count.
+-- pg_trgm finds the names that look alike
+
+CREATE EXTENSION IF NOT EXISTS unaccent WITH SCHEMA public;
+CREATE EXTENSION IF NOT EXISTS pg_trgm WITH SCHEMA public;
+
+-- unaccent() is only STABLE, an index needs IMMUTABLE.
+-- Same as database.SourceKey
+CREATE OR REPLACE FUNCTION source_key(name TEXT) RETURNS TEXT
+  LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+AS $$
+  SELECT lower(regexp_replace(btrim(public.unaccent('public.unaccent'::regdictionary, name)),
+                              '\s+', ' ', 'g'))
+$$;
+
+-- The duplicates already there get their id in their name,
+-- they can be merged afterwards
+UPDATE source s
+  SET name = s.name || ' (' || s.id || ')'
+    WHERE s.deleted_at IS NULL
+      AND EXISTS (SELECT 1 FROM source o
+                    WHERE o.deleted_at IS NULL AND o.id < s.id
+                      AND source_key(o.name) = source_key(s.name));
+
+CREATE UNIQUE INDEX IF NOT EXISTS source_name_key_idx
+  ON source (source_key(name)) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS source_name_trgm_idx
+  ON source USING gin (source_key(name) gin_trgm_ops);
@@ -0,0 +1,21 @@
+-- Equipment of a source (bays, transformers, breakers...).
+-- A bay holds its transformers and breakers: parent_id.
+-- Infos point to the equipment instead of free text
+
+CREATE TABLE IF NOT EXISTS equipment (
+    id        SERIAL PRIMARY KEY,
+    source_id INTEGER NOT NULL REFERENCES source (id) ON DELETE CASCADE,
+    parent_id INTEGER REFERENCES equipment (id) ON DELETE SET NULL,
+    kind      TEXT NOT NULL,
+    name      TEXT NOT NULL,
+    created   TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX IF NOT EXISTS equipment_source_id_idx ON equipment (source_id);
+CREATE INDEX IF NOT EXISTS equipment_parent_id_idx ON equipment (parent_id);
+
+ALTER TABLE info
+  ADD COLUMN IF NOT EXISTS equipment_id INTEGER
+    REFERENCES equipment (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS info_equipment_id_idx ON info (equipment_id);
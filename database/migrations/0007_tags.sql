@@ -0,0 +1,19 @@
+-- Tags classify the infos (electrical, civil works, safety...),
+-- an info can have several of them
+
+CREATE TABLE IF NOT EXISTS tag (
+    id      SERIAL PRIMARY KEY,
+    name    TEXT NOT NULL,
+    color   TEXT NOT NULL DEFAULT '#7f8c8d',
+    created TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS tag_name_idx ON tag (lower(name));
+
+CREATE TABLE IF NOT EXISTS info_tag (
+    info_id INTEGER NOT NULL REFERENCES info (id) ON DELETE CASCADE,
+    tag_id  INTEGER NOT NULL REFERENCES tag (id) ON DELETE CASCADE,
+    PRIMARY KEY (info_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS info_tag_tag_id_idx ON info_tag (tag_id);
@@ -0,0 +1,36 @@
+-- Agents directory. An info is reported by an agent and
+-- can be assigned to another one; info.agent keeps the name
+-- of the reporter
+
+CREATE TABLE IF NOT EXISTS agent (
+    id      SERIAL PRIMARY KEY,
+    name    TEXT NOT NULL,
+    team    TEXT NOT NULL DEFAULT '',
+    contact TEXT NOT NULL DEFAULT '',
+    active  BOOLEAN NOT NULL DEFAULT TRUE,
+    created TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS agent_name_idx ON agent (lower(name));
+
+ALTER TABLE info
+  ADD COLUMN IF NOT EXISTS reporter_id INTEGER
+    REFERENCES agent (id) ON DELETE SET NULL,
+  ADD COLUMN IF NOT EXISTS assignee_id INTEGER
+    REFERENCES agent (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS info_reporter_id_idx ON info (reporter_id);
+CREATE INDEX IF NOT EXISTS info_assignee_id_idx ON info (assignee_id);
+
+-- The names typed so far become the first agents
+INSERT INTO agent (name)
+SELECT DISTINCT ON (lower(btrim(agent))) btrim(agent)
+  FROM info
+    WHERE btrim(agent) <> ''
+ON CONFLICT DO NOTHING;
+
+UPDATE info AS i
+  SET reporter_id = a.id
+  FROM agent AS a
+    WHERE i.reporter_id IS NULL
+      AND lower(a.name) = lower(btrim(i.agent));
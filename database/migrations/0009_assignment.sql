@@ -0,0 +1,8 @@
+-- An info is assigned to an agent (assignee_id) and/or a
+-- team, and can have a due date
+
+ALTER TABLE info
+  ADD COLUMN IF NOT EXISTS team TEXT NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS due DATE;
+
+CREATE INDEX IF NOT EXISTS info_team_idx ON info (team) WHERE team <> '';
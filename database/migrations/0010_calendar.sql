@@ -0,0 +1,19 @@
+-- Planned day of the intervention of an info, and the secret
+-- links of the .ics feeds: one per agent and one per source
+
+ALTER TABLE info
+  ADD COLUMN IF NOT EXISTS planned DATE;
+
+CREATE TABLE IF NOT EXISTS calendar_feed (
+    token     TEXT PRIMARY KEY,
+    agent_id  INTEGER REFERENCES agent (id) ON DELETE CASCADE,
+    source_id INTEGER REFERENCES source (id) ON DELETE CASCADE,
+    created   TIMESTAMP NOT NULL,
+
+    CHECK ((agent_id IS NULL) <> (source_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS calendar_feed_agent_idx
+  ON calendar_feed (agent_id) WHERE agent_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS calendar_feed_source_idx
+  ON calendar_feed (source_id) WHERE source_id IS NOT NULL;
@@ -0,0 +1,15 @@
+-- Where the sources are: a postal address and a point (WGS 84,
+-- degrees) for the map. A source has both coordinates or none
+
+ALTER TABLE source
+  ADD COLUMN IF NOT EXISTS address TEXT NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION,
+  ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+
+ALTER TABLE source
+  DROP CONSTRAINT IF EXISTS source_location_check;
+ALTER TABLE source
+  ADD CONSTRAINT source_location_check
+    CHECK ((latitude IS NULL) = (longitude IS NULL)
+           AND latitude BETWEEN -90 AND 90
+           AND longitude BETWEEN -180 AND 180);
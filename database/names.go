@@ -0,0 +1,83 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgconn"
+	"golang.org/x/text/unicode/norm"
+)
+
+// SimilarityThreshold is the trigram similarity from which
+// two source names look like the same source (pg_trgm default)
+const SimilarityThreshold = 0.3
+
+// SourceKey is the name compared for uniqueness: no case,
+// no accents and single spaces. "  Évry  Sud" is "evry sud".
+// Same as the source_key() function of PSQL
+func SourceKey(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(name) {
+		// The accents are separate marks after NFD
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Similarity is the trigram similarity of two names,
+// from 0 to 1, as similarity() of pg_trgm on their keys
+func Similarity(a, b string) float64 {
+	ta, tb := trigrams(SourceKey(a)), trigrams(SourceKey(b))
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// trigrams of every word of s, padded like pg_trgm does:
+// "ab" gives "  a", " ab", "ab "
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		r := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(r); i++ {
+			set[string(r[i:i+3])] = true
+		}
+	}
+
+	return set
+}
+
+// duplicateSource turns the unique violation of
+// source_name_key_idx into ErrDuplicateSource
+func duplicateSource(err error) error {
+	return uniqueViolation(err, "source_name_key_idx", ErrDuplicateSource)
+}
+
+// uniqueViolation returns dup when err is a unique
+// violation of index, else err
+func uniqueViolation(err error, index string, dup error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" &&
+		pgErr.ConstraintName == index {
+		return dup
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSourceKey(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Billancourt", "billancourt"},
+		{"  Évry   Sud ", "evry sud"},
+		{"SAINT-DENIS", "saint-denis"},
+		{"Châtillon\tçà", "chatillon ca"},
+	}
+
+	for _, tt := range tests {
+		if got := SourceKey(tt.name); got != tt.want {
+			t.Errorf("SourceKey(%q) = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	if got := Similarity("Évry", "evry"); got != 1 {
+		t.Errorf("got %v; want 1", got)
+	}
+	if got := Similarity("Billancourt", "Billancour"); got < SimilarityThreshold {
+		t.Errorf("got %v; want >= %v", got, SimilarityThreshold)
+	}
+	if got := Similarity("Billancourt", "Nanterre"); got >= SimilarityThreshold {
+		t.Errorf("got %v; want < %v", got, SimilarityThreshold)
+	}
+	if got := Similarity("", "Nanterre"); got != 0 {
+		t.Errorf("got %v; want 0", got)
+	}
+}
+
+func TestSourceNames(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	m := &SourceModel{DB: db}
+
+	id, err := m.SourceInsert(ctx, &Source{Name: "Évry Sud"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.SourceInsert(ctx, &Source{Name: "  evry   SUD"})
+	if !errors.Is(err, ErrDuplicateSource) {
+		t.Errorf("got %v; want ErrDuplicateSource", err)
+	}
+
+	other, err := m.SourceInsert(ctx, &Source{Name: "Nanterre"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.SourceUpdate(ctx, &Source{ID: other, Name: "EVRY sud"})
+	if !errors.Is(err, ErrDuplicateSource) {
+		t.Errorf("got %v; want ErrDuplicateSource", err)
+	}
+
+	similar, err := m.SourceSimilar(ctx, "Evry", 0)
+	if err != nil || len(similar) != 1 || similar[0].ID != id {
+		t.Errorf("got %+v, %v", similar, err)
+	}
+	if similar, _ = m.SourceSimilar(ctx, "Evry", id); len(similar) != 0 {
+		t.Errorf("got %+v; want the source left out", similar)
+	}
+
+	// A source in the trash frees its name, and can't
+	// come back while it's taken
+	if err = m.SourceDelete(ctx, id, "Dupont"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.SourceInsert(ctx, &Source{Name: "Evry sud"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.SourceRestore(ctx, id); !errors.Is(err, ErrDuplicateSource) {
+		t.Errorf("got %v; want ErrDuplicateSource", err)
+	}
+}
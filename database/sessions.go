@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// SessionStore keeps the scs sessions in PSQL so they
+// survive a restart. It implements scs.Store and scs.CtxStore
+type SessionStore struct {
+	DB DBTX
+}
+
+func (s *SessionStore) FindCtx(ctx context.Context, token string) ([]byte, bool, error) {
+	query := `
+SELECT data
+  FROM sessions
+    WHERE token = $1 AND expiry > NOW()
+`
+	var b []byte
+
+	err := s.DB.QueryRow(ctx, query, token).Scan(&b)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	return b, true, nil
+}
+
+func (s *SessionStore) CommitCtx(ctx context.Context, token string, b []byte, expiry time.Time) error {
+	query := `
+INSERT INTO sessions (token, data, expiry)
+VALUES ($1, $2, $3)
+  ON CONFLICT (token)
+    DO UPDATE SET data = EXCLUDED.data, expiry = EXCLUDED.expiry
+`
+	_, err := s.DB.Exec(ctx, query, token, b, expiry)
+
+	return err
+}
+
+func (s *SessionStore) DeleteCtx(ctx context.Context, token string) error {
+	query := `
+DELETE FROM sessions
+  WHERE token = $1
+`
+	_, err := s.DB.Exec(ctx, query, token)
+
+	return err
+}
+
+func (s *SessionStore) Find(token string) ([]byte, bool, error) {
+	return s.FindCtx(context.Background(), token)
+}
+
+func (s *SessionStore) Commit(token string, b []byte, expiry time.Time) error {
+	return s.CommitCtx(context.Background(), token, b, expiry)
+}
+
+func (s *SessionStore) Delete(token string) error {
+	return s.DeleteCtx(context.Background(), token)
+}
+
+// DeleteExpired removes the expired sessions,
+// main calls it regularly
+func (s *SessionStore) DeleteExpired(ctx context.Context) error {
+	query := `
+DELETE FROM sessions
+  WHERE expiry < NOW()
+`
+	_, err := s.DB.Exec(ctx, query)
+
+	return err
+}
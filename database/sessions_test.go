@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSessionStore(t *testing.T) {
+	ctx := context.Background()
+	s := &SessionStore{DB: newTestDB(t)}
+
+	err := s.CommitCtx(ctx, "abc", []byte("data"), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, found, err := s.FindCtx(ctx, "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found || string(b) != "data" {
+		t.Errorf("got %q, %v", b, found)
+	}
+
+	// Commit again updates the data
+	err = s.CommitCtx(ctx, "abc", []byte("new"), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _, _ = s.FindCtx(ctx, "abc")
+	if string(b) != "new" {
+		t.Errorf("got %q; want new", b)
+	}
+
+	if err = s.DeleteCtx(ctx, "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, found, _ = s.FindCtx(ctx, "abc"); found {
+		t.Error("session still found after delete")
+	}
+
+	// Expired sessions are not found and are cleaned
+	s.CommitCtx(ctx, "old", []byte("data"), time.Now().Add(-time.Hour))
+	if _, found, _ = s.FindCtx(ctx, "old"); found {
+		t.Error("expired session found")
+	}
+	if err = s.DeleteExpired(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v4"
-	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 type Source struct {
@@ -17,7 +16,47 @@ type Source struct {
 	Curatifs int    `json:"curatifs"` // Info
 	SID      int    `json:"-"`        // Infos source_id (FK)
 
+	// Open infos per tag name, filled for the dashboard
+	Tags map[string]int `json:"tags,omitempty"`
+
+	// Where the source is, Location is nil when it's not on the map
+	Address  string    `json:"-"`
+	Location *Location `json:"-"`
+
 	Created time.Time `json:"-"`
+
+	// Set when the source is in the trash
+	DeletedAt time.Time `json:"-"`
+	DeletedBy string    `json:"-"`
+}
+
+// Location is a point in degrees (WGS 84)
+type Location struct {
+	Lat float64
+	Lng float64
+}
+
+// location returns the point of the nullable columns
+// latitude and longitude
+func location(lat, lng *float64) *Location {
+	if lat == nil || lng == nil {
+		return nil
+	}
+	return &Location{Lat: *lat, Lng: *lng}
+}
+
+// coordinates returns the columns latitude and
+// longitude of l, NULL when l is nil
+func (l *Location) coordinates() (lat, lng *float64) {
+	if l == nil {
+		return nil, nil
+	}
+	return &l.Lat, &l.Lng
+}
+
+// SourceModel is the PSQL SourceStore
+type SourceModel struct {
+	DB DBTX
 }
 
 // Create json data so it can be sent to home page
@@ -33,19 +72,23 @@ func (jsrc *Source) JSource() ([]byte, error) {
 }
 
 // Date retrieve and sent to home page
-func (src *Source) MenuSource(conn *pgxpool.Conn) ([]*Source, error) {
-	ctx := context.Background()
+func (m *SourceModel) MenuSource(ctx context.Context) ([]*Source, error) {
 	query := `
 SELECT s.id,
        s.name,
+       s.address,
+       s.latitude,
+       s.longitude,
        COUNT(i.status) FILTER (WHERE i.status <> 'archived')
   FROM source AS s
        LEFT JOIN info AS i ON i.source_id = s.id
+                          AND i.deleted_at IS NULL
+  WHERE s.deleted_at IS NULL
   GROUP BY s.id
   ORDER BY name ASC
 `
 
-	rows, err := conn.Query(ctx, query)
+	rows, err := m.DB.Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -56,11 +99,14 @@ SELECT s.id,
 
 	for rows.Next() {
 		sObj := &Source{}
+		var lat, lng *float64
 
-		err := rows.Scan(&sObj.ID, &sObj.Name, &sObj.Curatifs)
+		err := rows.Scan(&sObj.ID, &sObj.Name, &sObj.Address, &lat, &lng,
+			&sObj.Curatifs)
 		if err != nil {
 			return nil, err
 		}
+		sObj.Location = location(lat, lng)
 
 		sources = append(sources, sObj)
 	}
@@ -73,16 +119,17 @@ SELECT s.id,
 }
 
 // Fetch source data to source view page
-func (src *Source) SourceGet(id int, conn *pgxpool.Conn) (*Source, error) {
-	ctx := context.Background()
+func (m *SourceModel) SourceGet(ctx context.Context, id int) (*Source, error) {
 	query := `
-SELECT id, name, created
+SELECT id, name, address, latitude, longitude, created
   FROM source
-    WHERE id = $1
+    WHERE id = $1 AND deleted_at IS NULL
 `
 	sObj := &Source{}
-	err := conn.QueryRow(ctx, query, id).Scan(&sObj.ID, &sObj.Name,
-		&sObj.Created)
+	var lat, lng *float64
+
+	err := m.DB.QueryRow(ctx, query, id).Scan(&sObj.ID, &sObj.Name,
+		&sObj.Address, &lat, &lng, &sObj.Created)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNoRecord
@@ -90,54 +137,386 @@ SELECT id, name, created
 			return nil, err
 		}
 	}
+	sObj.Location = location(lat, lng)
 
 	return sObj, nil
 }
 
 // Send source data to DB
-func (src *Source) SourceInsert(name string, conn *pgxpool.Conn) (int, error) {
-	ctx := context.Background()
+func (m *SourceModel) SourceInsert(ctx context.Context, src *Source) (int, error) {
 	query := `
-INSERT INTO source (name, created)
-VALUES ($1, $2)
+INSERT INTO source (name, created, address, latitude, longitude)
+VALUES ($1, $2, $3, $4, $5)
   RETURNING id
 `
-	err := conn.QueryRow(ctx, query, name,
-		time.Now().UTC()).Scan(&src.ID)
+	lat, lng := src.Location.coordinates()
+
+	err := m.DB.QueryRow(ctx, query, src.Name, time.Now().UTC(),
+		src.Address, lat, lng).Scan(&src.ID)
 	if err != nil {
-		return 0, nil
+		return 0, duplicateSource(err)
 	}
 
 	return src.ID, nil
 }
 
-// Delete source
-func (src *Source) SourceDelete(id int, conn *pgxpool.Conn) error {
-	ctx := context.Background()
+// Lock the source row until the end of the transaction so
+// no info can be attached to it meanwhile
+func (m *SourceModel) SourceLock(ctx context.Context, id int) error {
 	query := `
-DELETE FROM source
-  WHERE id = $1
+SELECT id
+  FROM source
+    WHERE id = $1 AND deleted_at IS NULL
+  FOR UPDATE
 `
-	_, err := conn.Exec(ctx, query, id)
+	err := m.DB.QueryRow(ctx, query, id).Scan(&id)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNoRecord
+		} else {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Move the source to the trash, by is who deleted it.
+// Its archived infos go with it, if it still has open
+// infos ErrSourceNotEmpty is returned
+func (m *SourceModel) SourceDelete(ctx context.Context, id int, by string) error {
+	query := `
+SELECT COUNT(*)
+  FROM info
+    WHERE source_id = $1 AND deleted_at IS NULL
+      AND status <> 'archived'
+`
+	update := `
+UPDATE source
+  SET deleted_at = $2, deleted_by = $3
+    WHERE id = $1
+`
+	// The lock keeps new infos out until the source is deleted
+	return WithTx(ctx, m.DB, func(tx pgx.Tx) error {
+		err := (&SourceModel{DB: tx}).SourceLock(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		var open int
+		if err = tx.QueryRow(ctx, query, id).Scan(&open); err != nil {
+			return err
+		}
+		if open > 0 {
+			return ErrSourceNotEmpty
+		}
+
+		_, err = tx.Exec(ctx, update, id, time.Now().UTC(), by)
 		return err
+	})
+}
+
+// Update source name and location, src.ID is the row updated
+func (m *SourceModel) SourceUpdate(ctx context.Context, src *Source) error {
+	query := `
+UPDATE source
+  SET name = $1, address = $3, latitude = $4, longitude = $5
+    WHERE id = $2 AND deleted_at IS NULL
+`
+	lat, lng := src.Location.coordinates()
+
+	tag, err := m.DB.Exec(ctx, query, src.Name, src.ID, src.Address,
+		lat, lng)
+	if err != nil {
+		return duplicateSource(err)
+	}
+
+	// Nothing changed, the id doesn't exist
+	if tag.RowsAffected() == 0 {
+		return ErrNoRecord
 	}
 
 	return nil
 }
 
-// Update source name
-func (src *Source) SourceUpdate(id int, conn *pgxpool.Conn) error {
-	ctx := context.Background()
+// Sources whose name looks like name, the closest first.
+// excludeID is left out (the source being updated)
+func (m *SourceModel) SourceSimilar(ctx context.Context, name string, excludeID int) ([]*Source, error) {
+	query := `
+SELECT id, name
+  FROM source
+    WHERE deleted_at IS NULL AND id <> $2
+      AND similarity(source_key(name), source_key($1)) >= $3
+        ORDER BY similarity(source_key(name), source_key($1)) DESC, name
+          LIMIT 5
+`
+	rows, err := m.DB.Query(ctx, query, name, excludeID, SimilarityThreshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := []*Source{}
+
+	for rows.Next() {
+		sObj := &Source{}
+
+		err := rows.Scan(&sObj.ID, &sObj.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, sObj)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sources, nil
+}
+
+//
+// Merge
+//
+
+// Merge the source fromID into toID: its infos (archived and in
+// the trash too), aliases and history go to toID, then it's
+// deleted. Its name and id are kept as an alias of toID
+func (m *SourceModel) SourceMerge(ctx context.Context, fromID, toID int) error {
+	if fromID == toID {
+		return ErrSameSource
+	}
+
+	// The .ics feed follows the source, unless the other
+	// one already has its own (one feed a source)
+	moves := []string{`
+UPDATE info
+  SET source_id = $2
+    WHERE source_id = $1
+`, `
+UPDATE source_alias
+  SET source_id = $2
+    WHERE source_id = $1
+`, `
+UPDATE equipment
+  SET source_id = $2
+    WHERE source_id = $1
+`, `
+UPDATE audit
+  SET source_id = $2
+    WHERE source_id = $1
+`, `
+DELETE FROM calendar_feed
+  WHERE source_id = $1
+    AND EXISTS (SELECT 1 FROM calendar_feed WHERE source_id = $2)
+`, `
+UPDATE calendar_feed
+  SET source_id = $2
+    WHERE source_id = $1
+`}
+
+	alias := `
+INSERT INTO source_alias (source_id, old_id, name, created)
+  SELECT $2, id, name, $3
+    FROM source
+      WHERE id = $1
+`
+	remove := `
+DELETE FROM source
+  WHERE id = $1
+`
+
+	return WithTx(ctx, m.DB, func(tx pgx.Tx) error {
+		src := &SourceModel{DB: tx}
+
+		// Always in the same order, two merges
+		// can't wait for each other
+		first, second := min(fromID, toID), max(fromID, toID)
+		if err := src.SourceLock(ctx, first); err != nil {
+			return err
+		}
+		if err := src.SourceLock(ctx, second); err != nil {
+			return err
+		}
+
+		for _, query := range moves {
+			if _, err := tx.Exec(ctx, query, fromID, toID); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Exec(ctx, alias, fromID, toID, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, remove, fromID)
+		return err
+	})
+}
+
+// Names of the sources merged into id
+func (m *SourceModel) SourceAliases(ctx context.Context, id int) ([]string, error) {
+	query := `
+SELECT name
+  FROM source_alias
+    WHERE source_id = $1
+  ORDER BY name ASC
+`
+	rows, err := m.DB.Query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// Id of the source oldID was merged into
+func (m *SourceModel) SourceAlias(ctx context.Context, oldID int) (int, error) {
+	query := `
+SELECT source_id
+  FROM source_alias
+    WHERE old_id = $1
+`
+	var id int
+
+	err := m.DB.QueryRow(ctx, query, oldID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrNoRecord
+		} else {
+			return 0, err
+		}
+	}
+
+	return id, nil
+}
+
+//
+// Trash
+//
+
+// Sources in the trash, the last deleted first
+func (m *SourceModel) SourceTrash(ctx context.Context) ([]*Source, error) {
+	query := `
+SELECT id, name, created, deleted_at, deleted_by
+  FROM source
+    WHERE deleted_at IS NOT NULL
+  ORDER BY deleted_at DESC
+`
+	rows, err := m.DB.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := []*Source{}
+
+	for rows.Next() {
+		sObj := &Source{}
+
+		err := rows.Scan(&sObj.ID, &sObj.Name, &sObj.Created,
+			&sObj.DeletedAt, &sObj.DeletedBy)
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, sObj)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sources, nil
+}
+
+// Take the source out of the trash
+func (m *SourceModel) SourceRestore(ctx context.Context, id int) error {
 	query := `
 UPDATE source
-  SET name = $1
-    WHERE id = $2
+  SET deleted_at = NULL, deleted_by = ''
+    WHERE id = $1 AND deleted_at IS NOT NULL
+`
+	tag, err := m.DB.Exec(ctx, query, id)
+	if err != nil {
+		// Another source took its name meanwhile
+		return duplicateSource(err)
+	}
+
+	// Not in the trash
+	if tag.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// Delete for good a source of the trash with its infos
+// (archived ones or in the trash too)
+func (m *SourceModel) SourcePurge(ctx context.Context, id int) error {
+	query := `
+WITH s AS (
+  DELETE FROM source
+    WHERE id = $1 AND deleted_at IS NOT NULL
+  RETURNING id
+), i AS (
+  DELETE FROM info
+    WHERE source_id IN (SELECT id FROM s)
+)
+SELECT COUNT(*) FROM s
 `
-	_, err := conn.Exec(ctx, query, src.Name, id)
+	var count int
+
+	err := m.DB.QueryRow(ctx, query, id).Scan(&count)
 	if err != nil {
 		return err
 	}
 
+	// Not in the trash
+	if count == 0 {
+		return ErrNoRecord
+	}
+
 	return nil
 }
+
+// Delete for good the sources put in the trash before t,
+// and their infos. Returns the number of sources deleted
+func (m *SourceModel) SourcePurgeBefore(ctx context.Context, t time.Time) (int, error) {
+	query := `
+WITH s AS (
+  DELETE FROM source
+    WHERE deleted_at < $1
+  RETURNING id
+), i AS (
+  DELETE FROM info
+    WHERE source_id IN (SELECT id FROM s)
+)
+SELECT COUNT(*) FROM s
+`
+	var count int
+
+	err := m.DB.QueryRow(ctx, query, t.UTC()).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSourceModel(t *testing.T) {
+	ctx := context.Background()
+	m := &SourceModel{DB: newTestDB(t)}
+
+	id, err := m.SourceInsert(ctx, &Source{Name: "Billancourt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id < 1 {
+		t.Fatalf("got id %d; want > 0", id)
+	}
+
+	src, err := m.SourceGet(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Name != "Billancourt" {
+		t.Errorf("got name %q; want %q", src.Name, "Billancourt")
+	}
+	if src.Created.IsZero() {
+		t.Error("created is not set")
+	}
+
+	err = m.SourceUpdate(ctx, &Source{ID: id, Name: "Nanterre"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err = m.SourceGet(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Name != "Nanterre" {
+		t.Errorf("got name %q; want %q", src.Name, "Nanterre")
+	}
+
+	if err = m.SourceDelete(ctx, id, "Dupont"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.SourceGet(ctx, id)
+	if !errors.Is(err, ErrNoRecord) {
+		t.Errorf("got %v; want ErrNoRecord", err)
+	}
+}
+
+func TestSourceModelNoRecord(t *testing.T) {
+	ctx := context.Background()
+	m := &SourceModel{DB: newTestDB(t)}
+
+	if _, err := m.SourceGet(ctx, 42); !errors.Is(err, ErrNoRecord) {
+		t.Errorf("SourceGet: got %v; want ErrNoRecord", err)
+	}
+	if err := m.SourceUpdate(ctx, &Source{ID: 42, Name: "x"}); !errors.Is(err, ErrNoRecord) {
+		t.Errorf("SourceUpdate: got %v; want ErrNoRecord", err)
+	}
+	if err := m.SourceDelete(ctx, 42, "Dupont"); !errors.Is(err, ErrNoRecord) {
+		t.Errorf("SourceDelete: got %v; want ErrNoRecord", err)
+	}
+	if err := m.SourceLock(ctx, 42); !errors.Is(err, ErrNoRecord) {
+		t.Errorf("SourceLock: got %v; want ErrNoRecord", err)
+	}
+}
+
+func TestSourceLocation(t *testing.T) {
+	ctx := context.Background()
+	m := &SourceModel{DB: newTestDB(t)}
+
+	id, err := m.SourceInsert(ctx, &Source{Name: "Billancourt",
+		Address: "1 quai de Stalingrad", Location: &Location{Lat: 48.8339, Lng: 2.241}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := m.SourceGet(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Address != "1 quai de Stalingrad" {
+		t.Errorf("got address %q", src.Address)
+	}
+	if src.Location == nil || *src.Location != (Location{Lat: 48.8339, Lng: 2.241}) {
+		t.Errorf("got location %+v", src.Location)
+	}
+
+	menu, err := m.MenuSource(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if menu[0].Location == nil {
+		t.Error("no location in the menu")
+	}
+
+	// Off the map
+	err = m.SourceUpdate(ctx, &Source{ID: id, Name: "Billancourt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, _ = m.SourceGet(ctx, id)
+	if src.Location != nil || src.Address != "" {
+		t.Errorf("got %q %+v; want none", src.Address, src.Location)
+	}
+
+	// source_location_check
+	err = m.SourceUpdate(ctx, &Source{ID: id, Name: "Billancourt",
+		Location: &Location{Lat: 91, Lng: 2.241}})
+	if err == nil {
+		t.Error("latitude 91 saved")
+	}
+}
+
+func TestMenuSource(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	sources := &SourceModel{DB: db}
+	infos := &InfoModel{DB: db}
+
+	b, _ := sources.SourceInsert(ctx, &Source{Name: "B"})
+	a, _ := sources.SourceInsert(ctx, &Source{Name: "A"})
+
+	for _, status := range []string{"waiting", "done", "archived"} {
+		_, err := infos.InfoInsert(ctx, &Info{SourceID: b, Agent: "x",
+			Material: "x", Detail: "x", Priority: 1, Status: status})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	menu, err := sources.MenuSource(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(menu) != 2 {
+		t.Fatalf("got %d sources; want 2", len(menu))
+	}
+	if menu[0].ID != a || menu[1].ID != b {
+		t.Errorf("sources are not sorted by name")
+	}
+	// archived infos are not counted
+	if menu[1].Curatifs != 2 {
+		t.Errorf("got %d curatifs; want 2", menu[1].Curatifs)
+	}
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// The handlers only know these interfaces. PGStore talks to PSQL,
+// database/memory keeps everything in maps (used by the tests).
+// Delete moves a row to the trash, only the Trash methods
+// see it until it's restored or purged
+
+type SourceStore interface {
+	MenuSource(ctx context.Context) ([]*Source, error)
+	SourceGet(ctx context.Context, id int) (*Source, error)
+	SourceInsert(ctx context.Context, src *Source) (int, error)
+	SourceUpdate(ctx context.Context, src *Source) error
+	SourceDelete(ctx context.Context, id int, by string) error
+	SourceLock(ctx context.Context, id int) error
+	SourceSimilar(ctx context.Context, name string, excludeID int) ([]*Source, error)
+
+	// Merge
+	SourceMerge(ctx context.Context, fromID, toID int) error
+	SourceAliases(ctx context.Context, id int) ([]string, error)
+	SourceAlias(ctx context.Context, oldID int) (int, error)
+
+	// Trash
+	SourceTrash(ctx context.Context) ([]*Source, error)
+	SourceRestore(ctx context.Context, id int) error
+	SourcePurge(ctx context.Context, id int) error
+	SourcePurgeBefore(ctx context.Context, t time.Time) (int, error)
+}
+
+type InfoStore interface {
+	InfoInsert(ctx context.Context, info *Info) (int, error)
+	InfoGet(ctx context.Context, id int) (*Info, error)
+	InfoList(ctx context.Context, sourceID int) ([]*Info, error)
+	InfoSimilar(ctx context.Context, sourceID int, material, detail string) ([]*Info, error)
+	InfoListEquipment(ctx context.Context, equipmentIDs []int) ([]*Info, error)
+	InfoSearch(ctx context.Context, q string, tagID int) ([]*Info, error)
+	InfoListAgent(ctx context.Context, agentID int) ([]*Info, error)
+	InfoListAssigned(ctx context.Context, agentID int, team string) ([]*Info, error)
+	InfoAssign(ctx context.Context, id, assigneeID int, team string) error
+	InfoBoard(ctx context.Context, sourceID, agentID int, archived bool) ([]*Info, error)
+	InfoCalendar(ctx context.Context, from, to time.Time, sourceID, agentID int) ([]*Info, error)
+	InfoUpdate(ctx context.Context, info *Info) error
+	InfoDelete(ctx context.Context, id int, by string) error
+	StatusCount(ctx context.Context) (map[string]int, error)
+	InfoMove(ctx context.Context, id, sourceID int) error
+	InfoMoveAll(ctx context.Context, fromID, toID int) (int, error)
+	InfoArchiveAll(ctx context.Context, sourceID int) (int, error)
+
+	// Trash
+	InfoTrash(ctx context.Context) ([]*Info, error)
+	InfoRestore(ctx context.Context, id int) error
+	InfoPurge(ctx context.Context, id int) error
+	InfoPurgeBefore(ctx context.Context, t time.Time) (int, error)
+}
+
+type EquipmentStore interface {
+	EquipmentList(ctx context.Context, sourceID int) ([]*Equipment, error)
+	EquipmentGet(ctx context.Context, id int) (*Equipment, error)
+	EquipmentInsert(ctx context.Context, e *Equipment) (int, error)
+	EquipmentUpdate(ctx context.Context, e *Equipment) error
+	EquipmentDelete(ctx context.Context, id int) error
+}
+
+type TagStore interface {
+	TagList(ctx context.Context) ([]*Tag, error)
+	TagGet(ctx context.Context, id int) (*Tag, error)
+	TagInsert(ctx context.Context, tag *Tag) (int, error)
+	TagUpdate(ctx context.Context, tag *Tag) error
+	TagDelete(ctx context.Context, id int) error
+
+	TagsOfInfos(ctx context.Context, infoIDs []int) (map[int][]*Tag, error)
+	TagsSet(ctx context.Context, infoID int, tagIDs []int) error
+	TagCounts(ctx context.Context) (map[int]map[string]int, error)
+}
+
+type AgentStore interface {
+	AgentList(ctx context.Context) ([]*Agent, error)
+	AgentGet(ctx context.Context, id int) (*Agent, error)
+	AgentInsert(ctx context.Context, agent *Agent) (int, error)
+	AgentUpdate(ctx context.Context, agent *Agent) error
+}
+
+type FeedStore interface {
+	FeedGet(ctx context.Context, token string) (*Feed, error)
+	FeedFind(ctx context.Context, agentID, sourceID int) (*Feed, error)
+	FeedRenew(ctx context.Context, feed *Feed) error
+}
+
+type AuditStore interface {
+	AuditInsert(ctx context.Context, e *AuditEntry) error
+	AuditList(ctx context.Context, sourceID int) ([]*AuditEntry, error)
+	AuditListInfo(ctx context.Context, infoID int) ([]*AuditEntry, error)
+}
+
+// Store gives access to every repository.
+// Inside WithTx, the Store passed to fn runs all its
+// queries in the same transaction
+type Store interface {
+	Sources() SourceStore
+	Infos() InfoStore
+	Equipment() EquipmentStore
+	Tags() TagStore
+	Agents() AgentStore
+	Feeds() FeedStore
+	Audit() AuditStore
+
+	WithTx(ctx context.Context, fn func(tx Store) error) error
+}
+
+// PGStore is the PostgreSQL Store
+type PGStore struct {
+	db DBTX
+}
+
+// NewPGStore works with a pool, a connection or a transaction
+func NewPGStore(db DBTX) *PGStore {
+	return &PGStore{db: db}
+}
+
+func (s *PGStore) Sources() SourceStore {
+	return &SourceModel{DB: s.db}
+}
+
+func (s *PGStore) Infos() InfoStore {
+	return &InfoModel{DB: s.db}
+}
+
+func (s *PGStore) Equipment() EquipmentStore {
+	return &EquipmentModel{DB: s.db}
+}
+
+func (s *PGStore) Tags() TagStore {
+	return &TagModel{DB: s.db}
+}
+
+func (s *PGStore) Agents() AgentStore {
+	return &AgentModel{DB: s.db}
+}
+
+func (s *PGStore) Feeds() FeedStore {
+	return &FeedModel{DB: s.db}
+}
+
+func (s *PGStore) Audit() AuditStore {
+	return &AuditModel{DB: s.db}
+}
+
+// WithTx @ database/db.go. Called inside a transaction
+// it creates a savepoint
+func (s *PGStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	return WithTx(ctx, s.db, func(tx pgx.Tx) error {
+		return fn(&PGStore{db: tx})
+	})
+}
@@ -0,0 +1,256 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// Tag classifies infos. Color is #rrggbb
+type Tag struct {
+	ID      int
+	Name    string
+	Color   string
+	Created time.Time
+
+	// Infos (not in the trash) with the tag, set by TagList
+	Count int
+}
+
+// TagModel is the PSQL TagStore
+type TagModel struct {
+	DB DBTX
+}
+
+// Every tag by name, with the number of infos
+func (m *TagModel) TagList(ctx context.Context) ([]*Tag, error) {
+	query := `
+SELECT t.id, t.name, t.color, t.created, COUNT(i.id)
+  FROM tag AS t
+       LEFT JOIN info_tag AS it ON it.tag_id = t.id
+       LEFT JOIN info AS i ON i.id = it.info_id
+                          AND i.deleted_at IS NULL
+  GROUP BY t.id
+  ORDER BY lower(t.name)
+`
+	rows, err := m.DB.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*Tag{}
+
+	for rows.Next() {
+		tObj := &Tag{}
+
+		err = rows.Scan(&tObj.ID, &tObj.Name, &tObj.Color,
+			&tObj.Created, &tObj.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tObj)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (m *TagModel) TagGet(ctx context.Context, id int) (*Tag, error) {
+	query := `
+SELECT id, name, color, created
+  FROM tag
+    WHERE id = $1
+`
+	tObj := &Tag{}
+	err := m.DB.QueryRow(ctx, query, id).Scan(&tObj.ID, &tObj.Name,
+		&tObj.Color, &tObj.Created)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return tObj, nil
+}
+
+func (m *TagModel) TagInsert(ctx context.Context, tag *Tag) (int, error) {
+	query := `
+INSERT INTO tag (name, color, created)
+VALUES ($1, $2, $3)
+  RETURNING id
+`
+	err := m.DB.QueryRow(ctx, query, tag.Name, tag.Color,
+		time.Now().UTC()).Scan(&tag.ID)
+	if err != nil {
+		return 0, uniqueViolation(err, "tag_name_idx", ErrDuplicateTag)
+	}
+
+	return tag.ID, nil
+}
+
+func (m *TagModel) TagUpdate(ctx context.Context, tag *Tag) error {
+	query := `
+UPDATE tag
+  SET name = $2, color = $3
+    WHERE id = $1
+`
+	res, err := m.DB.Exec(ctx, query, tag.ID, tag.Name, tag.Color)
+	if err != nil {
+		return uniqueViolation(err, "tag_name_idx", ErrDuplicateTag)
+	}
+
+	// Nothing changed, the id doesn't exist
+	if res.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// The infos only lose the tag (ON DELETE CASCADE of info_tag)
+func (m *TagModel) TagDelete(ctx context.Context, id int) error {
+	query := `
+DELETE FROM tag
+  WHERE id = $1
+`
+	res, err := m.DB.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// Tags of each info given, by name: info ID ~> tags
+func (m *TagModel) TagsOfInfos(ctx context.Context, infoIDs []int) (map[int][]*Tag, error) {
+	query := `
+SELECT it.info_id, t.id, t.name, t.color, t.created
+  FROM info_tag AS it
+       JOIN tag AS t ON t.id = it.tag_id
+    WHERE it.info_id = ANY($1)
+  ORDER BY lower(t.name)
+`
+	rows, err := m.DB.Query(ctx, query, infoIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := map[int][]*Tag{}
+
+	for rows.Next() {
+		var infoID int
+		tObj := &Tag{}
+
+		err = rows.Scan(&infoID, &tObj.ID, &tObj.Name, &tObj.Color,
+			&tObj.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		tags[infoID] = append(tags[infoID], tObj)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// The info has exactly the tags given afterwards. An unknown
+// tag is ErrNoRecord
+func (m *TagModel) TagsSet(ctx context.Context, infoID int, tagIDs []int) error {
+	remove := `
+DELETE FROM info_tag
+  WHERE info_id = $1 AND tag_id <> ALL($2)
+`
+	add := `
+INSERT INTO info_tag (info_id, tag_id)
+  SELECT $1, t.id
+    FROM tag AS t
+      WHERE t.id = ANY($2)
+ON CONFLICT DO NOTHING
+`
+	count := `
+SELECT COUNT(*) FROM tag WHERE id = ANY($1)
+`
+	// nil would be sent as NULL, and <> ALL(NULL) is never true
+	if tagIDs == nil {
+		tagIDs = []int{}
+	}
+
+	return WithTx(ctx, m.DB, func(tx pgx.Tx) error {
+		// ANY() ignores the duplicates
+		unique := map[int]bool{}
+		for _, id := range tagIDs {
+			unique[id] = true
+		}
+
+		var n int
+		if err := tx.QueryRow(ctx, count, tagIDs).Scan(&n); err != nil {
+			return err
+		}
+		if n != len(unique) {
+			return ErrNoRecord
+		}
+
+		if _, err := tx.Exec(ctx, remove, infoID, tagIDs); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, add, infoID, tagIDs)
+		return err
+	})
+}
+
+// Open infos (not archived) of each source by tag name,
+// for the dashboard: source ID ~> tag ~> count
+func (m *TagModel) TagCounts(ctx context.Context) (map[int]map[string]int, error) {
+	query := `
+SELECT i.source_id, t.name, COUNT(*)
+  FROM info_tag AS it
+       JOIN info AS i ON i.id = it.info_id
+       JOIN tag AS t ON t.id = it.tag_id
+    WHERE i.deleted_at IS NULL AND i.status <> 'archived'
+  GROUP BY i.source_id, t.name
+`
+	rows, err := m.DB.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[int]map[string]int{}
+
+	for rows.Next() {
+		var sourceID, n int
+		var name string
+
+		if err = rows.Scan(&sourceID, &name, &n); err != nil {
+			return nil, err
+		}
+
+		if counts[sourceID] == nil {
+			counts[sourceID] = map[string]int{}
+		}
+		counts[sourceID][name] = n
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTagModel(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	sources := &SourceModel{DB: db}
+	infos := &InfoModel{DB: db}
+	m := &TagModel{DB: db}
+
+	sID, err := sources.SourceInsert(ctx, &Source{Name: "Billancourt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	open, err := infos.InfoInsert(ctx, &Info{SourceID: sID, Agent: "Dupont",
+		Material: "Transfo 1", Detail: "Oil leak", Priority: 1, Status: "waiting"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	archived, err := infos.InfoInsert(ctx, &Info{SourceID: sID, Agent: "Dupont",
+		Material: "Transfo 2", Detail: "Noise", Priority: 1, Status: "archived"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	safety, err := m.TagInsert(ctx, &Tag{Name: "Safety", Color: "#c0392b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	civil, err := m.TagInsert(ctx, &Tag{Name: "Civil works", Color: "#7f8c8d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Names are unique whatever the case
+	_, err = m.TagInsert(ctx, &Tag{Name: "SAFETY", Color: "#c0392b"})
+	if !errors.Is(err, ErrDuplicateTag) {
+		t.Errorf("got %v; want ErrDuplicateTag", err)
+	}
+	err = m.TagUpdate(ctx, &Tag{ID: civil, Name: "safety", Color: "#7f8c8d"})
+	if !errors.Is(err, ErrDuplicateTag) {
+		t.Errorf("got %v; want ErrDuplicateTag", err)
+	}
+
+	if err = m.TagsSet(ctx, open, []int{safety, civil}); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.TagsSet(ctx, archived, []int{safety}); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.TagsSet(ctx, open, []int{42}); !errors.Is(err, ErrNoRecord) {
+		t.Errorf("got %v; want ErrNoRecord", err)
+	}
+
+	of, err := m.TagsOfInfos(ctx, []int{open, archived})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(of[open]) != 2 || len(of[archived]) != 1 {
+		t.Errorf("got %+v", of)
+	}
+
+	// Archived infos aren't counted on the dashboard
+	counts, err := m.TagCounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[sID]["Safety"] != 1 || counts[sID]["Civil works"] != 1 {
+		t.Errorf("got %v", counts)
+	}
+
+	found, err := infos.InfoSearch(ctx, "transfo", safety)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found[0].SourceName != "Billancourt" {
+		t.Errorf("got %+v", found)
+	}
+
+	// The infos only lose the tag
+	if err = m.TagDelete(ctx, safety); err != nil {
+		t.Fatal(err)
+	}
+	of, err = m.TagsOfInfos(ctx, []int{open})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(of[open]) != 1 || of[open][0].ID != civil {
+		t.Errorf("got %+v", of[open])
+	}
+	if err = m.TagDelete(ctx, safety); !errors.Is(err, ErrNoRecord) {
+		t.Errorf("got %v; want ErrNoRecord", err)
+	}
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// The tests of this package need a real PSQL.
+// TestMain starts a throwaway one with initdb/pg_ctl (found in
+// $PATH or /usr/lib/postgresql/*/bin) inside a temp directory,
+// or uses CURATOR_TEST_DATABASE_URL if set (the database is
+// emptied, don't point it to real data).
+// Without any of them the tests are skipped, but in CI (CI set,
+// as GitHub Actions and GitLab do) the URL is required: the SQL
+// must not go untested there
+
+const testURLEnv = "CURATOR_TEST_DATABASE_URL"
+
+var (
+	testDB         *pgxpool.Pool
+	testSkipReason string
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(runTests(m))
+}
+
+func runTests(m *testing.M) int {
+	url := os.Getenv(testURLEnv)
+
+	if url == "" && os.Getenv("CI") != "" {
+		fmt.Fprintf(os.Stderr, "%s is required in CI\n", testURLEnv)
+		return 1
+	}
+
+	if url == "" {
+		dir, err := os.MkdirTemp("", "curator-pg-")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer os.RemoveAll(dir)
+
+		var stop func()
+		url, stop, err = startPostgres(dir)
+		if err != nil {
+			testSkipReason = err.Error()
+			return m.Run()
+		}
+		defer stop()
+	}
+
+	ctx := context.Background()
+
+	db, err := pgxpool.Connect(ctx, url)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer db.Close()
+
+	if err = Migrate(ctx, db); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	testDB = db
+
+	return m.Run()
+}
+
+// startPostgres creates a new cluster in dir and starts it
+// on a free port. stop shuts it down
+func startPostgres(dir string) (string, func(), error) {
+	if os.Geteuid() == 0 {
+		return "", nil, fmt.Errorf("PSQL can't be started as root, set %s",
+			testURLEnv)
+	}
+
+	initdb, err := findPGBinary("initdb")
+	if err != nil {
+		return "", nil, err
+	}
+	pgctl, err := findPGBinary("pg_ctl")
+	if err != nil {
+		return "", nil, err
+	}
+
+	data := filepath.Join(dir, "data")
+
+	out, err := exec.Command(initdb, "-D", data, "-U", "curator",
+		"-A", "trust", "-E", "UTF8").CombinedOutput()
+	if err != nil {
+		return "", nil, fmt.Errorf("initdb: %s: %s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return "", nil, err
+	}
+
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -F",
+		port, dir)
+	out, err = exec.Command(pgctl, "-D", data, "-o", opts,
+		"-l", filepath.Join(dir, "postgres.log"), "-w", "start").CombinedOutput()
+	if err != nil {
+		return "", nil, fmt.Errorf("pg_ctl start: %s: %s", err, out)
+	}
+
+	stop := func() {
+		exec.Command(pgctl, "-D", data, "-m", "immediate", "stop").Run()
+	}
+
+	url := fmt.Sprintf("postgres://curator@127.0.0.1:%d/postgres?sslmode=disable",
+		port)
+
+	return url, stop, nil
+}
+
+func findPGBinary(name string) (string, error) {
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+
+	matches, _ := filepath.Glob(filepath.Join("/usr/lib/postgresql/*/bin", name))
+	if len(matches) > 0 {
+		return matches[len(matches)-1], nil
+	}
+
+	return "", fmt.Errorf("%s not found, install PSQL or set %s",
+		name, testURLEnv)
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// newTestDB returns the test pool with empty tables
+func newTestDB(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	if testDB == nil {
+		t.Skipf("no test database: %s", testSkipReason)
+	}
+
+	_, err := testDB.Exec(context.Background(),
+		"TRUNCATE source, info, sessions, source_alias, audit, equipment, tag, info_tag, agent, calendar_feed RESTART IDENTITY CASCADE")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testDB
+}
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	sources := &SourceModel{DB: db}
+	infos := &InfoModel{DB: db}
+
+	sID, err := sources.SourceInsert(ctx, &Source{Name: "Billancourt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	iID, err := infos.InfoInsert(ctx, &Info{SourceID: sID, Agent: "Dupont",
+		Material: "Transfo 1", Detail: "Oil leak", Priority: 1, Status: "waiting"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An info in the trash is hidden everywhere else
+	if err = infos.InfoDelete(ctx, iID, "Dupont"); err != nil {
+		t.Fatal(err)
+	}
+
+	if list, _ := infos.InfoList(ctx, sID); len(list) != 0 {
+		t.Errorf("got %d infos; want 0", len(list))
+	}
+
+	menu, _ := sources.MenuSource(ctx)
+	if len(menu) != 1 || menu[0].Curatifs != 0 {
+		t.Errorf("got menu %+v", menu)
+	}
+
+	trash, err := infos.InfoTrash(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash) != 1 || trash[0].DeletedBy != "Dupont" ||
+		trash[0].SourceName != "Billancourt" || trash[0].DeletedAt.IsZero() {
+		t.Fatalf("got trash %+v", trash)
+	}
+
+	// Then the source, restoring the info restores the source
+	if err = sources.SourceDelete(ctx, sID, "Martin"); err != nil {
+		t.Fatal(err)
+	}
+	if menu, _ = sources.MenuSource(ctx); len(menu) != 0 {
+		t.Errorf("got menu %+v; want empty", menu)
+	}
+
+	if err = infos.InfoRestore(ctx, iID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = sources.SourceGet(ctx, sID); err != nil {
+		t.Errorf("source not restored: %v", err)
+	}
+	if _, err = infos.InfoGet(ctx, iID); err != nil {
+		t.Errorf("info not restored: %v", err)
+	}
+	if err = infos.InfoRestore(ctx, iID); !errors.Is(err, ErrNoRecord) {
+		t.Errorf("got %v; want ErrNoRecord", err)
+	}
+
+	// Purge only works on the trash
+	if err = sources.SourcePurge(ctx, sID); !errors.Is(err, ErrNoRecord) {
+		t.Errorf("got %v; want ErrNoRecord", err)
+	}
+	if err = infos.InfoPurge(ctx, iID); !errors.Is(err, ErrNoRecord) {
+		t.Errorf("got %v; want ErrNoRecord", err)
+	}
+
+	// A purged source takes its infos with it
+	infos.InfoDelete(ctx, iID, "Dupont")
+	sources.SourceDelete(ctx, sID, "Dupont")
+
+	if err = sources.SourcePurge(ctx, sID); err != nil {
+		t.Fatal(err)
+	}
+	if trash, _ = infos.InfoTrash(ctx); len(trash) != 0 {
+		t.Errorf("got trash %+v; want empty", trash)
+	}
+}
+
+func TestPurgeBefore(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	sources := &SourceModel{DB: db}
+	infos := &InfoModel{DB: db}
+
+	sID, _ := sources.SourceInsert(ctx, &Source{Name: "Billancourt"})
+	iID, _ := infos.InfoInsert(ctx, &Info{SourceID: sID, Agent: "Dupont",
+		Material: "Transfo 1", Detail: "Oil leak", Priority: 1, Status: "waiting"})
+
+	infos.InfoDelete(ctx, iID, "Dupont")
+
+	// Too recent
+	n, err := infos.InfoPurgeBefore(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("got %d purged; want 0", n)
+	}
+
+	sources.SourceDelete(ctx, sID, "Dupont")
+
+	n, err = sources.SourcePurgeBefore(ctx, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("got %d sources purged; want 1", n)
+	}
+	if trash, _ := infos.InfoTrash(ctx); len(trash) != 0 {
+		t.Errorf("got trash %+v; want empty", trash)
+	}
+}
+
+func TestSourceDeleteOpenInfos(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	sources := &SourceModel{DB: db}
+	infos := &InfoModel{DB: db}
+
+	from, _ := sources.SourceInsert(ctx, &Source{Name: "Billancourt"})
+	to, _ := sources.SourceInsert(ctx, &Source{Name: "Nanterre"})
+	infos.InfoInsert(ctx, &Info{SourceID: from, Agent: "Dupont",
+		Material: "Transfo 1", Detail: "Oil leak", Priority: 1, Status: "waiting"})
+
+	err := sources.SourceDelete(ctx, from, "Dupont")
+	if !errors.Is(err, ErrSourceNotEmpty) {
+		t.Fatalf("got %v; want ErrSourceNotEmpty", err)
+	}
+
+	// Archived infos don't keep the source
+	n, err := infos.InfoArchiveAll(ctx, from)
+	if err != nil || n != 1 {
+		t.Fatalf("got %d, %v; want 1 info archived", n, err)
+	}
+	if err = sources.SourceDelete(ctx, from, "Dupont"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Move back the archived info to a live source
+	n, err = infos.InfoMoveAll(ctx, from, to)
+	if err != nil || n != 1 {
+		t.Fatalf("got %d, %v; want 1 info moved", n, err)
+	}
+	if list, _ := infos.InfoList(ctx, to); len(list) != 1 {
+		t.Errorf("got %d infos; want 1", len(list))
+	}
+}
@@ -0,0 +1,251 @@
+package i18n
+
+// catalogs[lang][English message] = translation.
+// English needs no catalog, the messages are already in English
+var catalogs = map[string]map[string]string{
+	French: {
+		// Pages
+		"Home":             "Accueil",
+		"New source":       "Nouveau poste source",
+		"New info":         "Nouvelle info",
+		"Source name:":     "Nom du poste source :",
+		"Ex.: Billancourt": "Ex. : Billancourt",
+		"Search status...": "Chercher des status...",
+		"Create Info":      "Créer une info",
+		"Material":         "Ouvrage",
+		"Priority":         "Priorité",
+		"Status":           "Statut",
+		"Status:":          "Statut :",
+		"Details":          "Détails",
+		"Estimate Price":   "Prix estimé",
+		"Created: %s":      "Créée le %s",
+		"Updated: %s":      "Modifiée le %s",
+		"Required":         "Obligatoire",
+		"Submit":           "Soumettre",
+		"Clean":            "Aucune info",
+		"Request ID:":      "ID de la requête :",
+		"Delete":           "Supprimer",
+		"Edit":             "Modifier",
+		"Back":             "Retour",
+		"Language":         "Langue",
+
+		// Dates
+		"just now":       "à l'instant",
+		"1 minute ago":   "il y a 1 minute",
+		"%d minutes ago": "il y a %d minutes",
+		"1 hour ago":     "il y a 1 heure",
+		"%d hours ago":   "il y a %d heures",
+		"yesterday":      "hier",
+		"%d days ago":    "il y a %d jours",
+		"Last change":    "Dernière modification",
+		"Time zone":      "Fuseau horaire",
+
+		"Your name": "Votre nom",
+
+		// Trash
+		"Trash":                                  "Corbeille",
+		"Sources":                                "Postes sources",
+		"Infos":                                  "Infos",
+		"Source":                                 "Poste source",
+		"Name":                                   "Nom",
+		"Deleted":                                "Supprimé",
+		"Deleted by":                             "Supprimé par",
+		"Restore":                                "Restaurer",
+		"Delete for good":                        "Supprimer définitivement",
+		"The trash is empty.":                    "La corbeille est vide.",
+		"Delete for good? This can't be undone.": "Supprimer définitivement ? C'est irréversible.",
+		"Deleted sources and infos are purged after %d days.": "Les postes sources et infos supprimés sont effacés après %d jours.",
+
+		// Source delete
+		"Delete %s": "Supprimer %s",
+		"These infos are attached to the source:": "Ces infos sont rattachées au poste source :",
+		"Move them to": "Les déplacer vers",
+		"Archive them, they go to the trash with the source": "Les archiver, elles vont à la corbeille avec le poste source",
+		"This source has no infos.":                          "Ce poste source n'a pas d'infos.",
+		"Move the source to the trash":                       "Mettre le poste source à la corbeille",
+		"Choose what to do with the infos":                   "Choisissez quoi faire des infos",
+		"A source with this name already exists":             "Un poste source porte déjà ce nom",
+		"Choose another source":                              "Choisissez un autre poste source",
+		"Merge":                                              "Fusionner",
+		"Merge %s":                                           "Fusionner %s",
+		"Merge into":                                         "Fusionner dans",
+		"Its infos and history go to the chosen source, its name is kept as an alias.": "Ses infos et son historique vont au poste source choisi, son nom est gardé comme alias.",
+		"There is no other source.": "Il n'y a pas d'autre poste source.",
+		"Also known as:":            "Aussi appelé :",
+		"History":                   "Historique",
+		"Move to":                   "Déplacer vers",
+		"Move":                      "Déplacer",
+		"These sources look alike, is it one of them?":  "Ces postes sources se ressemblent, est-ce l'un d'eux ?",
+		"No, save it anyway":                            "Non, enregistrer quand même",
+		"Possible duplicates":                           "Doublons possibles",
+		"These open infos of %s look like your report:": "Ces infos ouvertes de %s ressemblent à votre signalement :",
+		"Same defect":                                   "Même défaut",
+		"Your report:":                                  "Votre signalement :",
+		"It's a new defect":                             "C'est un nouveau défaut",
+		"Reported again":                                "Signalé à nouveau",
+		"Equipment":                                     "Équipements",
+		"Equipment:":                                    "Équipement :",
+		"Equipment of %s":                               "Équipements de %s",
+		"No equipment yet.":                             "Pas encore d'équipement.",
+		"Add equipment":                                 "Ajouter un équipement",
+		"Add":                                           "Ajouter",
+		"Kind":                                          "Type",
+		"Under":                                         "Sous",
+		"Defect history":                                "Historique des défauts",
+		"Created":                                       "Créée",
+		"No defect reported.":                           "Aucun défaut signalé.",
+		"No equipment":                                  "Sans équipement",
+		"Choose a kind":                                 "Choisissez un type",
+		"Choose another parent":                         "Choisissez un autre parent",
+		"Choose another equipment":                      "Choisissez un autre équipement",
+		"100 characters max":                            "100 caractères maximum",
+
+		// Equipment kinds
+		"bay":         "travée",
+		"transformer": "transformateur",
+		"breaker":     "disjoncteur",
+		"other":       "autre",
+
+		"Tags":                    "Étiquettes",
+		"Tag":                     "Étiquette",
+		"Tags:":                   "Étiquettes :",
+		"All":                     "Toutes",
+		"Search":                  "Rechercher",
+		"Material, details...":    "Matériel, détails...",
+		"No info found.":          "Aucune info trouvée.",
+		"No info with this tag.":  "Aucune info avec cette étiquette.",
+		"No tag yet.":             "Pas encore d'étiquette.",
+		"Add a tag":               "Ajouter une étiquette",
+		"Colour":                  "Couleur",
+		"Choose a colour":         "Choisissez une couleur",
+		"Choose existing tags":    "Choisissez des étiquettes existantes",
+		"This tag already exists": "Cette étiquette existe déjà",
+		"50 characters max":       "50 caractères maximum",
+
+		"Agents":                      "Agents",
+		"Team":                        "Équipe",
+		"Contact":                     "Contact",
+		"Open infos":                  "Infos ouvertes",
+		"inactive":                    "inactif",
+		"Active":                      "Actif",
+		"No agent yet.":               "Pas encore d'agent.",
+		"Add an agent":                "Ajouter un agent",
+		"Workload":                    "Charge de travail",
+		"Role":                        "Rôle",
+		"No open info.":               "Aucune info ouverte.",
+		"Reported by":                 "Signalée par",
+		"Assigned to":                 "Affectée à",
+		"Nobody":                      "Personne",
+		"Add agents to the directory": "Ajoutez des agents à l'annuaire",
+		"Phone, e-mail...":            "Téléphone, e-mail...",
+		"Choose an agent":             "Choisissez un agent",
+		"This agent already exists":   "Cet agent existe déjà",
+
+		"Due date":                 "Échéance",
+		"No team":                  "Aucune équipe",
+		"Due: %s":                  "Échéance : %s",
+		"Assign to":                "Affecter à",
+		"Assign":                   "Affecter",
+		"Assigned":                 "Affectée",
+		"My infos":                 "Mes infos",
+		"Who are you?":             "Qui êtes-vous ?",
+		"Nothing assigned to you.": "Rien ne vous est affecté.",
+		"Choose a team":            "Choisissez une équipe",
+		"Choose a date":            "Choisissez une date",
+
+		"Board":           "Tableau",
+		"Every source":    "Tous les postes sources",
+		"Every agent":     "Tous les agents",
+		"Show archived":   "Afficher les archivées",
+		"Filter":          "Filtrer",
+		"Choose a status": "Choisissez un statut",
+		"Drag a card to another column to change its status.":           "Glissez une carte dans une autre colonne pour changer son statut.",
+		"The status could not be changed. Open the info to correct it.": "Le statut n'a pas pu être changé. Ouvrez l'info pour la corriger.",
+
+		"Calendar":      "Calendrier",
+		"Week of %s":    "Semaine du %s",
+		"Previous":      "Précédent",
+		"Next":          "Suivant",
+		"Month":         "Mois",
+		"Week":          "Semaine",
+		"Planned date":  "Intervention prévue",
+		"Planned: %s":   "Intervention prévue : %s",
+		"Calendar link": "Lien du calendrier",
+		"New link":      "Nouveau lien",
+		"Create a link": "Créer un lien",
+		"Thunderbird, Outlook... can subscribe to this link. Anyone who has it can read the calendar, a new link stops the old one.": "Thunderbird, Outlook... peuvent s'abonner à ce lien. Quiconque l'a peut lire le calendrier, un nouveau lien désactive l'ancien.",
+
+		// Map @ cmd/map.go
+		"Map":            "Carte",
+		"GeoJSON export": "Export GeoJSON",
+		"On the map":     "Sur la carte",
+		"Not on the map": "Absents de la carte",
+		"%d open infos":  "%d infos ouvertes",
+		"Give them a latitude and a longitude on their page.": "Donnez-leur une latitude et une longitude sur leur page.",
+		"Address:":                                  "Adresse :",
+		"Latitude, longitude (decimal degrees):":    "Latitude, longitude (degrés décimaux) :",
+		"Enter both the latitude and the longitude": "Saisissez la latitude et la longitude",
+		"Between -90 and 90":                        "Entre -90 et 90",
+		"Between -180 and 180":                      "Entre -180 et 180",
+
+		// Labels @ cmd/qr.go
+		"Labels": "Étiquettes",
+		"Print":  "Imprimer",
+		"The links only hold a number, the labels stay right when the source is renamed or the info moved.": "Les liens ne contiennent qu'un numéro, les étiquettes restent bonnes si le poste source est renommé ou l'info déplacée.",
+
+		// Status labels
+		"waiting":  "en attente",
+		"affected": "affectée",
+		"done":     "terminée",
+		"archived": "archivée",
+
+		// Validator
+		"Cannot be empty":  "Ce champ ne doit pas être vide",
+		"Must be a number": "Doit être un nombre",
+
+		// Flash
+		"Source created":                    "Poste source créé",
+		"Source updated":                    "Poste source modifié",
+		"Source deleted":                    "Poste source supprimé",
+		"Info created":                      "Info créée",
+		"Info updated":                      "Info modifiée",
+		"Info deleted":                      "Info supprimée",
+		"Info moved to the trash":           "Info mise à la corbeille",
+		"Info restored":                     "Info restaurée",
+		"Info deleted for good":             "Info supprimée définitivement",
+		"Source moved to the trash":         "Poste source mis à la corbeille",
+		"Source restored":                   "Poste source restauré",
+		"Source deleted for good":           "Poste source supprimé définitivement",
+		"Source merged":                     "Poste source fusionné",
+		"Sources merged":                    "Postes sources fusionnés",
+		"Report added to the existing info": "Signalement ajouté à l'info existante",
+		"Equipment added":                   "Équipement ajouté",
+		"Equipment updated":                 "Équipement modifié",
+		"Equipment deleted":                 "Équipement supprimé",
+		"Tag created":                       "Étiquette créée",
+		"Tag updated":                       "Étiquette modifiée",
+		"Tag deleted":                       "Étiquette supprimée",
+		"Agent added":                       "Agent ajouté",
+		"Agent updated":                     "Agent modifié",
+		"Info assigned":                     "Info affectée",
+		"New calendar link":                 "Nouveau lien de calendrier",
+		"Info moved":                        "Info déplacée",
+
+		// Errors
+		"Bad Request":                          "Requête invalide",
+		"Forbidden":                            "Interdit",
+		"Not Found":                            "Page introuvable",
+		"Method Not Allowed":                   "Méthode non autorisée",
+		"Conflict":                             "Conflit",
+		"Unprocessable Entity":                 "Données invalides",
+		"Internal Server Error":                "Erreur du serveur",
+		"The request could not be understood.": "La requête n'a pas pu être comprise.",
+		"You are not allowed to do this.":      "Vous n'avez pas le droit de faire ceci.",
+		"This page does not exist or has been deleted.":                                                "Cette page n'existe pas ou a été supprimée.",
+		"The submitted data is not valid.":                                                             "Les données envoyées ne sont pas valides.",
+		"Something went wrong on our side. The error has been logged.":                                 "Une erreur s'est produite de notre côté. Elle a été enregistrée.",
+		"This source still has open infos, move or archive them first.":                                "Ce poste source a encore des infos ouvertes, déplacez-les ou archivez-les d'abord.",
+		"Another source has this name now, rename it before restoring this one.":                       "Un autre poste source porte ce nom maintenant, renommez-le avant de restaurer celui-ci.",
+		"This form has expired or was sent from another site. Go back, reload the page and try again.": "Ce formulaire a expiré ou vient d'un autre site. Revenez en arrière, rechargez la page et réessayez.",
+	},
+}
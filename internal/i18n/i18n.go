@@ -0,0 +1,221 @@
+// Package i18n translates the UI in English and French.
+// Messages are identified by their English text, so a message
+// missing from a catalog is still shown (in English)
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	English = "en"
+	French  = "fr"
+
+	Default = English
+)
+
+// Langs are the supported languages, in the order of the menu
+var Langs = []string{English, French}
+
+// Supported reports if lang has a catalog
+func Supported(lang string) bool {
+	for _, l := range Langs {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// Locale is the language and the time zone used
+// to render a request
+type Locale struct {
+	Lang string
+
+	// Dates are stored in UTC and shown in this zone,
+	// nil is UTC
+	TZ *time.Location
+}
+
+// T returns the translation of msg. args are used
+// with fmt.Sprintf when given
+func (l Locale) T(msg string, args ...any) string {
+	if tr, ok := catalogs[l.Lang][msg]; ok {
+		msg = tr
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+func (l Locale) location() *time.Location {
+	if l.TZ == nil {
+		return time.UTC
+	}
+	return l.TZ
+}
+
+// NoDate is shown instead of a zero time
+// (ex.: Updated of an info never updated)
+const NoDate = "-"
+
+// Date formats t the way the language writes dates:
+// Feb 10, 2023 / 10 févr. 2023
+func (l Locale) Date(t time.Time) string {
+	if t.IsZero() {
+		return NoDate
+	}
+
+	t = t.In(l.location())
+
+	if l.Lang == French {
+		return fmt.Sprintf("%d %s %d", t.Day(), frenchMonths[t.Month()-1], t.Year())
+	}
+	return t.Format("Jan 2, 2006")
+}
+
+// DateTime is Date with the hour:
+// Feb 10, 2023 7:28 PM / 10 févr. 2023 19:28
+func (l Locale) DateTime(t time.Time) string {
+	if t.IsZero() {
+		return NoDate
+	}
+
+	local := t.In(l.location())
+
+	if l.Lang == French {
+		return l.Date(t) + local.Format(" 15:04")
+	}
+	return l.Date(t) + local.Format(" 3:04 PM")
+}
+
+// Relative is the time since t ("3 days ago").
+// After a month, or for a date in the future, it's the date
+func (l Locale) Relative(t time.Time) string {
+	return l.relative(t, time.Now())
+}
+
+func (l Locale) relative(t, now time.Time) string {
+	if t.IsZero() {
+		return NoDate
+	}
+
+	d := now.Sub(t)
+
+	switch {
+	case d < 0:
+		return l.Date(t)
+	case d < time.Minute:
+		return l.T("just now")
+	case d < 2*time.Minute:
+		return l.T("1 minute ago")
+	case d < time.Hour:
+		return l.T("%d minutes ago", int(d.Minutes()))
+	case d < 2*time.Hour:
+		return l.T("1 hour ago")
+	case d < 24*time.Hour:
+		return l.T("%d hours ago", int(d.Hours()))
+	case d < 48*time.Hour:
+		return l.T("yesterday")
+	case d < 30*24*time.Hour:
+		return l.T("%d days ago", int(d.Hours()/24))
+	}
+
+	return l.Date(t)
+}
+
+var frenchMonths = [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin",
+	"juil.", "août", "sept.", "oct.", "nov.", "déc."}
+
+// Month is the month of a calendar day, as a title:
+// February 2023 / février 2023. t isn't moved to the
+// time zone, a day stored at midnight UTC stays the same
+func (l Locale) Month(t time.Time) string {
+	if l.Lang == French {
+		return fmt.Sprintf("%s %d", frenchMonthNames[t.Month()-1], t.Year())
+	}
+	return t.Format("January 2006")
+}
+
+// Weekday is the short name of a day of the week: Mon / lun.
+func (l Locale) Weekday(d time.Weekday) string {
+	if l.Lang == French {
+		return frenchWeekdays[d]
+	}
+	return d.String()[:3]
+}
+
+var frenchMonthNames = [12]string{"janvier", "février", "mars", "avril", "mai",
+	"juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"}
+
+var frenchWeekdays = [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."}
+
+// Match returns the best supported language of an
+// Accept-Language header, ex.: "fr-CA,fr;q=0.9,en;q=0.8"
+func Match(header string) string {
+	type choice struct {
+		lang string
+		q    float64
+	}
+
+	choices := []choice{}
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		// fr-CA ~> fr
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if !Supported(lang) {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			choices = append(choices, choice{lang, q})
+		}
+	}
+
+	if len(choices) == 0 {
+		return Default
+	}
+
+	sort.SliceStable(choices, func(i, j int) bool {
+		return choices[i].q > choices[j].q
+	})
+
+	return choices[0].lang
+}
+
+// Key type so nobody else can overwrite the locale
+// stored inside a context
+type contextKey string
+
+const localeKey = contextKey("locale")
+
+// WithLocale returns a copy of ctx holding l
+func WithLocale(ctx context.Context, l Locale) context.Context {
+	return context.WithValue(ctx, localeKey, l)
+}
+
+// FromContext returns the locale stored in ctx,
+// or the default one (English, UTC)
+func FromContext(ctx context.Context) Locale {
+	l, ok := ctx.Value(localeKey).(Locale)
+	if !ok {
+		return Locale{Lang: Default, TZ: time.UTC}
+	}
+	return l
+}
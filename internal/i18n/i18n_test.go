@@ -0,0 +1,150 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", English},
+		{"fr", French},
+		{"fr-CA,fr;q=0.9,en;q=0.8", French},
+		{"en-US,en;q=0.9,fr;q=0.8", English},
+		{"de,fr;q=0.5", French},
+		{"fr;q=0.2,en;q=0.7", English},
+		{"fr;q=0", English},
+		{"de-DE", English},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.header); got != tt.want {
+			t.Errorf("Match(%q) = %q; want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	fr := Locale{Lang: French}
+	en := Locale{Lang: English}
+
+	if got := fr.T("Cannot be empty"); got != "Ce champ ne doit pas être vide" {
+		t.Errorf("got %q", got)
+	}
+	if got := en.T("Cannot be empty"); got != "Cannot be empty" {
+		t.Errorf("got %q", got)
+	}
+
+	// Unknown messages are kept
+	if got := fr.T("Unknown message"); got != "Unknown message" {
+		t.Errorf("got %q", got)
+	}
+
+	if got := fr.T("Created: %s", "10 févr. 2023"); got != "Créée le 10 févr. 2023" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestDate(t *testing.T) {
+	d := time.Date(2023, time.February, 10, 19, 28, 53, 0, time.UTC)
+
+	if got := (Locale{Lang: French}).Date(d); got != "10 févr. 2023" {
+		t.Errorf("got %q", got)
+	}
+	if got := (Locale{Lang: English}).Date(d); got != "Feb 10, 2023" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestMonth(t *testing.T) {
+	d := time.Date(2023, time.February, 1, 0, 0, 0, 0, time.UTC)
+	paris, _ := time.LoadLocation("Europe/Paris")
+
+	// A calendar day: the zone doesn't change the month
+	if got := (Locale{Lang: French, TZ: paris}).Month(d); got != "février 2023" {
+		t.Errorf("got %q", got)
+	}
+	if got := (Locale{Lang: English}).Month(d); got != "February 2023" {
+		t.Errorf("got %q", got)
+	}
+	if got := (Locale{Lang: French}).Weekday(d.Weekday()); got != "mer." {
+		t.Errorf("got %q", got)
+	}
+	if got := (Locale{Lang: English}).Weekday(d.Weekday()); got != "Wed" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()); got.Lang != Default {
+		t.Errorf("got %q; want %q", got.Lang, Default)
+	}
+
+	ctx := WithLocale(context.Background(), Locale{Lang: French})
+	if got := FromContext(ctx); got.Lang != French {
+		t.Errorf("got %q; want %q", got.Lang, French)
+	}
+}
+
+func TestDateTimeZone(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// 00:30 in Paris is still the day before in UTC
+	d := time.Date(2023, time.February, 9, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"UTC date", Locale{Lang: French}.Date(d), "9 févr. 2023"},
+		{"Paris date", Locale{Lang: French, TZ: paris}.Date(d), "10 févr. 2023"},
+		{"Paris datetime fr", Locale{Lang: French, TZ: paris}.DateTime(d), "10 févr. 2023 00:30"},
+		{"Paris datetime en", Locale{Lang: English, TZ: paris}.DateTime(d), "Feb 10, 2023 12:30 AM"},
+		{"Zero date", Locale{Lang: French}.Date(time.Time{}), NoDate},
+		{"Zero datetime", Locale{Lang: English}.DateTime(time.Time{}), NoDate},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q; want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestRelative(t *testing.T) {
+	now := time.Date(2023, time.February, 10, 12, 0, 0, 0, time.UTC)
+	fr := Locale{Lang: French}
+	en := Locale{Lang: English}
+
+	tests := []struct {
+		l    Locale
+		t    time.Time
+		want string
+	}{
+		{en, now.Add(-10 * time.Second), "just now"},
+		{en, now.Add(-90 * time.Second), "1 minute ago"},
+		{en, now.Add(-5 * time.Minute), "5 minutes ago"},
+		{fr, now.Add(-5 * time.Minute), "il y a 5 minutes"},
+		{en, now.Add(-3 * time.Hour), "3 hours ago"},
+		{fr, now.Add(-30 * time.Hour), "hier"},
+		{en, now.Add(-3 * 24 * time.Hour), "3 days ago"},
+		{fr, now.Add(-3 * 24 * time.Hour), "il y a 3 jours"},
+		{en, now.Add(-60 * 24 * time.Hour), "Dec 12, 2022"},
+		{en, now.Add(time.Hour), "Feb 10, 2023"},
+		{en, time.Time{}, NoDate},
+	}
+
+	for _, tt := range tests {
+		if got := tt.l.relative(tt.t, now); got != tt.want {
+			t.Errorf("relative(%v) = %q; want %q", tt.t, got, tt.want)
+		}
+	}
+}
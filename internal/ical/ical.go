@@ -0,0 +1,117 @@
+// Package ical writes iCalendar feeds (RFC 5545) of all-day
+// events, the format Thunderbird and Outlook subscribe to
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dayLayout   = "20060102"
+	stampLayout = "20060102T150405Z"
+
+	// Lines longer than this (in bytes, CRLF excluded)
+	// are folded
+	lineLength = 75
+)
+
+// Event is one all-day event. UID must stay the same when
+// the event changes, Stamp is the time of its last change
+type Event struct {
+	UID         string
+	Stamp       time.Time
+	Day         time.Time
+	Summary     string
+	Description string
+	URL         string
+}
+
+// Calendar is a feed. Name is shown by the client
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Write writes the calendar to w
+func (c *Calendar) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	line := func(name, value string) {
+		fold(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//CURATOR//Calendar//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", escape(c.Name))
+
+	// Clients read the feed again every hour
+	line("REFRESH-INTERVAL;VALUE=DURATION", "PT1H")
+	line("X-PUBLISHED-TTL", "PT1H")
+
+	for _, e := range c.Events {
+		day := time.Date(e.Day.Year(), e.Day.Month(), e.Day.Day(),
+			0, 0, 0, 0, time.UTC)
+
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID)
+		line("DTSTAMP", e.Stamp.UTC().Format(stampLayout))
+		line("DTSTART;VALUE=DATE", day.Format(dayLayout))
+		line("DTEND;VALUE=DATE", day.AddDate(0, 0, 1).Format(dayLayout))
+		line("SUMMARY", escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escape(e.Description))
+		}
+		if e.URL != "" {
+			line("URL", e.URL)
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+
+	return bw.Flush()
+}
+
+// escape escapes a TEXT value: backslash, semicolon,
+// comma and new lines
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
+
+// fold writes a content line ending with CRLF. A long line
+// goes on with a space at the start of the next one, never
+// in the middle of a UTF-8 character
+func fold(w *bufio.Writer, s string) {
+	limit := lineLength
+
+	for len(s) > limit {
+		i := limit
+		for i > 0 && !utf8.RuneStart(s[i]) {
+			i--
+		}
+
+		w.WriteString(s[:i])
+		w.WriteString("\r\n ")
+		s = s[i:]
+
+		// The space counts in the next line
+		limit = lineLength - 1
+	}
+
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
@@ -0,0 +1,76 @@
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	c := &Calendar{
+		Name: "CURATOR, Billancourt",
+		Events: []Event{{
+			UID:         "info-1-due@curator",
+			Stamp:       time.Date(2024, 5, 2, 10, 30, 0, 0, time.UTC),
+			Day:         time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+			Summary:     "Transfo 1; oil leak",
+			Description: "Line 1\nLine 2",
+			URL:         "http://localhost:3005/source/1/info/view/1",
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := c.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:CURATOR\\, Billancourt\r\n",
+		"DTSTAMP:20240502T103000Z\r\n",
+		"DTSTART;VALUE=DATE:20240601\r\n",
+		"DTEND;VALUE=DATE:20240602\r\n",
+		"SUMMARY:Transfo 1\\; oil leak\r\n",
+		"DESCRIPTION:Line 1\\nLine 2\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%q is missing in:\n%s", want, got)
+		}
+	}
+}
+
+func TestFold(t *testing.T) {
+	// 2 bytes per "é", the lines can't be cut in the middle
+	long := "SUMMARY:" + strings.Repeat("é", 100)
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	fold(w, long)
+	w.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	if len(lines) < 3 {
+		t.Fatalf("got %d lines; want 3 or more", len(lines))
+	}
+
+	joined := lines[0]
+	for _, l := range lines {
+		if len(l) > lineLength {
+			t.Errorf("line of %d bytes", len(l))
+		}
+	}
+	for _, l := range lines[1:] {
+		if !strings.HasPrefix(l, " ") {
+			t.Errorf("%q doesn't start with a space", l)
+		}
+		joined += l[1:]
+	}
+
+	if joined != long {
+		t.Errorf("unfolded line is not the same")
+	}
+}
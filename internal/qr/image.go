@@ -0,0 +1,53 @@
+package qr
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// QuietZone is the light border readers need around
+// the code, in modules
+const QuietZone = 4
+
+// PNG writes the code with scale pixels a module
+func (c *Code) PNG(w io.Writer, scale int) error {
+	side := (c.Size + 2*QuietZone) * scale
+	palette := color.Palette{color.White, color.Black}
+	img := image.NewPaletted(image.Rect(0, 0, side, side), palette)
+
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			if c.Dark(x/scale-QuietZone, y/scale-QuietZone) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// SVG writes the code as a path, a unit a module. It
+// scales to the size it's given in the page
+func (c *Code) SVG(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	side := c.Size + 2*QuietZone
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" `+
+		`shape-rendering="crispEdges">`, side, side)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="#fff"/>`, side, side)
+	bw.WriteString(`<path fill="#000" d="`)
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				fmt.Fprintf(bw, "M%d,%dh1v1h-1z", x+QuietZone, y+QuietZone)
+			}
+		}
+	}
+	bw.WriteString(`"/></svg>`)
+
+	return bw.Flush()
+}
@@ -0,0 +1,499 @@
+// Package qr makes QR codes (ISO/IEC 18004) of short texts, the
+// links printed on the labels. Only what they need: byte mode,
+// error correction level M (15% of the code can be lost),
+// versions 1 to 10, that is up to 213 bytes
+package qr
+
+import (
+	"errors"
+)
+
+// ErrTooLong is returned for a text over 213 bytes
+var ErrTooLong = errors.New("qr: text too long")
+
+// Code is a QR code, Size modules a side. A dark module is true
+type Code struct {
+	Size    int
+	Version int
+	Mask    int
+
+	modules [][]bool
+	// Finder, timing, alignment, format and version
+	// modules, not data
+	function [][]bool
+}
+
+// Dark reports if the module at column x and row y is dark.
+// Outside the code (the quiet zone) it's light
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y][x]
+}
+
+// Blocks of a version at level M: the error correction codewords
+// of each block, then the blocks and their data codewords
+type blockSpec struct {
+	ec     int
+	groups [][2]int // count, data codewords
+}
+
+var versions = [...]blockSpec{
+	1:  {10, [][2]int{{1, 16}}},
+	2:  {16, [][2]int{{1, 28}}},
+	3:  {26, [][2]int{{1, 44}}},
+	4:  {18, [][2]int{{2, 32}}},
+	5:  {24, [][2]int{{2, 43}}},
+	6:  {16, [][2]int{{4, 27}}},
+	7:  {18, [][2]int{{4, 31}}},
+	8:  {22, [][2]int{{2, 38}, {2, 39}}},
+	9:  {22, [][2]int{{3, 36}, {2, 37}}},
+	10: {26, [][2]int{{4, 43}, {1, 44}}},
+}
+
+// Centres of the alignment patterns, on both axes
+var alignments = [...][]int{
+	2:  {6, 18},
+	3:  {6, 22},
+	4:  {6, 26},
+	5:  {6, 30},
+	6:  {6, 34},
+	7:  {6, 22, 38},
+	8:  {6, 24, 42},
+	9:  {6, 26, 46},
+	10: {6, 28, 50},
+}
+
+// dataCodewords is the data a version holds, in bytes
+func (b blockSpec) dataCodewords() int {
+	n := 0
+	for _, g := range b.groups {
+		n += g[0] * g[1]
+	}
+	return n
+}
+
+// Encode returns the smallest QR code of text
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+
+	for version := 1; version < len(versions); version++ {
+		// Mode, length (16 bits from version 10) and data
+		countBits := 8
+		if version >= 10 {
+			countBits = 16
+		}
+		capacity := versions[version].dataCodewords()
+		if 4+countBits+8*len(data) > 8*capacity {
+			continue
+		}
+
+		bits := &bitBuffer{}
+		bits.append(0b0100, 4)
+		bits.append(len(data), countBits)
+		for _, b := range data {
+			bits.append(int(b), 8)
+		}
+
+		// Terminator, then whole bytes, then the pad bytes
+		bits.append(0, min(4, 8*capacity-bits.n))
+		bits.append(0, (8-bits.n%8)%8)
+		for pad := 0xEC; bits.n < 8*capacity; pad ^= 0xEC ^ 0x11 {
+			bits.append(pad, 8)
+		}
+
+		return newCode(version, interleave(version, bits.bytes)), nil
+	}
+
+	return nil, ErrTooLong
+}
+
+type bitBuffer struct {
+	bytes []byte
+	n     int
+}
+
+// append writes the count low bits of v, highest first
+func (b *bitBuffer) append(v, count int) {
+	for i := count - 1; i >= 0; i-- {
+		if b.n%8 == 0 {
+			b.bytes = append(b.bytes, 0)
+		}
+		if v>>i&1 == 1 {
+			b.bytes[b.n/8] |= 0x80 >> (b.n % 8)
+		}
+		b.n++
+	}
+}
+
+// interleave splits data in blocks, adds their error correction
+// and mixes them: the first byte of each block, the second...
+func interleave(version int, data []byte) []byte {
+	spec := versions[version]
+	divisor := rsDivisor(spec.ec)
+
+	blocks := [][]byte{}
+	ecs := [][]byte{}
+	for _, g := range spec.groups {
+		for i := 0; i < g[0]; i++ {
+			block := data[:g[1]]
+			data = data[g[1]:]
+			blocks = append(blocks, block)
+			ecs = append(ecs, rsRemainder(block, divisor))
+		}
+	}
+
+	result := []byte{}
+	longest := len(blocks[len(blocks)-1])
+	for i := 0; i < longest; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < spec.ec; i++ {
+		for _, ec := range ecs {
+			result = append(result, ec[i])
+		}
+	}
+
+	return result
+}
+
+// newCode draws the code of the codewords with the mask
+// that leaves the fewest patterns a reader could mistake
+func newCode(version int, codewords []byte) *Code {
+	size := 4*version + 17
+	c := &Code{Size: size, Version: version}
+	c.modules = make([][]bool, size)
+	c.function = make([][]bool, size)
+	for i := range c.modules {
+		c.modules[i] = make([]bool, size)
+		c.function[i] = make([]bool, size)
+	}
+
+	c.drawFunctions()
+	c.drawCodewords(codewords)
+
+	best, lowest := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormat(mask)
+		if p := c.penalty(); lowest < 0 || p < lowest {
+			best, lowest = mask, p
+		}
+		// XOR again takes it off
+		c.applyMask(mask)
+	}
+
+	c.Mask = best
+	c.applyMask(best)
+	c.drawFormat(best)
+
+	return c
+}
+
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.function[y][x] = true
+}
+
+// drawFunctions draws everything but the data. The format
+// is reserved here, drawn with the mask
+func (c *Code) drawFunctions() {
+	size := c.Size
+
+	for i := 0; i < size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+
+	// Finders and their light separators
+	for _, p := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := p[0]+dx, p[1]+dy
+				if x < 0 || y < 0 || x >= size || y >= size {
+					continue
+				}
+				d := max(abs(dx), abs(dy))
+				c.set(x, y, d != 2 && d != 4)
+			}
+		}
+	}
+
+	// Alignments, not over the finders
+	centres := alignments[c.Version]
+	last := len(centres) - 1
+	for i, cy := range centres {
+		for j, cx := range centres {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	c.drawFormat(0)
+
+	// Version 7 and more have it in two 6x3 blocks
+	if c.Version >= 7 {
+		bits := versionBits(c.Version)
+		for i := 0; i < 18; i++ {
+			dark := bits>>i&1 == 1
+			a, b := size-11+i%3, i/3
+			c.set(a, b, dark)
+			c.set(b, a, dark)
+		}
+	}
+}
+
+// formatBits are the 15 bits of the level (M) and the mask
+func formatBits(mask int) int {
+	data := 0b00<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// versionBits are the 18 bits of the version
+func versionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	return version<<12 | rem
+}
+
+// drawFormat writes the format twice: around the top left
+// finder, and split under the top right and beside
+// the bottom left ones
+func (c *Code) drawFormat(mask int) {
+	bits := formatBits(mask)
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+	size := c.Size
+
+	for i := 0; i <= 5; i++ {
+		c.set(8, i, bit(i))
+	}
+	c.set(8, 7, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.set(size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, size-15+i, bit(i))
+	}
+
+	// Always dark
+	c.set(8, size-8, true)
+}
+
+// drawCodewords fills the data modules two columns at a time,
+// from the bottom right, going up then down
+func (c *Code) drawCodewords(codewords []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		// The vertical timing pattern is skipped
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if c.function[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				c.modules[y][x] = codewords[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// masked reports if mask turns the module at x, y over
+func masked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	}
+	return ((x+y)%2+x*y%3)%2 == 0
+}
+
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.function[y][x] && masked(mask, x, y) {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the code as the standard does: runs of the
+// same colour, 2x2 squares, finder-like patterns and the
+// balance of dark and light
+func (c *Code) penalty() int {
+	size := c.Size
+	score := 0
+
+	// Rows, then columns
+	for _, at := range []func(i, j int) bool{
+		func(i, j int) bool { return c.modules[i][j] },
+		func(i, j int) bool { return c.modules[j][i] },
+	} {
+		for i := 0; i < size; i++ {
+			run := 1
+			for j := 1; j <= size; j++ {
+				if j < size && at(i, j) == at(i, j-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += run - 2
+				}
+				run = 1
+			}
+
+			// 1:1:3:1:1 with 4 light modules on a side,
+			// the quiet zone is light
+			line := make([]bool, size+8)
+			for j := 0; j < size; j++ {
+				line[j+4] = at(i, j)
+			}
+			for j := 0; j+11 <= len(line); j++ {
+				if finderLike(line[j:j+11], false) || finderLike(line[j:j+11], true) {
+					score += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x > 0 && y > 0 {
+				v := c.modules[y][x]
+				if c.modules[y-1][x] == v && c.modules[y][x-1] == v &&
+					c.modules[y-1][x-1] == v {
+					score += 3
+				}
+			}
+		}
+	}
+
+	// 10 for each 5% away from half dark
+	total := size * size
+	k := (abs(dark*20-total*10) + total - 1) / total
+	score += max(0, k-1) * 10
+
+	return score
+}
+
+// finderLike is dark-light-dark dark dark-light-dark and 4 light
+// modules, after them or (reversed) before them
+func finderLike(w []bool, reversed bool) bool {
+	pattern := [11]bool{true, false, true, true, true, false, true}
+	for i, want := range pattern {
+		j := i
+		if reversed {
+			j = 10 - i
+		}
+		if w[j] != want {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+//
+// Reed-Solomon in GF(256), polynomial x⁸+x⁴+x³+x²+1
+//
+
+func gfMul(x, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		// z *= 2, reduced
+		carry := z >> 7
+		z = z<<1 ^ carry*0x1D
+		if y>>i&1 == 1 {
+			z ^= x
+		}
+	}
+	return z
+}
+
+// rsDivisor is the generator polynomial of degree n, its
+// coefficients from the highest power, the leading 1 left out
+func rsDivisor(n int) []byte {
+	result := make([]byte, n)
+	result[n-1] = 1
+
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < n {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+
+	return result
+}
+
+// rsRemainder is the error correction of data
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMul(d, factor)
+		}
+	}
+
+	return result
+}
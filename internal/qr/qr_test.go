@@ -0,0 +1,316 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// "HELLO WORLD" in version 1-M, from the tutorial
+	// of thonky.com
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	got := rsRemainder(data, rsDivisor(10))
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	for mask, want := range []string{
+		"101010000010010", "101000100100101", "101111001111100", "101101101001011",
+		"100010111111001", "100000011001110", "100111110010111", "100101010100000",
+	} {
+		if got := fmt.Sprintf("%015b", formatBits(mask)); got != want {
+			t.Errorf("mask %d: got %s; want %s", mask, got, want)
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	for version, want := range map[int]string{
+		7:  "000111110010010100",
+		10: "001010010011010011",
+	} {
+		if got := fmt.Sprintf("%018b", versionBits(version)); got != want {
+			t.Errorf("version %d: got %s; want %s", version, got, want)
+		}
+	}
+}
+
+// decode reads the text of c back: format, mask, codewords,
+// error correction of each block, and the byte mode segment
+func decode(t *testing.T, c *Code) string {
+	t.Helper()
+
+	// Format around the top left finder
+	format := 0
+	for i := 0; i <= 5; i++ {
+		format |= b2i(c.Dark(8, i)) << i
+	}
+	format |= b2i(c.Dark(8, 7))<<6 | b2i(c.Dark(8, 8))<<7 | b2i(c.Dark(7, 8))<<8
+	for i := 9; i < 15; i++ {
+		format |= b2i(c.Dark(14-i, 8)) << i
+	}
+	format ^= 0x5412
+	if level := format >> 13; level != 0b00 {
+		t.Fatalf("got level %02b; want M (00)", level)
+	}
+	mask := format >> 10 & 7
+
+	// The same modules in the same order, unmasked
+	spec := versions[c.Version]
+	total := spec.dataCodewords() + spec.ec*len(blocksOf(spec))
+	codewords := make([]byte, total)
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if c.function[y][x] || i >= total*8 {
+					continue
+				}
+				if c.Dark(x, y) != masked(mask, x, y) {
+					codewords[i/8] |= 0x80 >> (i % 8)
+				}
+				i++
+			}
+		}
+	}
+
+	// Back in blocks, each a multiple of the generator:
+	// zero at α⁰...α^(ec-1)
+	sizes := blocksOf(spec)
+	blocks := make([][]byte, len(sizes))
+	k := 0
+	for n := 0; n < sizes[len(sizes)-1]+spec.ec; n++ {
+		for b, size := range sizes {
+			if n < size || n >= sizes[len(sizes)-1] {
+				blocks[b] = append(blocks[b], codewords[k])
+				k++
+			}
+		}
+	}
+
+	data := []byte{}
+	for b, block := range blocks {
+		root := byte(1)
+		for r := 0; r < spec.ec; r++ {
+			var v byte
+			for _, cw := range block {
+				v = gfMul(v, root) ^ cw
+			}
+			if v != 0 {
+				t.Fatalf("block %d: syndrome %d is %d", b, r, v)
+			}
+			root = gfMul(root, 2)
+		}
+		data = append(data, block[:sizes[b]]...)
+	}
+
+	// Byte mode and its length
+	if data[0]>>4 != 0b0100 {
+		t.Fatalf("got mode %04b", data[0]>>4)
+	}
+	bits := &bitReader{data: data, n: 4}
+	length := bits.read(8)
+	if c.Version >= 10 {
+		length = length<<8 | bits.read(8)
+	}
+	text := make([]byte, length)
+	for n := range text {
+		text[n] = byte(bits.read(8))
+	}
+
+	return string(text)
+}
+
+func blocksOf(spec blockSpec) []int {
+	sizes := []int{}
+	for _, g := range spec.groups {
+		for i := 0; i < g[0]; i++ {
+			sizes = append(sizes, g[1])
+		}
+	}
+	return sizes
+}
+
+type bitReader struct {
+	data []byte
+	n    int
+}
+
+func (b *bitReader) read(count int) int {
+	v := 0
+	for i := 0; i < count; i++ {
+		v = v<<1 | int(b.data[b.n/8]>>(7-b.n%8)&1)
+		b.n++
+	}
+	return v
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		text    string
+		version int
+	}{
+		{"http://a/s/1", 1},
+		{"http://curator.example.org:3005/i/123456", 3},
+		{"https://curator.example.org/" + strings.Repeat("é", 40), 7},
+		{strings.Repeat("x", 150), 8},
+		{strings.Repeat("x", 213), 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d bytes", len(tt.text)), func(t *testing.T) {
+			c, err := Encode(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Version != tt.version || c.Size != 4*tt.version+17 {
+				t.Errorf("got version %d, size %d; want version %d",
+					c.Version, c.Size, tt.version)
+			}
+
+			// Finders: a dark ring, a light one and a dark centre
+			for _, corner := range [][2]int{{0, 0}, {c.Size - 7, 0}, {0, c.Size - 7}} {
+				x, y := corner[0], corner[1]
+				if !c.Dark(x, y) || c.Dark(x+1, y+1) || !c.Dark(x+3, y+3) {
+					t.Errorf("no finder at %d,%d", x, y)
+				}
+			}
+
+			if got := decode(t, c); got != tt.text {
+				t.Errorf("got %q; want %q", got, tt.text)
+			}
+		})
+	}
+
+	if _, err := Encode(strings.Repeat("x", 214)); !errors.Is(err, ErrTooLong) {
+		t.Errorf("got %v; want ErrTooLong", err)
+	}
+}
+
+// testdata/*.txt are codes of another encoder, rsc.io/qr/coding
+// (NewPlan(version, M, mask).Encode(coding.String(text))), one
+// row a line, # dark and . light. decode only reads the modules
+// back the way they were written, these check the writing
+func golden(t *testing.T, c *Code, name string) {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name+".txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(rows) != c.Size {
+		t.Fatalf("%s: got size %d; want %d", name, c.Size, len(rows))
+	}
+
+	for y, row := range rows {
+		for x := range row {
+			if c.Dark(x, y) != (row[x] == '#') {
+				t.Errorf("%s: module %d,%d differs", name, x, y)
+			}
+		}
+	}
+}
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		mask int
+	}{
+		{"v1", "http://a/s/1", 2},
+		{"v3-mask2", "http://curator.example.org:3005/i/123456", 2},
+		{"v7", "https://curator.example.org/" + strings.Repeat("é", 40), 2},
+		{"v8", strings.Repeat("x", 150), 2},
+		{"v10", strings.Repeat("x", 213), 0},
+	}
+
+	for _, tt := range tests {
+		c, err := Encode(tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Mask != tt.mask {
+			t.Errorf("%s: got mask %d; want %d", tt.name, c.Mask, tt.mask)
+			continue
+		}
+		golden(t, c, tt.name)
+	}
+
+	// The same code with each of the 8 masks
+	c, err := Encode("http://curator.example.org:3005/i/123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.applyMask(c.Mask)
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormat(mask)
+		golden(t, c, fmt.Sprintf("v3-mask%d", mask))
+		c.applyMask(mask)
+	}
+}
+
+func TestImages(t *testing.T) {
+	c, err := Encode("http://localhost:3005/s/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	side := c.Size + 2*QuietZone
+
+	var buf bytes.Buffer
+	if err = c.PNG(&buf, 4); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != side*4 || b.Dy() != side*4 {
+		t.Errorf("got %v; want %dx%d", b, side*4, side*4)
+	}
+
+	// The top left pixel is the quiet zone, the finder starts after it
+	if r, _, _, _ := img.At(0, 0).RGBA(); r == 0 {
+		t.Error("the quiet zone is dark")
+	}
+	if r, _, _, _ := img.At(QuietZone*4, QuietZone*4).RGBA(); r != 0 {
+		t.Error("the finder is light")
+	}
+
+	buf.Reset()
+	if err = c.SVG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+	if !strings.Contains(svg, fmt.Sprintf(`viewBox="0 0 %d %d"`, side, side)) {
+		t.Errorf("got %s", svg)
+	}
+	if !strings.Contains(svg, fmt.Sprintf("M%d,%dh1v1h-1z", QuietZone, QuietZone)) {
+		t.Error("the finder is missing")
+	}
+}
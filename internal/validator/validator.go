@@ -1,6 +1,8 @@
 package validator
 
 import (
+	"regexp"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 )
@@ -45,3 +47,24 @@ func NotBlank(value string) bool {
 func MaxChars(value string, n int) bool {
 	return utf8.RuneCountInString(value) <= n
 }
+
+// Retourne vrai si la valeur est un nombre entier
+func IsInt(value string) bool {
+	_, err := strconv.Atoi(strings.TrimSpace(value))
+	return err == nil
+}
+
+// Retourne vrai si la valeur est une des valeurs permises
+func PermittedValue[T comparable](value T, permittedValues ...T) bool {
+	for _, v := range permittedValues {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Retourne vrai si la valeur correspond à l'expression rationnelle
+func Matches(value string, rx *regexp.Regexp) bool {
+	return rx.MatchString(value)
+}
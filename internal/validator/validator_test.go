@@ -0,0 +1,54 @@
+package validator
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestValidator(t *testing.T) {
+	var v Validator
+
+	v.CheckField(NotBlank("  "), "name", "first")
+	v.CheckField(NotBlank(""), "name", "second")
+	v.CheckField(IsInt("12"), "priority", "not a number")
+
+	if v.Valid() {
+		t.Fatal("got valid; want invalid")
+	}
+	// Only the first message of a field is kept
+	if v.FieldErrors["name"] != "first" {
+		t.Errorf("got %q; want first", v.FieldErrors["name"])
+	}
+	if _, ok := v.FieldErrors["priority"]; ok {
+		t.Error("12 is a number")
+	}
+}
+
+func TestMaxChars(t *testing.T) {
+	if !MaxChars("été", 3) {
+		t.Error("été has 3 characters")
+	}
+	if MaxChars("abcd", 3) {
+		t.Error("abcd has more than 3 characters")
+	}
+}
+
+func TestPermittedValue(t *testing.T) {
+	if !PermittedValue("move", "move", "archive") {
+		t.Error("move is permitted")
+	}
+	if PermittedValue("drop", "move", "archive") {
+		t.Error("drop is not permitted")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	rx := regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+	if !Matches("#1abc9c", rx) {
+		t.Error("#1abc9c matches")
+	}
+	if Matches("red", rx) {
+		t.Error("red does not match")
+	}
+}
@@ -0,0 +1,11 @@
+package ui
+
+import (
+	"embed"
+)
+
+// Templates and static files are built into the binary,
+// so it can run from any directory
+//
+//go:embed "html" "static"
+var Files embed.FS
@@ -0,0 +1,80 @@
+// Drag and drop of the board (/board). A card dropped on another
+// column posts its new status, checked like the edit page
+// (cmd/board.go). Refused, the card goes back to its column
+
+document.addEventListener("DOMContentLoaded", function() {
+  const board = document.getElementById("board");
+  if (board == null) {
+    return;
+  }
+
+  // Sent in the X-CSRF-Token header (cmd/csrf.go)
+  const token = document.querySelector('meta[name="csrf-token"]').content;
+  let dragged = null;
+
+  function counts() {
+    board.querySelectorAll(".board-column").forEach(function(column) {
+      column.querySelector(".board-count").textContent =
+        column.querySelectorAll(".board-card").length;
+    });
+  }
+
+  board.querySelectorAll(".board-card").forEach(function(card) {
+    card.addEventListener("dragstart", function(e) {
+      dragged = card;
+      e.dataTransfer.effectAllowed = "move";
+      e.dataTransfer.setData("text/plain", card.dataset.url);
+    });
+    card.addEventListener("dragend", function() {
+      dragged = null;
+    });
+  });
+
+  board.querySelectorAll(".board-column").forEach(function(column) {
+    column.addEventListener("dragover", function(e) {
+      if (dragged != null) {
+        e.preventDefault();
+        column.classList.add("board-over");
+      }
+    });
+    column.addEventListener("dragleave", function() {
+      column.classList.remove("board-over");
+    });
+
+    column.addEventListener("drop", function(e) {
+      e.preventDefault();
+      column.classList.remove("board-over");
+
+      const card = dragged;
+      if (card == null) {
+        return;
+      }
+      const from = card.closest(".board-column");
+      if (from === column) {
+        return;
+      }
+
+      // Moved at once, put back if the server refuses
+      column.querySelector(".board-cards").appendChild(card);
+      counts();
+
+      fetch(card.dataset.url, {
+        method: "POST",
+        headers: {
+          "X-CSRF-Token": token,
+          "Accept": "application/json",
+        },
+        body: new URLSearchParams({ status: column.dataset.status }),
+        credentials: "same-origin",
+      }).then(function(res) {
+        if (!res.ok) {
+          throw new Error(res.status);
+        }
+      }).catch(function() {
+        from.querySelector(".board-cards").appendChild(card);
+        counts();
+        alert(board.dataset.error);
+      });
+    });
+  });
+});
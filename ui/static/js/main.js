@@ -83,7 +83,7 @@ document.addEventListener("DOMContentLoaded", function() {
     elements[i].oninvalid = function(e) {
       e.target.setCustomValidity("");
       if (!e.target.validity.valid) {
-        e.target.setCustomValidity("Ce champ ne doit pas être vide");
+        e.target.setCustomValidity(document.body.dataset.required);
         if (srcName != null) {
           srcName.classList.add('inptAlert');
         }